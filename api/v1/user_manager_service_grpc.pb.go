@@ -23,23 +23,45 @@ type UsersServiceClient interface {
 	ListAPIKeys(ctx context.Context, in *ListAPIKeysRequest, opts ...grpc.CallOption) (*ListAPIKeysResponse, error)
 	DeleteAPIKey(ctx context.Context, in *DeleteAPIKeyRequest, opts ...grpc.CallOption) (*DeleteAPIKeyResponse, error)
 	UpdateAPIKey(ctx context.Context, in *UpdateAPIKeyRequest, opts ...grpc.CallOption) (*APIKey, error)
+	RotateAPIKey(ctx context.Context, in *RotateAPIKeyRequest, opts ...grpc.CallOption) (*APIKey, error)
+	// TransferAPIKey changes the owner of an API key. The ID and the secret of the key are kept.
+	TransferAPIKey(ctx context.Context, in *TransferAPIKeyRequest, opts ...grpc.CallOption) (*TransferAPIKeyResponse, error)
+	// ImportAPIKey creates an API key with an externally generated secret. Only organization owners
+	// can import API keys.
+	ImportAPIKey(ctx context.Context, in *ImportAPIKeyRequest, opts ...grpc.CallOption) (*APIKey, error)
 	// TODO(kenji): Remove the follwoing three RPC endpoints in favor of "/v1/api_keys".
 	CreateProjectAPIKey(ctx context.Context, in *CreateAPIKeyRequest, opts ...grpc.CallOption) (*APIKey, error)
 	ListProjectAPIKeys(ctx context.Context, in *ListProjectAPIKeysRequest, opts ...grpc.CallOption) (*ListAPIKeysResponse, error)
 	DeleteProjectAPIKey(ctx context.Context, in *DeleteProjectAPIKeyRequest, opts ...grpc.CallOption) (*DeleteAPIKeyResponse, error)
 	CreateOrganization(ctx context.Context, in *CreateOrganizationRequest, opts ...grpc.CallOption) (*Organization, error)
 	ListOrganizations(ctx context.Context, in *ListOrganizationsRequest, opts ...grpc.CallOption) (*ListOrganizationsResponse, error)
+	GetOrganization(ctx context.Context, in *GetOrganizationRequest, opts ...grpc.CallOption) (*Organization, error)
 	DeleteOrganization(ctx context.Context, in *DeleteOrganizationRequest, opts ...grpc.CallOption) (*DeleteOrganizationResponse, error)
+	UpdateOrganization(ctx context.Context, in *UpdateOrganizationRequest, opts ...grpc.CallOption) (*Organization, error)
 	CreateOrganizationUser(ctx context.Context, in *CreateOrganizationUserRequest, opts ...grpc.CallOption) (*OrganizationUser, error)
 	ListOrganizationUsers(ctx context.Context, in *ListOrganizationUsersRequest, opts ...grpc.CallOption) (*ListOrganizationUsersResponse, error)
-	DeleteOrganizationUser(ctx context.Context, in *DeleteOrganizationUserRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	DeleteOrganizationUser(ctx context.Context, in *DeleteOrganizationUserRequest, opts ...grpc.CallOption) (*DeleteOrganizationUserResponse, error)
+	UpdateOrganizationUser(ctx context.Context, in *UpdateOrganizationUserRequest, opts ...grpc.CallOption) (*OrganizationUser, error)
 	CreateProject(ctx context.Context, in *CreateProjectRequest, opts ...grpc.CallOption) (*Project, error)
 	ListProjects(ctx context.Context, in *ListProjectsRequest, opts ...grpc.CallOption) (*ListProjectsResponse, error)
+	GetProject(ctx context.Context, in *GetProjectRequest, opts ...grpc.CallOption) (*Project, error)
 	DeleteProject(ctx context.Context, in *DeleteProjectRequest, opts ...grpc.CallOption) (*DeleteProjectResponse, error)
 	UpdateProject(ctx context.Context, in *UpdateProjectRequest, opts ...grpc.CallOption) (*Project, error)
 	CreateProjectUser(ctx context.Context, in *CreateProjectUserRequest, opts ...grpc.CallOption) (*ProjectUser, error)
 	ListProjectUsers(ctx context.Context, in *ListProjectUsersRequest, opts ...grpc.CallOption) (*ListProjectUsersResponse, error)
-	DeleteProjectUser(ctx context.Context, in *DeleteProjectUserRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	DeleteProjectUser(ctx context.Context, in *DeleteProjectUserRequest, opts ...grpc.CallOption) (*DeleteProjectUserResponse, error)
+	UpdateProjectUser(ctx context.Context, in *UpdateProjectUserRequest, opts ...grpc.CallOption) (*ProjectUser, error)
+	CreateServiceAccount(ctx context.Context, in *CreateServiceAccountRequest, opts ...grpc.CallOption) (*ServiceAccount, error)
+	ListServiceAccounts(ctx context.Context, in *ListServiceAccountsRequest, opts ...grpc.CallOption) (*ListServiceAccountsResponse, error)
+	GetServiceAccount(ctx context.Context, in *GetServiceAccountRequest, opts ...grpc.CallOption) (*ServiceAccount, error)
+	UpdateServiceAccount(ctx context.Context, in *UpdateServiceAccountRequest, opts ...grpc.CallOption) (*ServiceAccount, error)
+	DeleteServiceAccount(ctx context.Context, in *DeleteServiceAccountRequest, opts ...grpc.CallOption) (*DeleteServiceAccountResponse, error)
+	CreateInvitation(ctx context.Context, in *CreateInvitationRequest, opts ...grpc.CallOption) (*Invitation, error)
+	ListInvitations(ctx context.Context, in *ListInvitationsRequest, opts ...grpc.CallOption) (*ListInvitationsResponse, error)
+	ListOrganizationInvitations(ctx context.Context, in *ListOrganizationInvitationsRequest, opts ...grpc.CallOption) (*ListInvitationsResponse, error)
+	AcceptInvitation(ctx context.Context, in *AcceptInvitationRequest, opts ...grpc.CallOption) (*Invitation, error)
+	DeclineInvitation(ctx context.Context, in *DeclineInvitationRequest, opts ...grpc.CallOption) (*Invitation, error)
+	RevokeInvitation(ctx context.Context, in *RevokeInvitationRequest, opts ...grpc.CallOption) (*Invitation, error)
 	GetUserSelf(ctx context.Context, in *GetUserSelfRequest, opts ...grpc.CallOption) (*User, error)
 	ListUsers(ctx context.Context, in *ListUsersRequest, opts ...grpc.CallOption) (*ListUsersResponse, error)
 }
@@ -88,6 +110,33 @@ func (c *usersServiceClient) UpdateAPIKey(ctx context.Context, in *UpdateAPIKeyR
 	return out, nil
 }
 
+func (c *usersServiceClient) RotateAPIKey(ctx context.Context, in *RotateAPIKeyRequest, opts ...grpc.CallOption) (*APIKey, error) {
+	out := new(APIKey)
+	err := c.cc.Invoke(ctx, "/llmariner.users.server.v1.UsersService/RotateAPIKey", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *usersServiceClient) TransferAPIKey(ctx context.Context, in *TransferAPIKeyRequest, opts ...grpc.CallOption) (*TransferAPIKeyResponse, error) {
+	out := new(TransferAPIKeyResponse)
+	err := c.cc.Invoke(ctx, "/llmariner.users.server.v1.UsersService/TransferAPIKey", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *usersServiceClient) ImportAPIKey(ctx context.Context, in *ImportAPIKeyRequest, opts ...grpc.CallOption) (*APIKey, error) {
+	out := new(APIKey)
+	err := c.cc.Invoke(ctx, "/llmariner.users.server.v1.UsersService/ImportAPIKey", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *usersServiceClient) CreateProjectAPIKey(ctx context.Context, in *CreateAPIKeyRequest, opts ...grpc.CallOption) (*APIKey, error) {
 	out := new(APIKey)
 	err := c.cc.Invoke(ctx, "/llmariner.users.server.v1.UsersService/CreateProjectAPIKey", in, out, opts...)
@@ -133,6 +182,15 @@ func (c *usersServiceClient) ListOrganizations(ctx context.Context, in *ListOrga
 	return out, nil
 }
 
+func (c *usersServiceClient) GetOrganization(ctx context.Context, in *GetOrganizationRequest, opts ...grpc.CallOption) (*Organization, error) {
+	out := new(Organization)
+	err := c.cc.Invoke(ctx, "/llmariner.users.server.v1.UsersService/GetOrganization", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *usersServiceClient) DeleteOrganization(ctx context.Context, in *DeleteOrganizationRequest, opts ...grpc.CallOption) (*DeleteOrganizationResponse, error) {
 	out := new(DeleteOrganizationResponse)
 	err := c.cc.Invoke(ctx, "/llmariner.users.server.v1.UsersService/DeleteOrganization", in, out, opts...)
@@ -142,6 +200,15 @@ func (c *usersServiceClient) DeleteOrganization(ctx context.Context, in *DeleteO
 	return out, nil
 }
 
+func (c *usersServiceClient) UpdateOrganization(ctx context.Context, in *UpdateOrganizationRequest, opts ...grpc.CallOption) (*Organization, error) {
+	out := new(Organization)
+	err := c.cc.Invoke(ctx, "/llmariner.users.server.v1.UsersService/UpdateOrganization", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *usersServiceClient) CreateOrganizationUser(ctx context.Context, in *CreateOrganizationUserRequest, opts ...grpc.CallOption) (*OrganizationUser, error) {
 	out := new(OrganizationUser)
 	err := c.cc.Invoke(ctx, "/llmariner.users.server.v1.UsersService/CreateOrganizationUser", in, out, opts...)
@@ -160,8 +227,8 @@ func (c *usersServiceClient) ListOrganizationUsers(ctx context.Context, in *List
 	return out, nil
 }
 
-func (c *usersServiceClient) DeleteOrganizationUser(ctx context.Context, in *DeleteOrganizationUserRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
-	out := new(emptypb.Empty)
+func (c *usersServiceClient) DeleteOrganizationUser(ctx context.Context, in *DeleteOrganizationUserRequest, opts ...grpc.CallOption) (*DeleteOrganizationUserResponse, error) {
+	out := new(DeleteOrganizationUserResponse)
 	err := c.cc.Invoke(ctx, "/llmariner.users.server.v1.UsersService/DeleteOrganizationUser", in, out, opts...)
 	if err != nil {
 		return nil, err
@@ -169,6 +236,15 @@ func (c *usersServiceClient) DeleteOrganizationUser(ctx context.Context, in *Del
 	return out, nil
 }
 
+func (c *usersServiceClient) UpdateOrganizationUser(ctx context.Context, in *UpdateOrganizationUserRequest, opts ...grpc.CallOption) (*OrganizationUser, error) {
+	out := new(OrganizationUser)
+	err := c.cc.Invoke(ctx, "/llmariner.users.server.v1.UsersService/UpdateOrganizationUser", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *usersServiceClient) CreateProject(ctx context.Context, in *CreateProjectRequest, opts ...grpc.CallOption) (*Project, error) {
 	out := new(Project)
 	err := c.cc.Invoke(ctx, "/llmariner.users.server.v1.UsersService/CreateProject", in, out, opts...)
@@ -187,6 +263,15 @@ func (c *usersServiceClient) ListProjects(ctx context.Context, in *ListProjectsR
 	return out, nil
 }
 
+func (c *usersServiceClient) GetProject(ctx context.Context, in *GetProjectRequest, opts ...grpc.CallOption) (*Project, error) {
+	out := new(Project)
+	err := c.cc.Invoke(ctx, "/llmariner.users.server.v1.UsersService/GetProject", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *usersServiceClient) DeleteProject(ctx context.Context, in *DeleteProjectRequest, opts ...grpc.CallOption) (*DeleteProjectResponse, error) {
 	out := new(DeleteProjectResponse)
 	err := c.cc.Invoke(ctx, "/llmariner.users.server.v1.UsersService/DeleteProject", in, out, opts...)
@@ -223,8 +308,8 @@ func (c *usersServiceClient) ListProjectUsers(ctx context.Context, in *ListProje
 	return out, nil
 }
 
-func (c *usersServiceClient) DeleteProjectUser(ctx context.Context, in *DeleteProjectUserRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
-	out := new(emptypb.Empty)
+func (c *usersServiceClient) DeleteProjectUser(ctx context.Context, in *DeleteProjectUserRequest, opts ...grpc.CallOption) (*DeleteProjectUserResponse, error) {
+	out := new(DeleteProjectUserResponse)
 	err := c.cc.Invoke(ctx, "/llmariner.users.server.v1.UsersService/DeleteProjectUser", in, out, opts...)
 	if err != nil {
 		return nil, err
@@ -232,6 +317,114 @@ func (c *usersServiceClient) DeleteProjectUser(ctx context.Context, in *DeletePr
 	return out, nil
 }
 
+func (c *usersServiceClient) UpdateProjectUser(ctx context.Context, in *UpdateProjectUserRequest, opts ...grpc.CallOption) (*ProjectUser, error) {
+	out := new(ProjectUser)
+	err := c.cc.Invoke(ctx, "/llmariner.users.server.v1.UsersService/UpdateProjectUser", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *usersServiceClient) CreateServiceAccount(ctx context.Context, in *CreateServiceAccountRequest, opts ...grpc.CallOption) (*ServiceAccount, error) {
+	out := new(ServiceAccount)
+	err := c.cc.Invoke(ctx, "/llmariner.users.server.v1.UsersService/CreateServiceAccount", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *usersServiceClient) ListServiceAccounts(ctx context.Context, in *ListServiceAccountsRequest, opts ...grpc.CallOption) (*ListServiceAccountsResponse, error) {
+	out := new(ListServiceAccountsResponse)
+	err := c.cc.Invoke(ctx, "/llmariner.users.server.v1.UsersService/ListServiceAccounts", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *usersServiceClient) GetServiceAccount(ctx context.Context, in *GetServiceAccountRequest, opts ...grpc.CallOption) (*ServiceAccount, error) {
+	out := new(ServiceAccount)
+	err := c.cc.Invoke(ctx, "/llmariner.users.server.v1.UsersService/GetServiceAccount", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *usersServiceClient) UpdateServiceAccount(ctx context.Context, in *UpdateServiceAccountRequest, opts ...grpc.CallOption) (*ServiceAccount, error) {
+	out := new(ServiceAccount)
+	err := c.cc.Invoke(ctx, "/llmariner.users.server.v1.UsersService/UpdateServiceAccount", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *usersServiceClient) DeleteServiceAccount(ctx context.Context, in *DeleteServiceAccountRequest, opts ...grpc.CallOption) (*DeleteServiceAccountResponse, error) {
+	out := new(DeleteServiceAccountResponse)
+	err := c.cc.Invoke(ctx, "/llmariner.users.server.v1.UsersService/DeleteServiceAccount", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *usersServiceClient) CreateInvitation(ctx context.Context, in *CreateInvitationRequest, opts ...grpc.CallOption) (*Invitation, error) {
+	out := new(Invitation)
+	err := c.cc.Invoke(ctx, "/llmariner.users.server.v1.UsersService/CreateInvitation", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *usersServiceClient) ListInvitations(ctx context.Context, in *ListInvitationsRequest, opts ...grpc.CallOption) (*ListInvitationsResponse, error) {
+	out := new(ListInvitationsResponse)
+	err := c.cc.Invoke(ctx, "/llmariner.users.server.v1.UsersService/ListInvitations", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *usersServiceClient) ListOrganizationInvitations(ctx context.Context, in *ListOrganizationInvitationsRequest, opts ...grpc.CallOption) (*ListInvitationsResponse, error) {
+	out := new(ListInvitationsResponse)
+	err := c.cc.Invoke(ctx, "/llmariner.users.server.v1.UsersService/ListOrganizationInvitations", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *usersServiceClient) AcceptInvitation(ctx context.Context, in *AcceptInvitationRequest, opts ...grpc.CallOption) (*Invitation, error) {
+	out := new(Invitation)
+	err := c.cc.Invoke(ctx, "/llmariner.users.server.v1.UsersService/AcceptInvitation", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *usersServiceClient) DeclineInvitation(ctx context.Context, in *DeclineInvitationRequest, opts ...grpc.CallOption) (*Invitation, error) {
+	out := new(Invitation)
+	err := c.cc.Invoke(ctx, "/llmariner.users.server.v1.UsersService/DeclineInvitation", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *usersServiceClient) RevokeInvitation(ctx context.Context, in *RevokeInvitationRequest, opts ...grpc.CallOption) (*Invitation, error) {
+	out := new(Invitation)
+	err := c.cc.Invoke(ctx, "/llmariner.users.server.v1.UsersService/RevokeInvitation", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *usersServiceClient) GetUserSelf(ctx context.Context, in *GetUserSelfRequest, opts ...grpc.CallOption) (*User, error) {
 	out := new(User)
 	err := c.cc.Invoke(ctx, "/llmariner.users.server.v1.UsersService/GetUserSelf", in, out, opts...)
@@ -258,23 +451,45 @@ type UsersServiceServer interface {
 	ListAPIKeys(context.Context, *ListAPIKeysRequest) (*ListAPIKeysResponse, error)
 	DeleteAPIKey(context.Context, *DeleteAPIKeyRequest) (*DeleteAPIKeyResponse, error)
 	UpdateAPIKey(context.Context, *UpdateAPIKeyRequest) (*APIKey, error)
+	RotateAPIKey(context.Context, *RotateAPIKeyRequest) (*APIKey, error)
+	// TransferAPIKey changes the owner of an API key. The ID and the secret of the key are kept.
+	TransferAPIKey(context.Context, *TransferAPIKeyRequest) (*TransferAPIKeyResponse, error)
+	// ImportAPIKey creates an API key with an externally generated secret. Only organization owners
+	// can import API keys.
+	ImportAPIKey(context.Context, *ImportAPIKeyRequest) (*APIKey, error)
 	// TODO(kenji): Remove the follwoing three RPC endpoints in favor of "/v1/api_keys".
 	CreateProjectAPIKey(context.Context, *CreateAPIKeyRequest) (*APIKey, error)
 	ListProjectAPIKeys(context.Context, *ListProjectAPIKeysRequest) (*ListAPIKeysResponse, error)
 	DeleteProjectAPIKey(context.Context, *DeleteProjectAPIKeyRequest) (*DeleteAPIKeyResponse, error)
 	CreateOrganization(context.Context, *CreateOrganizationRequest) (*Organization, error)
 	ListOrganizations(context.Context, *ListOrganizationsRequest) (*ListOrganizationsResponse, error)
+	GetOrganization(context.Context, *GetOrganizationRequest) (*Organization, error)
 	DeleteOrganization(context.Context, *DeleteOrganizationRequest) (*DeleteOrganizationResponse, error)
+	UpdateOrganization(context.Context, *UpdateOrganizationRequest) (*Organization, error)
 	CreateOrganizationUser(context.Context, *CreateOrganizationUserRequest) (*OrganizationUser, error)
 	ListOrganizationUsers(context.Context, *ListOrganizationUsersRequest) (*ListOrganizationUsersResponse, error)
-	DeleteOrganizationUser(context.Context, *DeleteOrganizationUserRequest) (*emptypb.Empty, error)
+	DeleteOrganizationUser(context.Context, *DeleteOrganizationUserRequest) (*DeleteOrganizationUserResponse, error)
+	UpdateOrganizationUser(context.Context, *UpdateOrganizationUserRequest) (*OrganizationUser, error)
 	CreateProject(context.Context, *CreateProjectRequest) (*Project, error)
 	ListProjects(context.Context, *ListProjectsRequest) (*ListProjectsResponse, error)
+	GetProject(context.Context, *GetProjectRequest) (*Project, error)
 	DeleteProject(context.Context, *DeleteProjectRequest) (*DeleteProjectResponse, error)
 	UpdateProject(context.Context, *UpdateProjectRequest) (*Project, error)
 	CreateProjectUser(context.Context, *CreateProjectUserRequest) (*ProjectUser, error)
 	ListProjectUsers(context.Context, *ListProjectUsersRequest) (*ListProjectUsersResponse, error)
-	DeleteProjectUser(context.Context, *DeleteProjectUserRequest) (*emptypb.Empty, error)
+	DeleteProjectUser(context.Context, *DeleteProjectUserRequest) (*DeleteProjectUserResponse, error)
+	UpdateProjectUser(context.Context, *UpdateProjectUserRequest) (*ProjectUser, error)
+	CreateServiceAccount(context.Context, *CreateServiceAccountRequest) (*ServiceAccount, error)
+	ListServiceAccounts(context.Context, *ListServiceAccountsRequest) (*ListServiceAccountsResponse, error)
+	GetServiceAccount(context.Context, *GetServiceAccountRequest) (*ServiceAccount, error)
+	UpdateServiceAccount(context.Context, *UpdateServiceAccountRequest) (*ServiceAccount, error)
+	DeleteServiceAccount(context.Context, *DeleteServiceAccountRequest) (*DeleteServiceAccountResponse, error)
+	CreateInvitation(context.Context, *CreateInvitationRequest) (*Invitation, error)
+	ListInvitations(context.Context, *ListInvitationsRequest) (*ListInvitationsResponse, error)
+	ListOrganizationInvitations(context.Context, *ListOrganizationInvitationsRequest) (*ListInvitationsResponse, error)
+	AcceptInvitation(context.Context, *AcceptInvitationRequest) (*Invitation, error)
+	DeclineInvitation(context.Context, *DeclineInvitationRequest) (*Invitation, error)
+	RevokeInvitation(context.Context, *RevokeInvitationRequest) (*Invitation, error)
 	GetUserSelf(context.Context, *GetUserSelfRequest) (*User, error)
 	ListUsers(context.Context, *ListUsersRequest) (*ListUsersResponse, error)
 	mustEmbedUnimplementedUsersServiceServer()
@@ -296,6 +511,15 @@ func (UnimplementedUsersServiceServer) DeleteAPIKey(context.Context, *DeleteAPIK
 func (UnimplementedUsersServiceServer) UpdateAPIKey(context.Context, *UpdateAPIKeyRequest) (*APIKey, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method UpdateAPIKey not implemented")
 }
+func (UnimplementedUsersServiceServer) RotateAPIKey(context.Context, *RotateAPIKeyRequest) (*APIKey, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RotateAPIKey not implemented")
+}
+func (UnimplementedUsersServiceServer) TransferAPIKey(context.Context, *TransferAPIKeyRequest) (*TransferAPIKeyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method TransferAPIKey not implemented")
+}
+func (UnimplementedUsersServiceServer) ImportAPIKey(context.Context, *ImportAPIKeyRequest) (*APIKey, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ImportAPIKey not implemented")
+}
 func (UnimplementedUsersServiceServer) CreateProjectAPIKey(context.Context, *CreateAPIKeyRequest) (*APIKey, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method CreateProjectAPIKey not implemented")
 }
@@ -311,24 +535,36 @@ func (UnimplementedUsersServiceServer) CreateOrganization(context.Context, *Crea
 func (UnimplementedUsersServiceServer) ListOrganizations(context.Context, *ListOrganizationsRequest) (*ListOrganizationsResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListOrganizations not implemented")
 }
+func (UnimplementedUsersServiceServer) GetOrganization(context.Context, *GetOrganizationRequest) (*Organization, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetOrganization not implemented")
+}
 func (UnimplementedUsersServiceServer) DeleteOrganization(context.Context, *DeleteOrganizationRequest) (*DeleteOrganizationResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeleteOrganization not implemented")
 }
+func (UnimplementedUsersServiceServer) UpdateOrganization(context.Context, *UpdateOrganizationRequest) (*Organization, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateOrganization not implemented")
+}
 func (UnimplementedUsersServiceServer) CreateOrganizationUser(context.Context, *CreateOrganizationUserRequest) (*OrganizationUser, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method CreateOrganizationUser not implemented")
 }
 func (UnimplementedUsersServiceServer) ListOrganizationUsers(context.Context, *ListOrganizationUsersRequest) (*ListOrganizationUsersResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListOrganizationUsers not implemented")
 }
-func (UnimplementedUsersServiceServer) DeleteOrganizationUser(context.Context, *DeleteOrganizationUserRequest) (*emptypb.Empty, error) {
+func (UnimplementedUsersServiceServer) DeleteOrganizationUser(context.Context, *DeleteOrganizationUserRequest) (*DeleteOrganizationUserResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeleteOrganizationUser not implemented")
 }
+func (UnimplementedUsersServiceServer) UpdateOrganizationUser(context.Context, *UpdateOrganizationUserRequest) (*OrganizationUser, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateOrganizationUser not implemented")
+}
 func (UnimplementedUsersServiceServer) CreateProject(context.Context, *CreateProjectRequest) (*Project, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method CreateProject not implemented")
 }
 func (UnimplementedUsersServiceServer) ListProjects(context.Context, *ListProjectsRequest) (*ListProjectsResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListProjects not implemented")
 }
+func (UnimplementedUsersServiceServer) GetProject(context.Context, *GetProjectRequest) (*Project, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetProject not implemented")
+}
 func (UnimplementedUsersServiceServer) DeleteProject(context.Context, *DeleteProjectRequest) (*DeleteProjectResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeleteProject not implemented")
 }
@@ -341,9 +577,45 @@ func (UnimplementedUsersServiceServer) CreateProjectUser(context.Context, *Creat
 func (UnimplementedUsersServiceServer) ListProjectUsers(context.Context, *ListProjectUsersRequest) (*ListProjectUsersResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListProjectUsers not implemented")
 }
-func (UnimplementedUsersServiceServer) DeleteProjectUser(context.Context, *DeleteProjectUserRequest) (*emptypb.Empty, error) {
+func (UnimplementedUsersServiceServer) DeleteProjectUser(context.Context, *DeleteProjectUserRequest) (*DeleteProjectUserResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeleteProjectUser not implemented")
 }
+func (UnimplementedUsersServiceServer) UpdateProjectUser(context.Context, *UpdateProjectUserRequest) (*ProjectUser, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateProjectUser not implemented")
+}
+func (UnimplementedUsersServiceServer) CreateServiceAccount(context.Context, *CreateServiceAccountRequest) (*ServiceAccount, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateServiceAccount not implemented")
+}
+func (UnimplementedUsersServiceServer) ListServiceAccounts(context.Context, *ListServiceAccountsRequest) (*ListServiceAccountsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListServiceAccounts not implemented")
+}
+func (UnimplementedUsersServiceServer) GetServiceAccount(context.Context, *GetServiceAccountRequest) (*ServiceAccount, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetServiceAccount not implemented")
+}
+func (UnimplementedUsersServiceServer) UpdateServiceAccount(context.Context, *UpdateServiceAccountRequest) (*ServiceAccount, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateServiceAccount not implemented")
+}
+func (UnimplementedUsersServiceServer) DeleteServiceAccount(context.Context, *DeleteServiceAccountRequest) (*DeleteServiceAccountResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteServiceAccount not implemented")
+}
+func (UnimplementedUsersServiceServer) CreateInvitation(context.Context, *CreateInvitationRequest) (*Invitation, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateInvitation not implemented")
+}
+func (UnimplementedUsersServiceServer) ListInvitations(context.Context, *ListInvitationsRequest) (*ListInvitationsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListInvitations not implemented")
+}
+func (UnimplementedUsersServiceServer) ListOrganizationInvitations(context.Context, *ListOrganizationInvitationsRequest) (*ListInvitationsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListOrganizationInvitations not implemented")
+}
+func (UnimplementedUsersServiceServer) AcceptInvitation(context.Context, *AcceptInvitationRequest) (*Invitation, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AcceptInvitation not implemented")
+}
+func (UnimplementedUsersServiceServer) DeclineInvitation(context.Context, *DeclineInvitationRequest) (*Invitation, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeclineInvitation not implemented")
+}
+func (UnimplementedUsersServiceServer) RevokeInvitation(context.Context, *RevokeInvitationRequest) (*Invitation, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RevokeInvitation not implemented")
+}
 func (UnimplementedUsersServiceServer) GetUserSelf(context.Context, *GetUserSelfRequest) (*User, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetUserSelf not implemented")
 }
@@ -435,6 +707,60 @@ func _UsersService_UpdateAPIKey_Handler(srv interface{}, ctx context.Context, de
 	return interceptor(ctx, in, info, handler)
 }
 
+func _UsersService_RotateAPIKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RotateAPIKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UsersServiceServer).RotateAPIKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/llmariner.users.server.v1.UsersService/RotateAPIKey",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UsersServiceServer).RotateAPIKey(ctx, req.(*RotateAPIKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UsersService_TransferAPIKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TransferAPIKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UsersServiceServer).TransferAPIKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/llmariner.users.server.v1.UsersService/TransferAPIKey",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UsersServiceServer).TransferAPIKey(ctx, req.(*TransferAPIKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UsersService_ImportAPIKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ImportAPIKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UsersServiceServer).ImportAPIKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/llmariner.users.server.v1.UsersService/ImportAPIKey",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UsersServiceServer).ImportAPIKey(ctx, req.(*ImportAPIKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _UsersService_CreateProjectAPIKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(CreateAPIKeyRequest)
 	if err := dec(in); err != nil {
@@ -525,6 +851,24 @@ func _UsersService_ListOrganizations_Handler(srv interface{}, ctx context.Contex
 	return interceptor(ctx, in, info, handler)
 }
 
+func _UsersService_GetOrganization_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetOrganizationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UsersServiceServer).GetOrganization(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/llmariner.users.server.v1.UsersService/GetOrganization",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UsersServiceServer).GetOrganization(ctx, req.(*GetOrganizationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _UsersService_DeleteOrganization_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(DeleteOrganizationRequest)
 	if err := dec(in); err != nil {
@@ -543,6 +887,24 @@ func _UsersService_DeleteOrganization_Handler(srv interface{}, ctx context.Conte
 	return interceptor(ctx, in, info, handler)
 }
 
+func _UsersService_UpdateOrganization_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateOrganizationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UsersServiceServer).UpdateOrganization(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/llmariner.users.server.v1.UsersService/UpdateOrganization",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UsersServiceServer).UpdateOrganization(ctx, req.(*UpdateOrganizationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _UsersService_CreateOrganizationUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(CreateOrganizationUserRequest)
 	if err := dec(in); err != nil {
@@ -597,6 +959,24 @@ func _UsersService_DeleteOrganizationUser_Handler(srv interface{}, ctx context.C
 	return interceptor(ctx, in, info, handler)
 }
 
+func _UsersService_UpdateOrganizationUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateOrganizationUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UsersServiceServer).UpdateOrganizationUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/llmariner.users.server.v1.UsersService/UpdateOrganizationUser",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UsersServiceServer).UpdateOrganizationUser(ctx, req.(*UpdateOrganizationUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _UsersService_CreateProject_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(CreateProjectRequest)
 	if err := dec(in); err != nil {
@@ -633,6 +1013,24 @@ func _UsersService_ListProjects_Handler(srv interface{}, ctx context.Context, de
 	return interceptor(ctx, in, info, handler)
 }
 
+func _UsersService_GetProject_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetProjectRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UsersServiceServer).GetProject(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/llmariner.users.server.v1.UsersService/GetProject",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UsersServiceServer).GetProject(ctx, req.(*GetProjectRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _UsersService_DeleteProject_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(DeleteProjectRequest)
 	if err := dec(in); err != nil {
@@ -723,6 +1121,222 @@ func _UsersService_DeleteProjectUser_Handler(srv interface{}, ctx context.Contex
 	return interceptor(ctx, in, info, handler)
 }
 
+func _UsersService_UpdateProjectUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateProjectUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UsersServiceServer).UpdateProjectUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/llmariner.users.server.v1.UsersService/UpdateProjectUser",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UsersServiceServer).UpdateProjectUser(ctx, req.(*UpdateProjectUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UsersService_CreateServiceAccount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateServiceAccountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UsersServiceServer).CreateServiceAccount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/llmariner.users.server.v1.UsersService/CreateServiceAccount",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UsersServiceServer).CreateServiceAccount(ctx, req.(*CreateServiceAccountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UsersService_ListServiceAccounts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListServiceAccountsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UsersServiceServer).ListServiceAccounts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/llmariner.users.server.v1.UsersService/ListServiceAccounts",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UsersServiceServer).ListServiceAccounts(ctx, req.(*ListServiceAccountsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UsersService_GetServiceAccount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetServiceAccountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UsersServiceServer).GetServiceAccount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/llmariner.users.server.v1.UsersService/GetServiceAccount",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UsersServiceServer).GetServiceAccount(ctx, req.(*GetServiceAccountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UsersService_UpdateServiceAccount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateServiceAccountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UsersServiceServer).UpdateServiceAccount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/llmariner.users.server.v1.UsersService/UpdateServiceAccount",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UsersServiceServer).UpdateServiceAccount(ctx, req.(*UpdateServiceAccountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UsersService_DeleteServiceAccount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteServiceAccountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UsersServiceServer).DeleteServiceAccount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/llmariner.users.server.v1.UsersService/DeleteServiceAccount",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UsersServiceServer).DeleteServiceAccount(ctx, req.(*DeleteServiceAccountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UsersService_CreateInvitation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateInvitationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UsersServiceServer).CreateInvitation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/llmariner.users.server.v1.UsersService/CreateInvitation",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UsersServiceServer).CreateInvitation(ctx, req.(*CreateInvitationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UsersService_ListInvitations_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListInvitationsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UsersServiceServer).ListInvitations(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/llmariner.users.server.v1.UsersService/ListInvitations",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UsersServiceServer).ListInvitations(ctx, req.(*ListInvitationsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UsersService_ListOrganizationInvitations_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListOrganizationInvitationsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UsersServiceServer).ListOrganizationInvitations(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/llmariner.users.server.v1.UsersService/ListOrganizationInvitations",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UsersServiceServer).ListOrganizationInvitations(ctx, req.(*ListOrganizationInvitationsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UsersService_AcceptInvitation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AcceptInvitationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UsersServiceServer).AcceptInvitation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/llmariner.users.server.v1.UsersService/AcceptInvitation",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UsersServiceServer).AcceptInvitation(ctx, req.(*AcceptInvitationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UsersService_DeclineInvitation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeclineInvitationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UsersServiceServer).DeclineInvitation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/llmariner.users.server.v1.UsersService/DeclineInvitation",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UsersServiceServer).DeclineInvitation(ctx, req.(*DeclineInvitationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UsersService_RevokeInvitation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RevokeInvitationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UsersServiceServer).RevokeInvitation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/llmariner.users.server.v1.UsersService/RevokeInvitation",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UsersServiceServer).RevokeInvitation(ctx, req.(*RevokeInvitationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _UsersService_GetUserSelf_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(GetUserSelfRequest)
 	if err := dec(in); err != nil {
@@ -782,6 +1396,18 @@ var UsersService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "UpdateAPIKey",
 			Handler:    _UsersService_UpdateAPIKey_Handler,
 		},
+		{
+			MethodName: "RotateAPIKey",
+			Handler:    _UsersService_RotateAPIKey_Handler,
+		},
+		{
+			MethodName: "TransferAPIKey",
+			Handler:    _UsersService_TransferAPIKey_Handler,
+		},
+		{
+			MethodName: "ImportAPIKey",
+			Handler:    _UsersService_ImportAPIKey_Handler,
+		},
 		{
 			MethodName: "CreateProjectAPIKey",
 			Handler:    _UsersService_CreateProjectAPIKey_Handler,
@@ -802,10 +1428,18 @@ var UsersService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ListOrganizations",
 			Handler:    _UsersService_ListOrganizations_Handler,
 		},
+		{
+			MethodName: "GetOrganization",
+			Handler:    _UsersService_GetOrganization_Handler,
+		},
 		{
 			MethodName: "DeleteOrganization",
 			Handler:    _UsersService_DeleteOrganization_Handler,
 		},
+		{
+			MethodName: "UpdateOrganization",
+			Handler:    _UsersService_UpdateOrganization_Handler,
+		},
 		{
 			MethodName: "CreateOrganizationUser",
 			Handler:    _UsersService_CreateOrganizationUser_Handler,
@@ -818,6 +1452,10 @@ var UsersService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "DeleteOrganizationUser",
 			Handler:    _UsersService_DeleteOrganizationUser_Handler,
 		},
+		{
+			MethodName: "UpdateOrganizationUser",
+			Handler:    _UsersService_UpdateOrganizationUser_Handler,
+		},
 		{
 			MethodName: "CreateProject",
 			Handler:    _UsersService_CreateProject_Handler,
@@ -826,6 +1464,10 @@ var UsersService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ListProjects",
 			Handler:    _UsersService_ListProjects_Handler,
 		},
+		{
+			MethodName: "GetProject",
+			Handler:    _UsersService_GetProject_Handler,
+		},
 		{
 			MethodName: "DeleteProject",
 			Handler:    _UsersService_DeleteProject_Handler,
@@ -846,6 +1488,54 @@ var UsersService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "DeleteProjectUser",
 			Handler:    _UsersService_DeleteProjectUser_Handler,
 		},
+		{
+			MethodName: "UpdateProjectUser",
+			Handler:    _UsersService_UpdateProjectUser_Handler,
+		},
+		{
+			MethodName: "CreateServiceAccount",
+			Handler:    _UsersService_CreateServiceAccount_Handler,
+		},
+		{
+			MethodName: "ListServiceAccounts",
+			Handler:    _UsersService_ListServiceAccounts_Handler,
+		},
+		{
+			MethodName: "GetServiceAccount",
+			Handler:    _UsersService_GetServiceAccount_Handler,
+		},
+		{
+			MethodName: "UpdateServiceAccount",
+			Handler:    _UsersService_UpdateServiceAccount_Handler,
+		},
+		{
+			MethodName: "DeleteServiceAccount",
+			Handler:    _UsersService_DeleteServiceAccount_Handler,
+		},
+		{
+			MethodName: "CreateInvitation",
+			Handler:    _UsersService_CreateInvitation_Handler,
+		},
+		{
+			MethodName: "ListInvitations",
+			Handler:    _UsersService_ListInvitations_Handler,
+		},
+		{
+			MethodName: "ListOrganizationInvitations",
+			Handler:    _UsersService_ListOrganizationInvitations_Handler,
+		},
+		{
+			MethodName: "AcceptInvitation",
+			Handler:    _UsersService_AcceptInvitation_Handler,
+		},
+		{
+			MethodName: "DeclineInvitation",
+			Handler:    _UsersService_DeclineInvitation_Handler,
+		},
+		{
+			MethodName: "RevokeInvitation",
+			Handler:    _UsersService_RevokeInvitation_Handler,
+		},
 		{
 			MethodName: "GetUserSelf",
 			Handler:    _UsersService_GetUserSelf_Handler,
@@ -863,7 +1553,15 @@ var UsersService_ServiceDesc = grpc.ServiceDesc{
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type UsersInternalServiceClient interface {
+	// ListInternalAPIKeys returns all unexpired API keys including their secrets.
+	// Prefer AuthenticateAPIKey, which does not require the secrets to leave this service.
 	ListInternalAPIKeys(ctx context.Context, in *ListInternalAPIKeysRequest, opts ...grpc.CallOption) (*ListInternalAPIKeysResponse, error)
+	// AuthenticateAPIKey returns the unexpired API key that has the given secret.
+	// The secret of the returned API key is obfuscated.
+	AuthenticateAPIKey(ctx context.Context, in *AuthenticateAPIKeyRequest, opts ...grpc.CallOption) (*InternalAPIKey, error)
+	// WatchAPIKeys streams the changes of API keys.
+	WatchAPIKeys(ctx context.Context, in *WatchAPIKeysRequest, opts ...grpc.CallOption) (UsersInternalService_WatchAPIKeysClient, error)
+	ReportAPIKeyUsages(ctx context.Context, in *ReportAPIKeyUsagesRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	ListInternalOrganizations(ctx context.Context, in *ListInternalOrganizationsRequest, opts ...grpc.CallOption) (*ListInternalOrganizationsResponse, error)
 	ListOrganizationUsers(ctx context.Context, in *ListOrganizationUsersRequest, opts ...grpc.CallOption) (*ListOrganizationUsersResponse, error)
 	ListProjects(ctx context.Context, in *ListProjectsRequest, opts ...grpc.CallOption) (*ListProjectsResponse, error)
@@ -889,6 +1587,56 @@ func (c *usersInternalServiceClient) ListInternalAPIKeys(ctx context.Context, in
 	return out, nil
 }
 
+func (c *usersInternalServiceClient) AuthenticateAPIKey(ctx context.Context, in *AuthenticateAPIKeyRequest, opts ...grpc.CallOption) (*InternalAPIKey, error) {
+	out := new(InternalAPIKey)
+	err := c.cc.Invoke(ctx, "/llmariner.users.server.v1.UsersInternalService/AuthenticateAPIKey", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *usersInternalServiceClient) WatchAPIKeys(ctx context.Context, in *WatchAPIKeysRequest, opts ...grpc.CallOption) (UsersInternalService_WatchAPIKeysClient, error) {
+	stream, err := c.cc.NewStream(ctx, &UsersInternalService_ServiceDesc.Streams[0], "/llmariner.users.server.v1.UsersInternalService/WatchAPIKeys", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &usersInternalServiceWatchAPIKeysClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type UsersInternalService_WatchAPIKeysClient interface {
+	Recv() (*APIKeyEvent, error)
+	grpc.ClientStream
+}
+
+type usersInternalServiceWatchAPIKeysClient struct {
+	grpc.ClientStream
+}
+
+func (x *usersInternalServiceWatchAPIKeysClient) Recv() (*APIKeyEvent, error) {
+	m := new(APIKeyEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *usersInternalServiceClient) ReportAPIKeyUsages(ctx context.Context, in *ReportAPIKeyUsagesRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/llmariner.users.server.v1.UsersInternalService/ReportAPIKeyUsages", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *usersInternalServiceClient) ListInternalOrganizations(ctx context.Context, in *ListInternalOrganizationsRequest, opts ...grpc.CallOption) (*ListInternalOrganizationsResponse, error) {
 	out := new(ListInternalOrganizationsResponse)
 	err := c.cc.Invoke(ctx, "/llmariner.users.server.v1.UsersInternalService/ListInternalOrganizations", in, out, opts...)
@@ -947,7 +1695,15 @@ func (c *usersInternalServiceClient) CreateUserInternal(ctx context.Context, in
 // All implementations must embed UnimplementedUsersInternalServiceServer
 // for forward compatibility
 type UsersInternalServiceServer interface {
+	// ListInternalAPIKeys returns all unexpired API keys including their secrets.
+	// Prefer AuthenticateAPIKey, which does not require the secrets to leave this service.
 	ListInternalAPIKeys(context.Context, *ListInternalAPIKeysRequest) (*ListInternalAPIKeysResponse, error)
+	// AuthenticateAPIKey returns the unexpired API key that has the given secret.
+	// The secret of the returned API key is obfuscated.
+	AuthenticateAPIKey(context.Context, *AuthenticateAPIKeyRequest) (*InternalAPIKey, error)
+	// WatchAPIKeys streams the changes of API keys.
+	WatchAPIKeys(*WatchAPIKeysRequest, UsersInternalService_WatchAPIKeysServer) error
+	ReportAPIKeyUsages(context.Context, *ReportAPIKeyUsagesRequest) (*emptypb.Empty, error)
 	ListInternalOrganizations(context.Context, *ListInternalOrganizationsRequest) (*ListInternalOrganizationsResponse, error)
 	ListOrganizationUsers(context.Context, *ListOrganizationUsersRequest) (*ListOrganizationUsersResponse, error)
 	ListProjects(context.Context, *ListProjectsRequest) (*ListProjectsResponse, error)
@@ -964,6 +1720,15 @@ type UnimplementedUsersInternalServiceServer struct {
 func (UnimplementedUsersInternalServiceServer) ListInternalAPIKeys(context.Context, *ListInternalAPIKeysRequest) (*ListInternalAPIKeysResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListInternalAPIKeys not implemented")
 }
+func (UnimplementedUsersInternalServiceServer) AuthenticateAPIKey(context.Context, *AuthenticateAPIKeyRequest) (*InternalAPIKey, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AuthenticateAPIKey not implemented")
+}
+func (UnimplementedUsersInternalServiceServer) WatchAPIKeys(*WatchAPIKeysRequest, UsersInternalService_WatchAPIKeysServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchAPIKeys not implemented")
+}
+func (UnimplementedUsersInternalServiceServer) ReportAPIKeyUsages(context.Context, *ReportAPIKeyUsagesRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReportAPIKeyUsages not implemented")
+}
 func (UnimplementedUsersInternalServiceServer) ListInternalOrganizations(context.Context, *ListInternalOrganizationsRequest) (*ListInternalOrganizationsResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListInternalOrganizations not implemented")
 }
@@ -1013,6 +1778,63 @@ func _UsersInternalService_ListInternalAPIKeys_Handler(srv interface{}, ctx cont
 	return interceptor(ctx, in, info, handler)
 }
 
+func _UsersInternalService_AuthenticateAPIKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AuthenticateAPIKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UsersInternalServiceServer).AuthenticateAPIKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/llmariner.users.server.v1.UsersInternalService/AuthenticateAPIKey",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UsersInternalServiceServer).AuthenticateAPIKey(ctx, req.(*AuthenticateAPIKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UsersInternalService_WatchAPIKeys_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchAPIKeysRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(UsersInternalServiceServer).WatchAPIKeys(m, &usersInternalServiceWatchAPIKeysServer{stream})
+}
+
+type UsersInternalService_WatchAPIKeysServer interface {
+	Send(*APIKeyEvent) error
+	grpc.ServerStream
+}
+
+type usersInternalServiceWatchAPIKeysServer struct {
+	grpc.ServerStream
+}
+
+func (x *usersInternalServiceWatchAPIKeysServer) Send(m *APIKeyEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _UsersInternalService_ReportAPIKeyUsages_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReportAPIKeyUsagesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UsersInternalServiceServer).ReportAPIKeyUsages(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/llmariner.users.server.v1.UsersInternalService/ReportAPIKeyUsages",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UsersInternalServiceServer).ReportAPIKeyUsages(ctx, req.(*ReportAPIKeyUsagesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _UsersInternalService_ListInternalOrganizations_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(ListInternalOrganizationsRequest)
 	if err := dec(in); err != nil {
@@ -1132,6 +1954,14 @@ var UsersInternalService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ListInternalAPIKeys",
 			Handler:    _UsersInternalService_ListInternalAPIKeys_Handler,
 		},
+		{
+			MethodName: "AuthenticateAPIKey",
+			Handler:    _UsersInternalService_AuthenticateAPIKey_Handler,
+		},
+		{
+			MethodName: "ReportAPIKeyUsages",
+			Handler:    _UsersInternalService_ReportAPIKeyUsages_Handler,
+		},
 		{
 			MethodName: "ListInternalOrganizations",
 			Handler:    _UsersInternalService_ListInternalOrganizations_Handler,
@@ -1157,6 +1987,12 @@ var UsersInternalService_ServiceDesc = grpc.ServiceDesc{
 			Handler:    _UsersInternalService_CreateUserInternal_Handler,
 		},
 	},
-	Streams:  []grpc.StreamDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchAPIKeys",
+			Handler:       _UsersInternalService_WatchAPIKeys_Handler,
+			ServerStreams: true,
+		},
+	},
 	Metadata: "api/v1/user_manager_service.proto",
 }
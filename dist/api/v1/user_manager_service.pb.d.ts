@@ -12,6 +12,25 @@ export declare enum ProjectRole {
     PROJECT_ROLE_OWNER = "PROJECT_ROLE_OWNER",
     PROJECT_ROLE_MEMBER = "PROJECT_ROLE_MEMBER"
 }
+export declare enum InvitationState {
+    INVITATION_STATE_UNSPECIFIED = "INVITATION_STATE_UNSPECIFIED",
+    INVITATION_STATE_PENDING = "INVITATION_STATE_PENDING",
+    INVITATION_STATE_ACCEPTED = "INVITATION_STATE_ACCEPTED",
+    INVITATION_STATE_DECLINED = "INVITATION_STATE_DECLINED",
+    INVITATION_STATE_REVOKED = "INVITATION_STATE_REVOKED",
+    INVITATION_STATE_EXPIRED = "INVITATION_STATE_EXPIRED"
+}
+export declare enum ListAPIKeysRequestServiceAccountFilter {
+    SERVICE_ACCOUNT_FILTER_UNSPECIFIED = "SERVICE_ACCOUNT_FILTER_UNSPECIFIED",
+    SERVICE_ACCOUNT_FILTER_ONLY = "SERVICE_ACCOUNT_FILTER_ONLY",
+    SERVICE_ACCOUNT_FILTER_EXCLUDE = "SERVICE_ACCOUNT_FILTER_EXCLUDE"
+}
+export declare enum APIKeyEventType {
+    TYPE_UNSPECIFIED = "TYPE_UNSPECIFIED",
+    TYPE_CREATED = "TYPE_CREATED",
+    TYPE_UPDATED = "TYPE_UPDATED",
+    TYPE_DELETED = "TYPE_DELETED"
+}
 export type APIKey = {
     id?: string;
     object?: string;
@@ -24,6 +43,26 @@ export type APIKey = {
     organization_role?: OrganizationRole;
     project_role?: ProjectRole;
     excluded_from_rate_limiting?: boolean;
+    expires_at?: string;
+    previous_secret_expires_at?: string;
+    last_used_at?: string;
+    last_used_source_ip?: string;
+    scopes?: string[];
+    allowed_models?: string[];
+    allowed_cidrs?: string[];
+    limits?: APIKeyLimits;
+    enabled?: boolean;
+    labels?: {
+        [key: string]: string;
+    };
+    description?: string;
+    imported_from?: string;
+    imported_by?: string;
+};
+export type APIKeyLimits = {
+    requests_per_minute?: string;
+    tokens_per_minute?: string;
+    monthly_token_budget?: string;
 };
 export type UserOrganizationRoleBinding = {
     organization_id?: string;
@@ -101,15 +140,43 @@ export type CreateAPIKeyRequest = {
     is_service_account?: boolean;
     role?: OrganizationRole;
     excluded_from_rate_limiting?: boolean;
+    expires_at?: string;
+    ttl_seconds?: string;
+    scopes?: string[];
+    allowed_models?: string[];
+    allowed_cidrs?: string[];
+    limits?: APIKeyLimits;
+    labels?: {
+        [key: string]: string;
+    };
+    description?: string;
+    service_account_id?: string;
+};
+export type ImportAPIKeyRequest = {
+    api_key?: CreateAPIKeyRequest;
+    secret?: string;
+    source?: string;
 };
 export type ListProjectAPIKeysRequest = {
     project_id?: string;
     organization_id?: string;
 };
-export type ListAPIKeysRequest = {};
+export type ListAPIKeysRequest = {
+    after?: string;
+    limit?: number;
+    organization_id?: string;
+    project_id?: string;
+    user_id?: string;
+    service_account_filter?: ListAPIKeysRequestServiceAccountFilter;
+    name_prefix?: string;
+    order_by?: string;
+    order?: string;
+    label_selector?: string;
+};
 export type ListAPIKeysResponse = {
     object?: string;
     data?: APIKey[];
+    has_more?: boolean;
 };
 export type DeleteAPIKeyRequest = {
     id?: string;
@@ -128,6 +195,18 @@ export type UpdateAPIKeyRequest = {
     api_key?: APIKey;
     update_mask?: GoogleProtobufField_mask.FieldMask;
 };
+export type RotateAPIKeyRequest = {
+    id?: string;
+    grace_period_seconds?: string;
+};
+export type TransferAPIKeyRequest = {
+    id?: string;
+    user_id?: string;
+};
+export type TransferAPIKeyResponse = {
+    api_key?: APIKey;
+    previous_user_id?: string;
+};
 export type CreateOrganizationRequest = {
     title?: string;
 };
@@ -137,6 +216,10 @@ export type ListOrganizationsRequest = {
 export type ListOrganizationsResponse = {
     organizations?: Organization[];
 };
+export type GetOrganizationRequest = {
+    id?: string;
+    include_summary?: boolean;
+};
 export type DeleteOrganizationRequest = {
     id?: string;
 };
@@ -145,6 +228,10 @@ export type DeleteOrganizationResponse = {
     object?: string;
     deleted?: boolean;
 };
+export type UpdateOrganizationRequest = {
+    organization?: Organization;
+    update_mask?: GoogleProtobufField_mask.FieldMask;
+};
 export type CreateOrganizationUserRequest = {
     organization_id?: string;
     user_id?: string;
@@ -159,11 +246,19 @@ export type ListOrganizationUsersResponse = {
 export type DeleteOrganizationUserRequest = {
     organization_id?: string;
     user_id?: string;
+    disable_api_keys?: boolean;
 };
 export type DeleteOrganizationUserResponse = {
     id?: string;
     object?: string;
     deleted?: boolean;
+    revoked_api_key_ids?: string[];
+    disabled_api_key_ids?: string[];
+};
+export type UpdateOrganizationUserRequest = {
+    organization_id?: string;
+    user_id?: string;
+    role?: OrganizationRole;
 };
 export type CreateProjectRequest = {
     title?: string;
@@ -174,9 +269,17 @@ export type CreateProjectRequest = {
 export type ListProjectsRequest = {
     organization_id?: string;
     include_summary?: boolean;
+    since_revision?: string;
+};
+export type GetProjectRequest = {
+    organization_id?: string;
+    id?: string;
+    include_summary?: boolean;
 };
 export type ListProjectsResponse = {
     projects?: Project[];
+    revision?: string;
+    deleted_project_ids?: string[];
 };
 export type DeleteProjectRequest = {
     organization_id?: string;
@@ -200,40 +303,178 @@ export type CreateProjectUserRequest = {
 export type ListProjectUsersRequest = {
     organization_id?: string;
     project_id?: string;
+    since_revision?: string;
 };
 export type ListProjectUsersResponse = {
     users?: ProjectUser[];
+    revision?: string;
+    deleted_users?: ProjectUser[];
 };
 export type DeleteProjectUserRequest = {
     organization_id?: string;
     project_id?: string;
     user_id?: string;
+    disable_api_keys?: boolean;
 };
 export type DeleteProjectUserResponse = {
     id?: string;
     object?: string;
     deleted?: boolean;
+    revoked_api_key_ids?: string[];
+    disabled_api_key_ids?: string[];
+};
+export type UpdateProjectUserRequest = {
+    organization_id?: string;
+    project_id?: string;
+    user_id?: string;
+    role?: ProjectRole;
+};
+export type ServiceAccount = {
+    id?: string;
+    name?: string;
+    description?: string;
+    organization_id?: string;
+    project_id?: string;
+    user_id?: string;
+    organization_role?: OrganizationRole;
+    project_role?: ProjectRole;
+    created_at?: string;
+    api_key_count?: number;
+};
+export type CreateServiceAccountRequest = {
+    organization_id?: string;
+    project_id?: string;
+    name?: string;
+    description?: string;
+    organization_role?: OrganizationRole;
+    project_role?: ProjectRole;
+};
+export type ListServiceAccountsRequest = {
+    organization_id?: string;
+    project_id?: string;
+};
+export type ListServiceAccountsResponse = {
+    object?: string;
+    data?: ServiceAccount[];
+};
+export type GetServiceAccountRequest = {
+    organization_id?: string;
+    project_id?: string;
+    id?: string;
+};
+export type UpdateServiceAccountRequest = {
+    service_account?: ServiceAccount;
+    update_mask?: GoogleProtobufField_mask.FieldMask;
+};
+export type DeleteServiceAccountRequest = {
+    organization_id?: string;
+    project_id?: string;
+    id?: string;
+};
+export type DeleteServiceAccountResponse = {
+    id?: string;
+    object?: string;
+    deleted?: boolean;
+    deleted_api_key_ids?: string[];
+};
+export type InvitationProjectBinding = {
+    project_id?: string;
+    role?: ProjectRole;
+};
+export type Invitation = {
+    id?: string;
+    organization_id?: string;
+    user_id?: string;
+    role?: OrganizationRole;
+    project_bindings?: InvitationProjectBinding[];
+    inviter_user_id?: string;
+    state?: InvitationState;
+    created_at?: string;
+    expires_at?: string;
+};
+export type InvitationProjectBindings = {
+    project_bindings?: InvitationProjectBinding[];
+};
+export type CreateInvitationRequest = {
+    organization_id?: string;
+    user_id?: string;
+    role?: OrganizationRole;
+    project_bindings?: InvitationProjectBinding[];
+    ttl_seconds?: string;
+};
+export type ListInvitationsRequest = {};
+export type ListInvitationsResponse = {
+    invitations?: Invitation[];
+};
+export type ListOrganizationInvitationsRequest = {
+    organization_id?: string;
+};
+export type AcceptInvitationRequest = {
+    id?: string;
+};
+export type DeclineInvitationRequest = {
+    id?: string;
+};
+export type RevokeInvitationRequest = {
+    organization_id?: string;
+    id?: string;
 };
 export type GetUserSelfRequest = {};
-export type ListUsersRequest = {};
+export type ListUsersRequest = {
+    since_revision?: string;
+};
 export type ListUsersResponse = {
     users?: User[];
+    revision?: string;
+    deleted_user_ids?: string[];
 };
 export type InternalAPIKey = {
     api_key?: APIKey;
     tenant_id?: string;
+    secret_hashed?: boolean;
+    secret_prefix?: string;
+    secret_salt?: Uint8Array;
+    secret_hash?: Uint8Array;
+};
+export type ListInternalAPIKeysRequest = {
+    since_revision?: string;
 };
-export type ListInternalAPIKeysRequest = {};
 export type ListInternalAPIKeysResponse = {
     api_keys?: InternalAPIKey[];
+    revision?: string;
+    deleted_api_key_ids?: string[];
+};
+export type AuthenticateAPIKeyRequest = {
+    secret?: string;
+};
+export type WatchAPIKeysRequest = {
+    since_revision?: string;
+};
+export type APIKeyEvent = {
+    type?: APIKeyEventType;
+    revision?: string;
+    api_key_id?: string;
+    api_keys?: InternalAPIKey[];
+};
+export type APIKeyUsage = {
+    api_key_id?: string;
+    used_at?: string;
+    source_ip?: string;
+};
+export type ReportAPIKeyUsagesRequest = {
+    usages?: APIKeyUsage[];
 };
 export type InternalOrganization = {
     organization?: Organization;
     tenant_id?: string;
 };
-export type ListInternalOrganizationsRequest = {};
+export type ListInternalOrganizationsRequest = {
+    since_revision?: string;
+};
 export type ListInternalOrganizationsResponse = {
     organizations?: InternalOrganization[];
+    revision?: string;
+    deleted_organization_ids?: string[];
 };
 export type CreateUserInternalRequest = {
     tenant_id?: string;
@@ -246,27 +487,49 @@ export declare class UsersService {
     static ListAPIKeys(req: ListAPIKeysRequest, initReq?: fm.InitReq): Promise<ListAPIKeysResponse>;
     static DeleteAPIKey(req: DeleteAPIKeyRequest, initReq?: fm.InitReq): Promise<DeleteAPIKeyResponse>;
     static UpdateAPIKey(req: UpdateAPIKeyRequest, initReq?: fm.InitReq): Promise<APIKey>;
+    static RotateAPIKey(req: RotateAPIKeyRequest, initReq?: fm.InitReq): Promise<APIKey>;
+    static TransferAPIKey(req: TransferAPIKeyRequest, initReq?: fm.InitReq): Promise<TransferAPIKeyResponse>;
+    static ImportAPIKey(req: ImportAPIKeyRequest, initReq?: fm.InitReq): Promise<APIKey>;
     static CreateProjectAPIKey(req: CreateAPIKeyRequest, initReq?: fm.InitReq): Promise<APIKey>;
     static ListProjectAPIKeys(req: ListProjectAPIKeysRequest, initReq?: fm.InitReq): Promise<ListAPIKeysResponse>;
     static DeleteProjectAPIKey(req: DeleteProjectAPIKeyRequest, initReq?: fm.InitReq): Promise<DeleteAPIKeyResponse>;
     static CreateOrganization(req: CreateOrganizationRequest, initReq?: fm.InitReq): Promise<Organization>;
     static ListOrganizations(req: ListOrganizationsRequest, initReq?: fm.InitReq): Promise<ListOrganizationsResponse>;
+    static GetOrganization(req: GetOrganizationRequest, initReq?: fm.InitReq): Promise<Organization>;
     static DeleteOrganization(req: DeleteOrganizationRequest, initReq?: fm.InitReq): Promise<DeleteOrganizationResponse>;
+    static UpdateOrganization(req: UpdateOrganizationRequest, initReq?: fm.InitReq): Promise<Organization>;
     static CreateOrganizationUser(req: CreateOrganizationUserRequest, initReq?: fm.InitReq): Promise<OrganizationUser>;
     static ListOrganizationUsers(req: ListOrganizationUsersRequest, initReq?: fm.InitReq): Promise<ListOrganizationUsersResponse>;
-    static DeleteOrganizationUser(req: DeleteOrganizationUserRequest, initReq?: fm.InitReq): Promise<GoogleProtobufEmpty.Empty>;
+    static DeleteOrganizationUser(req: DeleteOrganizationUserRequest, initReq?: fm.InitReq): Promise<DeleteOrganizationUserResponse>;
+    static UpdateOrganizationUser(req: UpdateOrganizationUserRequest, initReq?: fm.InitReq): Promise<OrganizationUser>;
     static CreateProject(req: CreateProjectRequest, initReq?: fm.InitReq): Promise<Project>;
     static ListProjects(req: ListProjectsRequest, initReq?: fm.InitReq): Promise<ListProjectsResponse>;
+    static GetProject(req: GetProjectRequest, initReq?: fm.InitReq): Promise<Project>;
     static DeleteProject(req: DeleteProjectRequest, initReq?: fm.InitReq): Promise<DeleteProjectResponse>;
     static UpdateProject(req: UpdateProjectRequest, initReq?: fm.InitReq): Promise<Project>;
     static CreateProjectUser(req: CreateProjectUserRequest, initReq?: fm.InitReq): Promise<ProjectUser>;
     static ListProjectUsers(req: ListProjectUsersRequest, initReq?: fm.InitReq): Promise<ListProjectUsersResponse>;
-    static DeleteProjectUser(req: DeleteProjectUserRequest, initReq?: fm.InitReq): Promise<GoogleProtobufEmpty.Empty>;
+    static DeleteProjectUser(req: DeleteProjectUserRequest, initReq?: fm.InitReq): Promise<DeleteProjectUserResponse>;
+    static UpdateProjectUser(req: UpdateProjectUserRequest, initReq?: fm.InitReq): Promise<ProjectUser>;
+    static CreateServiceAccount(req: CreateServiceAccountRequest, initReq?: fm.InitReq): Promise<ServiceAccount>;
+    static ListServiceAccounts(req: ListServiceAccountsRequest, initReq?: fm.InitReq): Promise<ListServiceAccountsResponse>;
+    static GetServiceAccount(req: GetServiceAccountRequest, initReq?: fm.InitReq): Promise<ServiceAccount>;
+    static UpdateServiceAccount(req: UpdateServiceAccountRequest, initReq?: fm.InitReq): Promise<ServiceAccount>;
+    static DeleteServiceAccount(req: DeleteServiceAccountRequest, initReq?: fm.InitReq): Promise<DeleteServiceAccountResponse>;
+    static CreateInvitation(req: CreateInvitationRequest, initReq?: fm.InitReq): Promise<Invitation>;
+    static ListInvitations(req: ListInvitationsRequest, initReq?: fm.InitReq): Promise<ListInvitationsResponse>;
+    static ListOrganizationInvitations(req: ListOrganizationInvitationsRequest, initReq?: fm.InitReq): Promise<ListInvitationsResponse>;
+    static AcceptInvitation(req: AcceptInvitationRequest, initReq?: fm.InitReq): Promise<Invitation>;
+    static DeclineInvitation(req: DeclineInvitationRequest, initReq?: fm.InitReq): Promise<Invitation>;
+    static RevokeInvitation(req: RevokeInvitationRequest, initReq?: fm.InitReq): Promise<Invitation>;
     static GetUserSelf(req: GetUserSelfRequest, initReq?: fm.InitReq): Promise<User>;
     static ListUsers(req: ListUsersRequest, initReq?: fm.InitReq): Promise<ListUsersResponse>;
 }
 export declare class UsersInternalService {
     static ListInternalAPIKeys(req: ListInternalAPIKeysRequest, initReq?: fm.InitReq): Promise<ListInternalAPIKeysResponse>;
+    static AuthenticateAPIKey(req: AuthenticateAPIKeyRequest, initReq?: fm.InitReq): Promise<InternalAPIKey>;
+    static WatchAPIKeys(req: WatchAPIKeysRequest, entityNotifier?: fm.NotifyStreamEntityArrival<APIKeyEvent>, initReq?: fm.InitReq): Promise<void>;
+    static ReportAPIKeyUsages(req: ReportAPIKeyUsagesRequest, initReq?: fm.InitReq): Promise<GoogleProtobufEmpty.Empty>;
     static ListInternalOrganizations(req: ListInternalOrganizationsRequest, initReq?: fm.InitReq): Promise<ListInternalOrganizationsResponse>;
     static ListOrganizationUsers(req: ListOrganizationUsersRequest, initReq?: fm.InitReq): Promise<ListOrganizationUsersResponse>;
     static ListProjects(req: ListProjectsRequest, initReq?: fm.InitReq): Promise<ListProjectsResponse>;
@@ -17,6 +17,28 @@ export var ProjectRole;
     ProjectRole["PROJECT_ROLE_OWNER"] = "PROJECT_ROLE_OWNER";
     ProjectRole["PROJECT_ROLE_MEMBER"] = "PROJECT_ROLE_MEMBER";
 })(ProjectRole || (ProjectRole = {}));
+export var InvitationState;
+(function (InvitationState) {
+    InvitationState["INVITATION_STATE_UNSPECIFIED"] = "INVITATION_STATE_UNSPECIFIED";
+    InvitationState["INVITATION_STATE_PENDING"] = "INVITATION_STATE_PENDING";
+    InvitationState["INVITATION_STATE_ACCEPTED"] = "INVITATION_STATE_ACCEPTED";
+    InvitationState["INVITATION_STATE_DECLINED"] = "INVITATION_STATE_DECLINED";
+    InvitationState["INVITATION_STATE_REVOKED"] = "INVITATION_STATE_REVOKED";
+    InvitationState["INVITATION_STATE_EXPIRED"] = "INVITATION_STATE_EXPIRED";
+})(InvitationState || (InvitationState = {}));
+export var ListAPIKeysRequestServiceAccountFilter;
+(function (ListAPIKeysRequestServiceAccountFilter) {
+    ListAPIKeysRequestServiceAccountFilter["SERVICE_ACCOUNT_FILTER_UNSPECIFIED"] = "SERVICE_ACCOUNT_FILTER_UNSPECIFIED";
+    ListAPIKeysRequestServiceAccountFilter["SERVICE_ACCOUNT_FILTER_ONLY"] = "SERVICE_ACCOUNT_FILTER_ONLY";
+    ListAPIKeysRequestServiceAccountFilter["SERVICE_ACCOUNT_FILTER_EXCLUDE"] = "SERVICE_ACCOUNT_FILTER_EXCLUDE";
+})(ListAPIKeysRequestServiceAccountFilter || (ListAPIKeysRequestServiceAccountFilter = {}));
+export var APIKeyEventType;
+(function (APIKeyEventType) {
+    APIKeyEventType["TYPE_UNSPECIFIED"] = "TYPE_UNSPECIFIED";
+    APIKeyEventType["TYPE_CREATED"] = "TYPE_CREATED";
+    APIKeyEventType["TYPE_UPDATED"] = "TYPE_UPDATED";
+    APIKeyEventType["TYPE_DELETED"] = "TYPE_DELETED";
+})(APIKeyEventType || (APIKeyEventType = {}));
 export class UsersService {
     static CreateAPIKey(req, initReq) {
         return fm.fetchReq(`/v1/api_keys`, Object.assign(Object.assign({}, initReq), { method: "POST", body: JSON.stringify(req) }));
@@ -30,6 +52,15 @@ export class UsersService {
     static UpdateAPIKey(req, initReq) {
         return fm.fetchReq(`/v1/api_keys/${req["api_key.id"]}`, Object.assign(Object.assign({}, initReq), { method: "PATCH", body: JSON.stringify(req) }));
     }
+    static RotateAPIKey(req, initReq) {
+        return fm.fetchReq(`/v1/api_keys/${req["id"]}/rotate`, Object.assign(Object.assign({}, initReq), { method: "POST", body: JSON.stringify(req) }));
+    }
+    static TransferAPIKey(req, initReq) {
+        return fm.fetchReq(`/v1/api_keys/${req["id"]}/transfer`, Object.assign(Object.assign({}, initReq), { method: "POST", body: JSON.stringify(req) }));
+    }
+    static ImportAPIKey(req, initReq) {
+        return fm.fetchReq(`/v1/api_keys/import`, Object.assign(Object.assign({}, initReq), { method: "POST", body: JSON.stringify(req) }));
+    }
     static CreateProjectAPIKey(req, initReq) {
         return fm.fetchReq(`/v1/organizations/${req["organization_id"]}/projects/${req["project_id"]}/api_keys`, Object.assign(Object.assign({}, initReq), { method: "POST", body: JSON.stringify(req) }));
     }
@@ -45,9 +76,15 @@ export class UsersService {
     static ListOrganizations(req, initReq) {
         return fm.fetchReq(`/v1/organizations?${fm.renderURLSearchParams(req, [])}`, Object.assign(Object.assign({}, initReq), { method: "GET" }));
     }
+    static GetOrganization(req, initReq) {
+        return fm.fetchReq(`/v1/organizations/${req["id"]}?${fm.renderURLSearchParams(req, ["id"])}`, Object.assign(Object.assign({}, initReq), { method: "GET" }));
+    }
     static DeleteOrganization(req, initReq) {
         return fm.fetchReq(`/v1/organizations/${req["id"]}`, Object.assign(Object.assign({}, initReq), { method: "DELETE" }));
     }
+    static UpdateOrganization(req, initReq) {
+        return fm.fetchReq(`/v1/organizations/${req["organization.id"]}`, Object.assign(Object.assign({}, initReq), { method: "PATCH", body: JSON.stringify(req) }));
+    }
     static CreateOrganizationUser(req, initReq) {
         return fm.fetchReq(`/v1/organizations/${req["organization_id"]}/users`, Object.assign(Object.assign({}, initReq), { method: "POST", body: JSON.stringify(req) }));
     }
@@ -57,12 +94,18 @@ export class UsersService {
     static DeleteOrganizationUser(req, initReq) {
         return fm.fetchReq(`/v1/organizations/${req["organization_id"]}/users/${req["user_id"]}`, Object.assign(Object.assign({}, initReq), { method: "DELETE" }));
     }
+    static UpdateOrganizationUser(req, initReq) {
+        return fm.fetchReq(`/v1/organizations/${req["organization_id"]}/users/${req["user_id"]}`, Object.assign(Object.assign({}, initReq), { method: "PATCH", body: JSON.stringify(req) }));
+    }
     static CreateProject(req, initReq) {
         return fm.fetchReq(`/v1/organizations/${req["organization_id"]}/projects`, Object.assign(Object.assign({}, initReq), { method: "POST", body: JSON.stringify(req) }));
     }
     static ListProjects(req, initReq) {
         return fm.fetchReq(`/v1/organizations/${req["organization_id"]}/projects?${fm.renderURLSearchParams(req, ["organization_id"])}`, Object.assign(Object.assign({}, initReq), { method: "GET" }));
     }
+    static GetProject(req, initReq) {
+        return fm.fetchReq(`/v1/organizations/${req["organization_id"]}/projects/${req["id"]}?${fm.renderURLSearchParams(req, ["organization_id", "id"])}`, Object.assign(Object.assign({}, initReq), { method: "GET" }));
+    }
     static DeleteProject(req, initReq) {
         return fm.fetchReq(`/v1/organizations/${req["organization_id"]}/projects/${req["id"]}`, Object.assign(Object.assign({}, initReq), { method: "DELETE" }));
     }
@@ -78,6 +121,42 @@ export class UsersService {
     static DeleteProjectUser(req, initReq) {
         return fm.fetchReq(`/v1/organizations/${req["organization_id"]}/projects/${req["project_id"]}/users/${req["user_id"]}`, Object.assign(Object.assign({}, initReq), { method: "DELETE" }));
     }
+    static UpdateProjectUser(req, initReq) {
+        return fm.fetchReq(`/v1/organizations/${req["organization_id"]}/projects/${req["project_id"]}/users/${req["user_id"]}`, Object.assign(Object.assign({}, initReq), { method: "PATCH", body: JSON.stringify(req) }));
+    }
+    static CreateServiceAccount(req, initReq) {
+        return fm.fetchReq(`/v1/organizations/${req["organization_id"]}/projects/${req["project_id"]}/service_accounts`, Object.assign(Object.assign({}, initReq), { method: "POST", body: JSON.stringify(req) }));
+    }
+    static ListServiceAccounts(req, initReq) {
+        return fm.fetchReq(`/v1/organizations/${req["organization_id"]}/projects/${req["project_id"]}/service_accounts?${fm.renderURLSearchParams(req, ["organization_id", "project_id"])}`, Object.assign(Object.assign({}, initReq), { method: "GET" }));
+    }
+    static GetServiceAccount(req, initReq) {
+        return fm.fetchReq(`/v1/organizations/${req["organization_id"]}/projects/${req["project_id"]}/service_accounts/${req["id"]}?${fm.renderURLSearchParams(req, ["organization_id", "project_id", "id"])}`, Object.assign(Object.assign({}, initReq), { method: "GET" }));
+    }
+    static UpdateServiceAccount(req, initReq) {
+        return fm.fetchReq(`/v1/organizations/${req["service_account.organization_id"]}/projects/${req["service_account.project_id"]}/service_accounts/${req["service_account.id"]}`, Object.assign(Object.assign({}, initReq), { method: "PATCH", body: JSON.stringify(req) }));
+    }
+    static DeleteServiceAccount(req, initReq) {
+        return fm.fetchReq(`/v1/organizations/${req["organization_id"]}/projects/${req["project_id"]}/service_accounts/${req["id"]}`, Object.assign(Object.assign({}, initReq), { method: "DELETE" }));
+    }
+    static CreateInvitation(req, initReq) {
+        return fm.fetchReq(`/v1/organizations/${req["organization_id"]}/invitations`, Object.assign(Object.assign({}, initReq), { method: "POST", body: JSON.stringify(req) }));
+    }
+    static ListInvitations(req, initReq) {
+        return fm.fetchReq(`/v1/invitations?${fm.renderURLSearchParams(req, [])}`, Object.assign(Object.assign({}, initReq), { method: "GET" }));
+    }
+    static ListOrganizationInvitations(req, initReq) {
+        return fm.fetchReq(`/v1/organizations/${req["organization_id"]}/invitations?${fm.renderURLSearchParams(req, ["organization_id"])}`, Object.assign(Object.assign({}, initReq), { method: "GET" }));
+    }
+    static AcceptInvitation(req, initReq) {
+        return fm.fetchReq(`/v1/invitations/${req["id"]}/accept`, Object.assign(Object.assign({}, initReq), { method: "POST", body: JSON.stringify(req) }));
+    }
+    static DeclineInvitation(req, initReq) {
+        return fm.fetchReq(`/v1/invitations/${req["id"]}/decline`, Object.assign(Object.assign({}, initReq), { method: "POST", body: JSON.stringify(req) }));
+    }
+    static RevokeInvitation(req, initReq) {
+        return fm.fetchReq(`/v1/organizations/${req["organization_id"]}/invitations/${req["id"]}/revoke`, Object.assign(Object.assign({}, initReq), { method: "POST", body: JSON.stringify(req) }));
+    }
     static GetUserSelf(req, initReq) {
         return fm.fetchReq(`/v1/users:getSelf?${fm.renderURLSearchParams(req, [])}`, Object.assign(Object.assign({}, initReq), { method: "GET" }));
     }
@@ -89,6 +168,15 @@ export class UsersInternalService {
     static ListInternalAPIKeys(req, initReq) {
         return fm.fetchReq(`/llmariner.users.server.v1.UsersInternalService/ListInternalAPIKeys`, Object.assign(Object.assign({}, initReq), { method: "POST", body: JSON.stringify(req) }));
     }
+    static AuthenticateAPIKey(req, initReq) {
+        return fm.fetchReq(`/llmariner.users.server.v1.UsersInternalService/AuthenticateAPIKey`, Object.assign(Object.assign({}, initReq), { method: "POST", body: JSON.stringify(req) }));
+    }
+    static WatchAPIKeys(req, entityNotifier, initReq) {
+        return fm.fetchStreamingRequest(`/llmariner.users.server.v1.UsersInternalService/WatchAPIKeys`, entityNotifier, Object.assign(Object.assign({}, initReq), { method: "POST", body: JSON.stringify(req) }));
+    }
+    static ReportAPIKeyUsages(req, initReq) {
+        return fm.fetchReq(`/llmariner.users.server.v1.UsersInternalService/ReportAPIKeyUsages`, Object.assign(Object.assign({}, initReq), { method: "POST", body: JSON.stringify(req) }));
+    }
     static ListInternalOrganizations(req, initReq) {
         return fm.fetchReq(`/llmariner.users.server.v1.UsersInternalService/ListInternalOrganizations`, Object.assign(Object.assign({}, initReq), { method: "POST", body: JSON.stringify(req) }));
     }
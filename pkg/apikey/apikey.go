@@ -0,0 +1,213 @@
+package apikey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash/crc32"
+	"math"
+	"strings"
+)
+
+const (
+	// LegacyPrefix is the prefix of API key secrets.
+	LegacyPrefix = "sk-"
+	// V1Prefix is the prefix of API key secrets in the version 1 format.
+	V1Prefix = "sk-v1."
+
+	// KeyIDPrefix is the prefix of API key IDs.
+	KeyIDPrefix = "key_"
+
+	keyIDLen      = 16
+	tenantHintLen = 8
+	randomLen     = 40
+	checksumLen   = 6
+
+	v1Len = len(V1Prefix) + keyIDLen + tenantHintLen + randomLen + checksumLen
+
+	base62Chars = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+)
+
+// Secret is a parsed API key secret in the version 1 format.
+//
+// The format is "sk-v1." followed by the key ID (without "key_"), the tenant hint,
+// random characters, and a base62-encoded CRC32 checksum of all the preceding characters.
+// Each component has a fixed length.
+type Secret struct {
+	// KeyID is the ID of the API key (e.g., "key_0123456789abcdef").
+	KeyID string
+	// TenantHint is derived from the tenant ID. See TenantHint.
+	TenantHint string
+}
+
+// Generate generates a new secret in the version 1 format for the API key.
+func Generate(keyID, tenantID string) (string, error) {
+	if !strings.HasPrefix(keyID, KeyIDPrefix) || len(keyID) != len(KeyIDPrefix)+keyIDLen {
+		return "", fmt.Errorf("invalid key ID %q", keyID)
+	}
+
+	b := make([]byte, (randomLen*3)/4)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	random := base64.RawURLEncoding.EncodeToString(b)[:randomLen]
+
+	body := V1Prefix + strings.TrimPrefix(keyID, KeyIDPrefix) + TenantHint(tenantID) + random
+	return body + checksum(body), nil
+}
+
+// Parse parses a secret in the version 1 format and verifies its checksum.
+func Parse(secret string) (*Secret, error) {
+	if !strings.HasPrefix(secret, V1Prefix) {
+		return nil, fmt.Errorf("secret does not start with %q", V1Prefix)
+	}
+	if len(secret) != v1Len {
+		return nil, fmt.Errorf("secret has an invalid length")
+	}
+	body, sum := secret[:v1Len-checksumLen], secret[v1Len-checksumLen:]
+	if checksum(body) != sum {
+		return nil, fmt.Errorf("secret has an invalid checksum")
+	}
+	rest := strings.TrimPrefix(body, V1Prefix)
+	return &Secret{
+		KeyID:      KeyIDPrefix + rest[:keyIDLen],
+		TenantHint: rest[keyIDLen : keyIDLen+tenantHintLen],
+	}, nil
+}
+
+// Validate returns an error if the secret is malformed. Secrets in the version 1 format
+// must have a valid checksum. Legacy secrets ("sk-" followed by random characters) are also accepted.
+func Validate(secret string) error {
+	if strings.HasPrefix(secret, V1Prefix) {
+		_, err := Parse(secret)
+		return err
+	}
+	if !strings.HasPrefix(secret, LegacyPrefix) {
+		return fmt.Errorf("secret does not start with %q", LegacyPrefix)
+	}
+	rest := strings.TrimPrefix(secret, LegacyPrefix)
+	if rest == "" {
+		return fmt.Errorf("secret is empty")
+	}
+	if !isURLSafe(rest) {
+		return fmt.Errorf("secret contains invalid characters")
+	}
+	return nil
+}
+
+// EntropyBits estimates the entropy of the secret in bits. The secret (excluding the "sk-" prefix) is
+// assumed to be drawn from the alphabet of the character classes that it uses (lowercase letters,
+// uppercase letters, digits, and symbols). Characters predictable from the preceding ones carry no
+// entropy: repeated characters ("aaa"), ascending or descending sequences ("abc", "987"), and
+// short repeated patterns ("x1y2x1y2"). The estimate is further capped by the Shannon entropy of
+// the character distribution.
+func EntropyBits(secret string) float64 {
+	rest := []rune(strings.TrimPrefix(secret, LegacyPrefix))
+	if len(rest) == 0 {
+		return 0
+	}
+	return math.Min(sequenceEntropyBits(rest), shannonEntropyBits(rest))
+}
+
+func sequenceEntropyBits(s []rune) float64 {
+	var lower, upper, digit, symbol bool
+	for _, c := range s {
+		switch {
+		case 'a' <= c && c <= 'z':
+			lower = true
+		case 'A' <= c && c <= 'Z':
+			upper = true
+		case '0' <= c && c <= '9':
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+	var alphabet int
+	if lower {
+		alphabet += 26
+	}
+	if upper {
+		alphabet += 26
+	}
+	if digit {
+		alphabet += 10
+	}
+	if symbol {
+		alphabet += 33
+	}
+	bitsPerChar := math.Log2(float64(alphabet))
+
+	var bits float64
+	for i := range s {
+		if !isPredictable(s, i) {
+			bits += bitsPerChar
+		}
+	}
+	return bits
+}
+
+// maxPatternPeriod is the maximum length of a repeated pattern detected by isPredictable.
+const maxPatternPeriod = 8
+
+// isPredictable returns true if s[i] repeats the previous character, continues an ascending or
+// descending sequence, or continues a repeated pattern.
+func isPredictable(s []rune, i int) bool {
+	if i == 0 {
+		return false
+	}
+	d := s[i] - s[i-1]
+	if d == 0 {
+		return true
+	}
+	if i > 1 && (d == 1 || d == -1) && d == s[i-1]-s[i-2] {
+		return true
+	}
+	for p := 2; p <= maxPatternPeriod && i-1-p >= 0; p++ {
+		if s[i] == s[i-p] && s[i-1] == s[i-1-p] {
+			return true
+		}
+	}
+	return false
+}
+
+func shannonEntropyBits(s []rune) float64 {
+	counts := map[rune]int{}
+	for _, c := range s {
+		counts[c]++
+	}
+	n := float64(len(s))
+	var h float64
+	for _, c := range counts {
+		p := float64(c) / n
+		h -= p * math.Log2(p)
+	}
+	return h * n
+}
+
+// TenantHint returns a short, non-reversible hint of the tenant ID embedded in secrets.
+func TenantHint(tenantID string) string {
+	h := sha256.Sum256([]byte(tenantID))
+	return hex.EncodeToString(h[:])[:tenantHintLen]
+}
+
+func checksum(s string) string {
+	c := crc32.ChecksumIEEE([]byte(s))
+	b := make([]byte, checksumLen)
+	for i := checksumLen - 1; i >= 0; i-- {
+		b[i] = base62Chars[c%62]
+		c /= 62
+	}
+	return string(b)
+}
+
+func isURLSafe(s string) bool {
+	for _, c := range s {
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '-' || c == '_') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,93 @@
+package apikey
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateAndParse(t *testing.T) {
+	const keyID = "key_0123456789abcdef"
+	secret, err := Generate(keyID, "tenant0")
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(secret, V1Prefix))
+
+	s, err := Parse(secret)
+	assert.NoError(t, err)
+	assert.Equal(t, keyID, s.KeyID)
+	assert.Equal(t, TenantHint("tenant0"), s.TenantHint)
+	assert.NotEqual(t, TenantHint("tenant1"), s.TenantHint)
+
+	assert.NoError(t, Validate(secret))
+
+	// Flip a character.
+	b := []byte(secret)
+	i := len(V1Prefix) + 20
+	if b[i] == 'a' {
+		b[i] = 'b'
+	} else {
+		b[i] = 'a'
+	}
+	_, err = Parse(string(b))
+	assert.Error(t, err)
+	assert.Error(t, Validate(string(b)))
+
+	_, err = Parse(secret[:len(secret)-1])
+	assert.Error(t, err)
+
+	_, err = Generate("invalid", "tenant0")
+	assert.Error(t, err)
+}
+
+func TestValidate(t *testing.T) {
+	tcs := []struct {
+		secret  string
+		wantErr bool
+	}{
+		{
+			secret: "sk-Ab3_x-9zQ",
+		},
+		{
+			secret:  "sk-",
+			wantErr: true,
+		},
+		{
+			secret:  "sk-abc def",
+			wantErr: true,
+		},
+		{
+			secret:  "pk-abc",
+			wantErr: true,
+		},
+		{
+			secret:  "sk-v1.abc",
+			wantErr: true,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.secret, func(t *testing.T) {
+			err := Validate(tc.secret)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestEntropyBits(t *testing.T) {
+	assert.Equal(t, 0.0, EntropyBits("sk-"))
+	assert.Equal(t, 0.0, EntropyBits("sk-aaaaaaaaaaaaaaaa"))
+	// Sequences and repeated patterns are not counted.
+	assert.InDelta(t, 3*math.Log2(26), EntropyBits("sk-abababababababab"), 1e-9)
+	assert.InDelta(t, 2*math.Log2(26), EntropyBits("sk-abcdefghijklmnopqrstuvwxyz"), 1e-9)
+	assert.InDelta(t, 2*math.Log2(10), EntropyBits("sk-9876543210"), 1e-9)
+	assert.Less(t, EntropyBits("sk-"+strings.Repeat("x1Y2", 50)), 40.0)
+
+	secret, err := Generate("key_0123456789abcdef", "tenant0")
+	assert.NoError(t, err)
+	assert.Greater(t, EntropyBits(secret), 200.0)
+}
@@ -0,0 +1,98 @@
+package scope
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	// Read is the capability to read a resource.
+	Read = "read"
+	// Write is the capability to modify a resource. It implies Read.
+	Write = "write"
+)
+
+// Resources of the user manager. The server authorizes its RPCs with these resources.
+const (
+	ResourceAPIKeys           = "api.organizations.projects.api_keys"
+	ResourceOrganizations     = "api.organizations"
+	ResourceOrganizationUsers = "api.organizations.users"
+	ResourceProjects          = "api.organizations.projects"
+	ResourceProjectUsers      = "api.organizations.projects.users"
+	ResourceSelfUser          = "api.selfuser"
+)
+
+// resourcePattern is the syntax of a resource name (e.g., "api.models", "api.fine_tuning.jobs").
+var resourcePattern = regexp.MustCompile(`^api(\.[a-z0-9_-]+)+$`)
+
+// ownResources is the set of resources that the user manager defines. Resources of the other
+// services are not known here, so only their syntax is validated.
+var ownResources = map[string]bool{
+	ResourceAPIKeys:           true,
+	ResourceOrganizations:     true,
+	ResourceOrganizationUsers: true,
+	ResourceProjects:          true,
+	ResourceProjectUsers:      true,
+	ResourceSelfUser:          true,
+}
+
+// isOwnNamespace returns true if the resource is in the namespace of the user manager.
+func isOwnNamespace(resource string) bool {
+	return resource == ResourceSelfUser ||
+		resource == ResourceOrganizations ||
+		strings.HasPrefix(resource, ResourceOrganizations+".")
+}
+
+// Scope is a permission granted to an API key.
+type Scope struct {
+	Resource   string
+	Capability string
+}
+
+// String returns the string representation of the scope.
+func (s Scope) String() string {
+	return s.Resource + ":" + s.Capability
+}
+
+// Parse parses a scope of the form "<resource>:<capability>" (e.g., "api.models:read").
+func Parse(s string) (Scope, error) {
+	resource, capability, ok := strings.Cut(s, ":")
+	if !ok {
+		return Scope{}, fmt.Errorf("scope %q must be in the form of <resource>:<capability>", s)
+	}
+	if !resourcePattern.MatchString(resource) {
+		return Scope{}, fmt.Errorf("invalid resource %q in scope %q", resource, s)
+	}
+	if isOwnNamespace(resource) && !ownResources[resource] {
+		return Scope{}, fmt.Errorf("unknown resource %q in scope %q", resource, s)
+	}
+	if capability != Read && capability != Write {
+		return Scope{}, fmt.Errorf("capability in scope %q must be %q or %q", s, Read, Write)
+	}
+	return Scope{
+		Resource:   resource,
+		Capability: capability,
+	}, nil
+}
+
+// Allows returns true if the scopes allow the capability on the resource.
+// An empty list of scopes allows everything.
+func Allows(scopes []string, resource, capability string) bool {
+	if len(scopes) == 0 {
+		return true
+	}
+	for _, s := range scopes {
+		sc, err := Parse(s)
+		if err != nil {
+			continue
+		}
+		if sc.Resource != resource {
+			continue
+		}
+		if sc.Capability == Write || sc.Capability == capability {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package scope
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tcs := []struct {
+		s       string
+		want    Scope
+		wantErr bool
+	}{
+		{
+			s:    "api.models:read",
+			want: Scope{Resource: "api.models", Capability: Read},
+		},
+		{
+			s:    "api.fine_tuning.jobs:write",
+			want: Scope{Resource: "api.fine_tuning.jobs", Capability: Write},
+		},
+		{
+			s:       "api.models",
+			wantErr: true,
+		},
+		{
+			s:       "models:read",
+			wantErr: true,
+		},
+		{
+			s:       "api.Models:read",
+			wantErr: true,
+		},
+		{
+			s:       "api.models:delete",
+			wantErr: true,
+		},
+		{
+			s:       "api..models:read",
+			wantErr: true,
+		},
+		{
+			s:       "api.organizations.project:read",
+			wantErr: true,
+		},
+		{
+			s:    "api.organizations.projects.api_keys:read",
+			want: Scope{Resource: "api.organizations.projects.api_keys", Capability: Read},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.s, func(t *testing.T) {
+			got, err := Parse(tc.s)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+			assert.Equal(t, tc.s, got.String())
+		})
+	}
+}
+
+func TestAllows(t *testing.T) {
+	scopes := []string{"api.models:read", "api.fine_tuning.jobs:write"}
+	assert.True(t, Allows(scopes, "api.models", Read))
+	assert.False(t, Allows(scopes, "api.models", Write))
+	assert.True(t, Allows(scopes, "api.fine_tuning.jobs", Read))
+	assert.True(t, Allows(scopes, "api.fine_tuning.jobs", Write))
+	assert.False(t, Allows(scopes, "api.files", Read))
+	assert.True(t, Allows(nil, "api.files", Write))
+}
@@ -0,0 +1,57 @@
+package serviceaccount
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Prefix is the prefix of the user IDs of service accounts.
+const Prefix = "system:serviceaccount:"
+
+// ID identifies a service account.
+//
+// Service accounts are scoped to a tenant and a project, so the same name
+// can be used in different projects without colliding.
+type ID struct {
+	TenantID  string
+	ProjectID string
+	Name      string
+}
+
+// UserID returns the user ID of the service account.
+func (i ID) UserID() string {
+	return UserID(i.TenantID, i.ProjectID, i.Name)
+}
+
+// UserID returns the user ID of the service account that has the given name in the project.
+// The format is "system:serviceaccount:<tenant ID>:<project ID>:<name>".
+func UserID(tenantID, projectID, name string) string {
+	return fmt.Sprintf("%s%s:%s:%s", Prefix, tenantID, projectID, name)
+}
+
+// IsServiceAccount returns true if the user ID is the one of a service account.
+// It also returns true for user IDs in the legacy format ("system:serviceaccount:<name>").
+func IsServiceAccount(userID string) bool {
+	return strings.HasPrefix(userID, Prefix)
+}
+
+// Parse parses the user ID of a service account. An error is returned if the user ID is not
+// a service account user ID or is in the legacy format that does not include the tenant and the project.
+func Parse(userID string) (ID, error) {
+	if !IsServiceAccount(userID) {
+		return ID{}, fmt.Errorf("%q is not a service account user ID", userID)
+	}
+	// Tenant and project IDs do not contain ':'. The name is the rest of the string.
+	parts := strings.SplitN(strings.TrimPrefix(userID, Prefix), ":", 3)
+	if len(parts) != 3 {
+		return ID{}, fmt.Errorf("%q does not include a tenant and a project", userID)
+	}
+	if parts[1] == "" || parts[2] == "" {
+		return ID{}, fmt.Errorf("%q has an empty project ID or name", userID)
+	}
+	return ID{
+		TenantID:  parts[0],
+		ProjectID: parts[1],
+		Name:      parts[2],
+	}, nil
+}
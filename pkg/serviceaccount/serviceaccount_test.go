@@ -0,0 +1,59 @@
+package serviceaccount
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tcs := []struct {
+		userID  string
+		want    ID
+		wantErr bool
+	}{
+		{
+			userID: "system:serviceaccount:t0:p0:ci",
+			want:   ID{TenantID: "t0", ProjectID: "p0", Name: "ci"},
+		},
+		{
+			userID: "system:serviceaccount:t0:p0:a:b",
+			want:   ID{TenantID: "t0", ProjectID: "p0", Name: "a:b"},
+		},
+		{
+			// Legacy format.
+			userID:  "system:serviceaccount:ci",
+			wantErr: true,
+		},
+		{
+			userID: "system:serviceaccount::p0:ci",
+			want:   ID{ProjectID: "p0", Name: "ci"},
+		},
+		{
+			userID:  "system:serviceaccount:t0::ci",
+			wantErr: true,
+		},
+		{
+			userID:  "user@example.com",
+			wantErr: true,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.userID, func(t *testing.T) {
+			got, err := Parse(tc.userID)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+			assert.Equal(t, tc.userID, got.UserID())
+		})
+	}
+}
+
+func TestIsServiceAccount(t *testing.T) {
+	assert.True(t, IsServiceAccount(UserID("t0", "p0", "ci")))
+	assert.True(t, IsServiceAccount("system:serviceaccount:ci"))
+	assert.False(t, IsServiceAccount("user@example.com"))
+}
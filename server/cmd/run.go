@@ -20,6 +20,7 @@ import (
 	"github.com/llmariner/rbac-manager/pkg/auth"
 	v1 "github.com/llmariner/user-manager/api/v1"
 	"github.com/llmariner/user-manager/server/internal/config"
+	"github.com/llmariner/user-manager/server/internal/invitation"
 	"github.com/llmariner/user-manager/server/internal/server"
 	"github.com/llmariner/user-manager/server/internal/store"
 	"github.com/spf13/cobra"
@@ -133,6 +134,22 @@ func run(ctx context.Context, c *config.Config) error {
 	}
 
 	s := server.New(st, dataKey, logger)
+	if err := s.BackfillAPIKeySecretPrefixes(ctx); err != nil {
+		return fmt.Errorf("backfill api key secret prefixes: %s", err)
+	}
+	if c.APIKey.HashSecrets {
+		if err := s.EnableAPIKeySecretHashing(ctx); err != nil {
+			return fmt.Errorf("enable api key secret hashing: %s", err)
+		}
+	}
+	if err := s.MigrateServiceAccountUserIDs(); err != nil {
+		return fmt.Errorf("migrate service account user ids: %s", err)
+	}
+	invitationSender, err := invitation.NewSender(c.Invitation.Sender, logger)
+	if err != nil {
+		return fmt.Errorf("create invitation sender: %s", err)
+	}
+	s.SetInvitationSender(invitationSender)
 	go func() {
 		errCh <- s.Run(ctx, c.GRPCPort, c.AuthConfig, usageSetter)
 	}()
@@ -142,6 +159,7 @@ func run(ctx context.Context, c *config.Config) error {
 	}
 
 	is := server.NewInternal(st, dataKey, logger)
+	go is.RunChangePruner(ctx)
 	go func() {
 		errCh <- is.Run(c.InternalGRPCPort)
 	}()
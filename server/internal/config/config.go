@@ -137,6 +137,29 @@ func (c *KMSConfig) validate() error {
 	return nil
 }
 
+// APIKeyConfig is the API key configuration.
+type APIKeyConfig struct {
+	// HashSecrets makes the server store only salted hashes of API key secrets.
+	// The secrets of existing API keys are converted to hashes on startup.
+	HashSecrets bool `yaml:"hashSecrets"`
+}
+
+// InvitationConfig is the invitation configuration.
+type InvitationConfig struct {
+	// Sender is the kind of the sender that delivers invitations. Only "log" is supported.
+	Sender string `yaml:"sender"`
+}
+
+// validate validates the configuration.
+func (c *InvitationConfig) validate() error {
+	switch c.Sender {
+	case "", "log":
+		return nil
+	default:
+		return fmt.Errorf("unsupported sender: %q", c.Sender)
+	}
+}
+
 // Config is the configuration.
 type Config struct {
 	GRPCPort         int `yaml:"grpcPort"`
@@ -156,6 +179,10 @@ type Config struct {
 
 	KMSConfig KMSConfig `yaml:"kms"`
 
+	APIKey APIKeyConfig `yaml:"apiKey"`
+
+	Invitation InvitationConfig `yaml:"invitation"`
+
 	Debug DebugConfig `yaml:"debug"`
 }
 
@@ -221,6 +248,9 @@ func (c *Config) Validate() error {
 	if err := c.KMSConfig.validate(); err != nil {
 		return err
 	}
+	if err := c.Invitation.validate(); err != nil {
+		return fmt.Errorf("invitation: %s", err)
+	}
 	return nil
 }
 
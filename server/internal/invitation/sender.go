@@ -0,0 +1,62 @@
+package invitation
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+const (
+	// SenderKindLog is the kind of the sender that only logs invitations.
+	SenderKindLog = "log"
+)
+
+// Message is an invitation message delivered to an invitee.
+type Message struct {
+	InvitationID      string
+	Email             string
+	OrganizationTitle string
+	InviterUserID     string
+	ExpiresAt         time.Time
+}
+
+// Sender delivers invitation messages.
+type Sender interface {
+	Send(ctx context.Context, m *Message) error
+}
+
+// NewSender creates a new sender of the given kind.
+func NewSender(kind string, log logr.Logger) (Sender, error) {
+	switch kind {
+	case "", SenderKindLog:
+		return NewLogSender(log), nil
+	default:
+		return nil, fmt.Errorf("unsupported invitation sender: %q", kind)
+	}
+}
+
+// NewLogSender creates a new sender that writes invitations to the log instead of delivering them.
+// This is intended for local use.
+func NewLogSender(log logr.Logger) Sender {
+	return &logSender{
+		log: log.WithName("invitation"),
+	}
+}
+
+type logSender struct {
+	log logr.Logger
+}
+
+// Send logs the invitation message.
+func (s *logSender) Send(ctx context.Context, m *Message) error {
+	s.log.Info("Sending invitation",
+		"invitationID", m.InvitationID,
+		"email", m.Email,
+		"organization", m.OrganizationTitle,
+		"inviter", m.InviterUserID,
+		"expiresAt", m.ExpiresAt,
+	)
+	return nil
+}
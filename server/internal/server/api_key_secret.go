@@ -0,0 +1,204 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+
+	"github.com/llmariner/common/pkg/aws"
+	"github.com/llmariner/user-manager/server/internal/store"
+)
+
+const (
+	// secretPrefixLen is the number of leading characters of a secret that are stored in plaintext.
+	secretPrefixLen = 12
+	secretSaltLen   = 16
+)
+
+// storedSecret is a secret of an API key in the form persisted in the store.
+type storedSecret struct {
+	secret    string
+	encrypted []byte
+	prefix    string
+	hash      []byte
+	salt      []byte
+}
+
+func currentSecret(k *store.APIKey) storedSecret {
+	return storedSecret{
+		secret:    k.Secret,
+		encrypted: k.EncryptedSecret,
+		prefix:    k.SecretPrefix,
+		hash:      k.SecretHash,
+		salt:      k.SecretSalt,
+	}
+}
+
+func previousSecret(k *store.APIKey) storedSecret {
+	return storedSecret{
+		secret:    k.PreviousSecret,
+		encrypted: k.PreviousEncryptedSecret,
+		prefix:    k.PreviousSecretPrefix,
+		hash:      k.PreviousSecretHash,
+		salt:      k.PreviousSecretSalt,
+	}
+}
+
+func (s storedSecret) isHashed() bool {
+	return len(s.hash) > 0
+}
+
+// newStoredSecret converts a secret to the form persisted in the store.
+func newStoredSecret(ctx context.Context, dataKey []byte, hashSecrets bool, apiKeyID, secret string) (storedSecret, error) {
+	ss := storedSecret{
+		prefix: secretPrefix(secret),
+	}
+	switch {
+	case hashSecrets:
+		salt, err := generateSecretSalt()
+		if err != nil {
+			return storedSecret{}, err
+		}
+		ss.hash = hashSecret(secret, salt)
+		ss.salt = salt
+	case len(dataKey) > 0:
+		encrypted, err := aws.Encrypt(ctx, secret, apiKeyID, dataKey)
+		if err != nil {
+			return storedSecret{}, err
+		}
+		ss.encrypted = encrypted
+	default:
+		ss.secret = secret
+	}
+	return ss, nil
+}
+
+// EnableAPIKeySecretHashing makes the server store only salted hashes of API key secrets.
+// The secrets of existing API keys are replaced with their hashes.
+func (s *S) EnableAPIKeySecretHashing(ctx context.Context) error {
+	s.hashAPIKeySecrets = true
+
+	ks, err := s.store.ListAPIKeysWithoutSecretHash()
+	if err != nil {
+		return fmt.Errorf("list api keys: %s", err)
+	}
+	for _, k := range ks {
+		secret, err := decryptSecret(ctx, s.dataKey, k.APIKeyID, currentSecret(k))
+		if err != nil {
+			return fmt.Errorf("decrypt secret of api key %q: %s", k.APIKeyID, err)
+		}
+		salt, err := generateSecretSalt()
+		if err != nil {
+			return err
+		}
+		if err := s.store.UpdateAPIKeySecretHash(k.APIKeyID, secretPrefix(secret), hashSecret(secret, salt), salt); err != nil {
+			return fmt.Errorf("update api key %q: %s", k.APIKeyID, err)
+		}
+
+		if ps := previousSecret(k); k.PreviousSecretExpiresAt > 0 && !ps.isHashed() {
+			secret, err := decryptSecret(ctx, s.dataKey, k.APIKeyID, ps)
+			if err != nil {
+				return fmt.Errorf("decrypt previous secret of api key %q: %s", k.APIKeyID, err)
+			}
+			salt, err := generateSecretSalt()
+			if err != nil {
+				return err
+			}
+			if err := s.store.UpdateAPIKeyPreviousSecretHash(k.APIKeyID, hashSecret(secret, salt), salt); err != nil {
+				return fmt.Errorf("update api key %q: %s", k.APIKeyID, err)
+			}
+		}
+		s.log.Info("Hashed the secret of API key", "apiKeyID", k.APIKeyID)
+	}
+	return nil
+}
+
+// BackfillAPIKeySecretPrefixes sets the secret prefixes of API keys created before the prefixes were stored.
+// Secrets are looked up by their prefixes, so such keys cannot be authenticated until the prefixes are set.
+func (s *S) BackfillAPIKeySecretPrefixes(ctx context.Context) error {
+	ks, err := s.store.ListAPIKeysWithoutSecretPrefix()
+	if err != nil {
+		return fmt.Errorf("list api keys: %s", err)
+	}
+	for _, k := range ks {
+		prefix := k.SecretPrefix
+		if prefix == "" {
+			ss := currentSecret(k)
+			if ss.isHashed() {
+				// Hashing always stores the prefix, so this should not happen.
+				s.log.Info("Skipped API key with a hashed secret and no prefix", "apiKeyID", k.APIKeyID)
+				continue
+			}
+			secret, err := decryptSecret(ctx, s.dataKey, k.APIKeyID, ss)
+			if err != nil {
+				return fmt.Errorf("decrypt secret of api key %q: %s", k.APIKeyID, err)
+			}
+			prefix = secretPrefix(secret)
+		}
+
+		previousPrefix := k.PreviousSecretPrefix
+		if ps := previousSecret(k); previousPrefix == "" && !ps.isHashed() && (ps.secret != "" || len(ps.encrypted) > 0) {
+			secret, err := decryptSecret(ctx, s.dataKey, k.APIKeyID, ps)
+			if err != nil {
+				return fmt.Errorf("decrypt previous secret of api key %q: %s", k.APIKeyID, err)
+			}
+			previousPrefix = secretPrefix(secret)
+		}
+
+		if err := s.store.UpdateAPIKeySecretPrefixes(k.APIKeyID, prefix, previousPrefix); err != nil {
+			return fmt.Errorf("update api key %q: %s", k.APIKeyID, err)
+		}
+	}
+	if len(ks) > 0 {
+		s.log.Info("Set the secret prefixes of API keys", "count", len(ks))
+	}
+	return nil
+}
+
+// secretPrefix returns the part of the secret that is stored in plaintext.
+func secretPrefix(secret string) string {
+	if len(secret) <= secretPrefixLen {
+		return secret
+	}
+	return secret[:secretPrefixLen]
+}
+
+func generateSecretSalt() ([]byte, error) {
+	salt := make([]byte, secretSaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("generate salt: %s", err)
+	}
+	return salt, nil
+}
+
+func hashSecret(secret string, salt []byte) []byte {
+	h := sha256.New()
+	h.Write(salt)
+	h.Write([]byte(secret))
+	return h.Sum(nil)
+}
+
+// decryptSecret returns the plaintext of the secret. It must not be called for hashed secrets.
+func decryptSecret(ctx context.Context, dataKey []byte, apiKeyID string, ss storedSecret) (string, error) {
+	if len(ss.encrypted) == 0 {
+		return ss.secret, nil
+	}
+	if len(dataKey) == 0 {
+		return "", fmt.Errorf("data key is required to decrypt the secret")
+	}
+	return aws.Decrypt(ctx, ss.encrypted, apiKeyID, dataKey)
+}
+
+// secretMatches returns true if the given secret matches the stored secret.
+func secretMatches(ctx context.Context, dataKey []byte, apiKeyID string, ss storedSecret, secret string) (bool, error) {
+	if ss.isHashed() {
+		return subtle.ConstantTimeCompare(hashSecret(secret, ss.salt), ss.hash) == 1, nil
+	}
+	s, err := decryptSecret(ctx, dataKey, apiKeyID, ss)
+	if err != nil {
+		return false, err
+	}
+	return subtle.ConstantTimeCompare([]byte(s), []byte(secret)) == 1, nil
+}
@@ -0,0 +1,276 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	v1 "github.com/llmariner/user-manager/api/v1"
+	"github.com/llmariner/user-manager/server/internal/store"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultWatchPollInterval = time.Second
+	// watchBatchSize is the maximum number of changes read from the change log at once.
+	watchBatchSize = 100
+)
+
+// WatchAPIKeys streams the changes of API keys.
+//
+// The change log is polled since other replicas of the server can also make changes.
+// Expirations of API keys and their previous secrets are not recorded in the change log,
+// so the watcher tracks the expiration times of the API keys and sends events when they pass.
+func (s *IS) WatchAPIKeys(
+	req *v1.WatchAPIKeysRequest,
+	stream v1.UsersInternalService_WatchAPIKeysServer,
+) error {
+	if req.SinceRevision < 0 {
+		return status.Error(codes.InvalidArgument, "since_revision must not be negative")
+	}
+
+	ctx := stream.Context()
+	rev := req.SinceRevision
+	expiries := apiKeyExpiries{}
+	if rev == 0 {
+		var err error
+		rev, err = s.sendAPIKeySnapshot(ctx, stream, expiries)
+		if err != nil {
+			return err
+		}
+	} else {
+		if err := s.resumeAPIKeyWatch(ctx, stream, rev, expiries); err != nil {
+			return err
+		}
+	}
+
+	ticker := time.NewTicker(s.watchPollInterval)
+	defer ticker.Stop()
+	for {
+		// Only read the changes up to the stable revision so that changes committed
+		// out of order are not skipped.
+		stableRev, err := s.store.GetStableRevision(time.Now(), changeSettleWindow)
+		if err != nil {
+			return status.Errorf(codes.Internal, "get latest revision: %s", err)
+		}
+		for {
+			cs, err := s.store.ListChanges(store.ResourceKindAPIKey, rev, stableRev, watchBatchSize)
+			if err != nil {
+				return status.Errorf(codes.Internal, "list changes: %s", err)
+			}
+			for _, c := range cs {
+				ev, k, err := s.toAPIKeyEvent(ctx, c)
+				if err != nil {
+					return status.Errorf(codes.Internal, "to api key event: %s", err)
+				}
+				if err := stream.Send(ev); err != nil {
+					return err
+				}
+				rev = c.Revision()
+				expiries.track(c.ResourceID, k, time.Now())
+			}
+			if len(cs) < watchBatchSize {
+				break
+			}
+		}
+
+		now := time.Now()
+		for _, id := range expiries.due(now) {
+			k, err := s.store.GetAPIKeyByID(id)
+			if err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					// The DELETED event has been or will be sent.
+					expiries.track(id, nil, now)
+					continue
+				}
+				return status.Errorf(codes.Internal, "get api key: %s", err)
+			}
+			if err := s.sendAPIKeyExpiryEvent(ctx, stream, k, rev, now); err != nil {
+				return err
+			}
+			expiries.track(id, k, now)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// sendAPIKeySnapshot sends CREATED events for all unexpired API keys. It returns the revision of the snapshot.
+func (s *IS) sendAPIKeySnapshot(ctx context.Context, stream v1.UsersInternalService_WatchAPIKeysServer, expiries apiKeyExpiries) (int64, error) {
+	// Get the revision before listing API keys so that no change is missed.
+	rev, err := s.store.GetStableRevision(time.Now(), changeSettleWindow)
+	if err != nil {
+		return 0, status.Errorf(codes.Internal, "get latest revision: %s", err)
+	}
+
+	now := time.Now()
+	ks, err := s.store.ListAllActiveAPIKeys(now)
+	if err != nil {
+		return 0, status.Errorf(codes.Internal, "list api keys: %s", err)
+	}
+	for _, k := range ks {
+		u, err := s.store.GetUserByUserID(k.UserID)
+		if err != nil {
+			return 0, status.Errorf(codes.Internal, "get user: %s", err)
+		}
+		kps, err := toInternalAPIKeyProtos(ctx, s.store, s.dataKey, k, u.InternalUserID, now)
+		if err != nil {
+			return 0, status.Errorf(codes.Internal, "to api key proto: %s", err)
+		}
+		if err := stream.Send(&v1.APIKeyEvent{
+			Type:     v1.APIKeyEvent_TYPE_CREATED,
+			Revision: rev,
+			ApiKeyId: k.APIKeyID,
+			ApiKeys:  kps,
+		}); err != nil {
+			return 0, err
+		}
+		expiries.track(k.APIKeyID, k, now)
+	}
+	return rev, nil
+}
+
+// resumeAPIKeyWatch sends events for the API keys and previous secrets that have expired after the
+// change of sinceRevision, and starts tracking the expiration times of the API keys.
+func (s *IS) resumeAPIKeyWatch(
+	ctx context.Context,
+	stream v1.UsersInternalService_WatchAPIKeysServer,
+	sinceRevision int64,
+	expiries apiKeyExpiries,
+) error {
+	c, err := s.sinceRevisionChange(sinceRevision)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	ks, err := s.store.ListAPIKeysExpiredBetween(c.CreatedAt, now)
+	if err != nil {
+		return status.Errorf(codes.Internal, "list api keys: %s", err)
+	}
+	for _, k := range ks {
+		if err := s.sendAPIKeyExpiryEvent(ctx, stream, k, sinceRevision, now); err != nil {
+			return err
+		}
+	}
+
+	ks, err = s.store.ListAPIKeysExpiringAfter(now)
+	if err != nil {
+		return status.Errorf(codes.Internal, "list api keys: %s", err)
+	}
+	for _, k := range ks {
+		expiries.track(k.APIKeyID, k, now)
+	}
+	return nil
+}
+
+// sendAPIKeyExpiryEvent sends a DELETED event if the API key has expired, or an UPDATED event
+// without the expired previous secret otherwise. Since expirations are not recorded in the change log,
+// the event has the revision of the last change sent.
+func (s *IS) sendAPIKeyExpiryEvent(
+	ctx context.Context,
+	stream v1.UsersInternalService_WatchAPIKeysServer,
+	k *store.APIKey,
+	rev int64,
+	now time.Time,
+) error {
+	ev := &v1.APIKeyEvent{
+		Type:     v1.APIKeyEvent_TYPE_DELETED,
+		Revision: rev,
+		ApiKeyId: k.APIKeyID,
+	}
+	if k.IsActive(now) {
+		u, err := s.store.GetUserByUserID(k.UserID)
+		if err != nil {
+			return status.Errorf(codes.Internal, "get user: %s", err)
+		}
+		kps, err := toInternalAPIKeyProtos(ctx, s.store, s.dataKey, k, u.InternalUserID, now)
+		if err != nil {
+			return status.Errorf(codes.Internal, "to api key proto: %s", err)
+		}
+		ev.Type = v1.APIKeyEvent_TYPE_UPDATED
+		ev.ApiKeys = kps
+	}
+	return stream.Send(ev)
+}
+
+// apiKeyExpiries holds the earliest upcoming expiration time (in Unix seconds) of
+// the secrets of each API key, keyed by API key ID.
+type apiKeyExpiries map[string]int64
+
+// track updates the expiration time of the API key. k is nil if the API key has been deleted.
+func (e apiKeyExpiries) track(id string, k *store.APIKey, now time.Time) {
+	delete(e, id)
+	if k == nil || !k.IsActive(now) {
+		return
+	}
+	var next int64
+	for _, t := range []int64{k.ExpiresAt, k.PreviousSecretExpiresAt} {
+		if t > now.Unix() && (next == 0 || t < next) {
+			next = t
+		}
+	}
+	if next > 0 {
+		e[id] = next
+	}
+}
+
+// due returns the IDs of the API keys that have secrets expired at the given time.
+func (e apiKeyExpiries) due(now time.Time) []string {
+	var ids []string
+	for id, t := range e {
+		if t <= now.Unix() {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// toAPIKeyEvent converts a change to an event. It also returns the API key if it is active.
+func (s *IS) toAPIKeyEvent(ctx context.Context, c *store.Change) (*v1.APIKeyEvent, *store.APIKey, error) {
+	ev := &v1.APIKeyEvent{
+		Revision: c.Revision(),
+		ApiKeyId: c.ResourceID,
+	}
+	switch c.Type {
+	case store.ChangeTypeCreated:
+		ev.Type = v1.APIKeyEvent_TYPE_CREATED
+	case store.ChangeTypeUpdated:
+		ev.Type = v1.APIKeyEvent_TYPE_UPDATED
+	case store.ChangeTypeDeleted:
+		ev.Type = v1.APIKeyEvent_TYPE_DELETED
+		return ev, nil, nil
+	}
+
+	// Populate the current state of the API key. It might have been deleted after the change,
+	// in which case a later DELETED event follows.
+	k, err := s.store.GetAPIKeyByID(c.ResourceID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ev, nil, nil
+		}
+		return nil, nil, err
+	}
+	now := time.Now()
+	if !k.IsActive(now) {
+		return ev, nil, nil
+	}
+	u, err := s.store.GetUserByUserID(k.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+	kps, err := toInternalAPIKeyProtos(ctx, s.store, s.dataKey, k, u.InternalUserID, now)
+	if err != nil {
+		return nil, nil, err
+	}
+	ev.ApiKeys = kps
+	return ev, k, nil
+}
@@ -0,0 +1,273 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/testr"
+	v1 "github.com/llmariner/user-manager/api/v1"
+	"github.com/llmariner/user-manager/server/internal/store"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+func TestWatchAPIKeys(t *testing.T) {
+	st, tearDown := store.NewTest(t)
+	defer tearDown()
+
+	srv := New(st, nil, testr.New(t))
+	isrv := NewInternal(st, nil, testr.New(t))
+	isrv.watchPollInterval = 10 * time.Millisecond
+
+	ctx := fakeAuthInto(context.Background())
+	org, err := srv.CreateOrganization(ctx, &v1.CreateOrganizationRequest{
+		Title: "Test organization",
+	})
+	assert.NoError(t, err)
+
+	proj, err := srv.CreateProject(ctx, &v1.CreateProjectRequest{
+		Title:               "Test project",
+		OrganizationId:      org.Id,
+		KubernetesNamespace: "test",
+	})
+	assert.NoError(t, err)
+
+	k0, err := srv.CreateAPIKey(ctx, &v1.CreateAPIKeyRequest{
+		Name:           "k0",
+		OrganizationId: org.Id,
+		ProjectId:      proj.Id,
+	})
+	assert.NoError(t, err)
+
+	wctx, cancel := context.WithCancel(ctx)
+	stream := newFakeWatchAPIKeysServer(wctx)
+	errCh := make(chan error)
+	go func() {
+		errCh <- isrv.WatchAPIKeys(&v1.WatchAPIKeysRequest{}, stream)
+	}()
+
+	// The stream starts with a snapshot.
+	ev := stream.recv(t)
+	assert.Equal(t, v1.APIKeyEvent_TYPE_CREATED, ev.Type)
+	assert.Equal(t, k0.Id, ev.ApiKeyId)
+	assert.Len(t, ev.ApiKeys, 1)
+	assert.Equal(t, k0.Secret, ev.ApiKeys[0].ApiKey.Secret)
+	snapshotRev := ev.Revision
+
+	k1, err := srv.CreateAPIKey(ctx, &v1.CreateAPIKeyRequest{
+		Name:           "k1",
+		OrganizationId: org.Id,
+		ProjectId:      proj.Id,
+	})
+	assert.NoError(t, err)
+	ev = stream.recv(t)
+	assert.Equal(t, v1.APIKeyEvent_TYPE_CREATED, ev.Type)
+	assert.Equal(t, k1.Id, ev.ApiKeyId)
+	assert.Greater(t, ev.Revision, snapshotRev)
+	createRev := ev.Revision
+
+	k1.Name = "k1-renamed"
+	_, err = srv.UpdateAPIKey(ctx, &v1.UpdateAPIKeyRequest{
+		ApiKey: k1,
+		UpdateMask: &fieldmaskpb.FieldMask{
+			Paths: []string{"name"},
+		},
+	})
+	assert.NoError(t, err)
+	ev = stream.recv(t)
+	assert.Equal(t, v1.APIKeyEvent_TYPE_UPDATED, ev.Type)
+	assert.Equal(t, "k1-renamed", ev.ApiKeys[0].ApiKey.Name)
+
+	_, err = srv.DeleteAPIKey(ctx, &v1.DeleteAPIKeyRequest{Id: k1.Id})
+	assert.NoError(t, err)
+	ev = stream.recv(t)
+	assert.Equal(t, v1.APIKeyEvent_TYPE_DELETED, ev.Type)
+	assert.Equal(t, k1.Id, ev.ApiKeyId)
+	assert.Empty(t, ev.ApiKeys)
+
+	cancel()
+	assert.NoError(t, <-errCh)
+
+	// Resume from the revision of the creation.
+	wctx, cancel = context.WithCancel(ctx)
+	defer cancel()
+	stream = newFakeWatchAPIKeysServer(wctx)
+	go func() {
+		errCh <- isrv.WatchAPIKeys(&v1.WatchAPIKeysRequest{SinceRevision: createRev}, stream)
+	}()
+	ev = stream.recv(t)
+	assert.Equal(t, v1.APIKeyEvent_TYPE_UPDATED, ev.Type)
+	// The key has been deleted.
+	assert.Empty(t, ev.ApiKeys)
+	ev = stream.recv(t)
+	assert.Equal(t, v1.APIKeyEvent_TYPE_DELETED, ev.Type)
+}
+
+func TestWatchAPIKeys_Expiration(t *testing.T) {
+	st, tearDown := store.NewTest(t)
+	defer tearDown()
+
+	srv := New(st, nil, testr.New(t))
+	isrv := NewInternal(st, nil, testr.New(t))
+	isrv.watchPollInterval = 10 * time.Millisecond
+
+	ctx := fakeAuthInto(context.Background())
+	org, err := srv.CreateOrganization(ctx, &v1.CreateOrganizationRequest{
+		Title: "Test organization",
+	})
+	assert.NoError(t, err)
+
+	proj, err := srv.CreateProject(ctx, &v1.CreateProjectRequest{
+		Title:               "Test project",
+		OrganizationId:      org.Id,
+		KubernetesNamespace: "test",
+	})
+	assert.NoError(t, err)
+
+	k0, err := srv.CreateAPIKey(ctx, &v1.CreateAPIKeyRequest{
+		Name:           "k0",
+		OrganizationId: org.Id,
+		ProjectId:      proj.Id,
+		TtlSeconds:     2,
+	})
+	assert.NoError(t, err)
+	k1, err := srv.CreateAPIKey(ctx, &v1.CreateAPIKeyRequest{
+		Name:           "k1",
+		OrganizationId: org.Id,
+		ProjectId:      proj.Id,
+	})
+	assert.NoError(t, err)
+	_, err = srv.RotateAPIKey(ctx, &v1.RotateAPIKeyRequest{
+		Id:                 k1.Id,
+		GracePeriodSeconds: 2,
+	})
+	assert.NoError(t, err)
+
+	wctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream := newFakeWatchAPIKeysServer(wctx)
+	errCh := make(chan error)
+	go func() {
+		errCh <- isrv.WatchAPIKeys(&v1.WatchAPIKeysRequest{}, stream)
+	}()
+
+	ev := stream.recv(t)
+	snapshotRev := ev.Revision
+	_ = stream.recv(t)
+
+	// The expirations of the key and the previous secret are sent.
+	evs := map[string]*v1.APIKeyEvent{}
+	for i := 0; i < 2; i++ {
+		ev := stream.recv(t)
+		assert.Equal(t, snapshotRev, ev.Revision)
+		evs[ev.ApiKeyId] = ev
+	}
+	assert.Equal(t, v1.APIKeyEvent_TYPE_DELETED, evs[k0.Id].Type)
+	assert.Equal(t, v1.APIKeyEvent_TYPE_UPDATED, evs[k1.Id].Type)
+	assert.Len(t, evs[k1.Id].ApiKeys, 1)
+
+	cancel()
+	assert.NoError(t, <-errCh)
+
+	// The expirations after the revision are also sent when the watch is resumed.
+	wctx, cancel = context.WithCancel(ctx)
+	defer cancel()
+	stream = newFakeWatchAPIKeysServer(wctx)
+	go func() {
+		errCh <- isrv.WatchAPIKeys(&v1.WatchAPIKeysRequest{SinceRevision: snapshotRev}, stream)
+	}()
+	evs = map[string]*v1.APIKeyEvent{}
+	for i := 0; i < 2; i++ {
+		ev := stream.recv(t)
+		evs[ev.ApiKeyId] = ev
+	}
+	assert.Equal(t, v1.APIKeyEvent_TYPE_DELETED, evs[k0.Id].Type)
+	assert.Equal(t, v1.APIKeyEvent_TYPE_UPDATED, evs[k1.Id].Type)
+	cancel()
+	assert.NoError(t, <-errCh)
+
+	resp, err := isrv.ListInternalAPIKeys(ctx, &v1.ListInternalAPIKeysRequest{SinceRevision: snapshotRev})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{k0.Id}, resp.DeletedApiKeyIds)
+	assert.Len(t, resp.ApiKeys, 1)
+	assert.Equal(t, k1.Id, resp.ApiKeys[0].ApiKey.Id)
+}
+
+func TestWatchAPIKeys_Pruned(t *testing.T) {
+	st, tearDown := store.NewTest(t)
+	defer tearDown()
+
+	srv := New(st, nil, testr.New(t))
+	isrv := NewInternal(st, nil, testr.New(t))
+
+	ctx := fakeAuthInto(context.Background())
+	_, err := srv.CreateOrganization(ctx, &v1.CreateOrganizationRequest{
+		Title: "Test organization",
+	})
+	assert.NoError(t, err)
+	rev, err := st.GetLatestRevision()
+	assert.NoError(t, err)
+	_, err = srv.CreateOrganization(ctx, &v1.CreateOrganizationRequest{
+		Title: "Another organization",
+	})
+	assert.NoError(t, err)
+
+	_, err = st.DeleteChangesBefore(time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+
+	// Clients must resync with revisions of pruned changes.
+	err = isrv.WatchAPIKeys(&v1.WatchAPIKeysRequest{SinceRevision: rev}, newFakeWatchAPIKeysServer(ctx))
+	assert.Error(t, err)
+	assert.Equal(t, codes.OutOfRange, status.Code(err))
+	_, err = isrv.ListInternalAPIKeys(ctx, &v1.ListInternalAPIKeysRequest{SinceRevision: rev})
+	assert.Error(t, err)
+	assert.Equal(t, codes.OutOfRange, status.Code(err))
+
+	// The revision right before the oldest retained change is still valid though its change has been pruned.
+	latest, err := st.GetLatestRevision()
+	assert.NoError(t, err)
+	_, err = isrv.ListInternalAPIKeys(ctx, &v1.ListInternalAPIKeysRequest{SinceRevision: latest - 1})
+	assert.NoError(t, err)
+
+	resp, err := isrv.ListInternalAPIKeys(ctx, &v1.ListInternalAPIKeysRequest{})
+	assert.NoError(t, err)
+	_, err = isrv.ListInternalAPIKeys(ctx, &v1.ListInternalAPIKeysRequest{SinceRevision: resp.Revision})
+	assert.NoError(t, err)
+}
+
+type fakeWatchAPIKeysServer struct {
+	grpc.ServerStream
+
+	ctx context.Context
+	ch  chan *v1.APIKeyEvent
+}
+
+func newFakeWatchAPIKeysServer(ctx context.Context) *fakeWatchAPIKeysServer {
+	return &fakeWatchAPIKeysServer{
+		ctx: ctx,
+		ch:  make(chan *v1.APIKeyEvent, 100),
+	}
+}
+
+func (s *fakeWatchAPIKeysServer) Context() context.Context {
+	return s.ctx
+}
+
+func (s *fakeWatchAPIKeysServer) Send(ev *v1.APIKeyEvent) error {
+	s.ch <- ev
+	return nil
+}
+
+func (s *fakeWatchAPIKeysServer) recv(t *testing.T) *v1.APIKeyEvent {
+	select {
+	case ev := <-s.ch:
+		return ev
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for an event")
+		return nil
+	}
+}
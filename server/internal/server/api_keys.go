@@ -4,20 +4,44 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net"
+	"regexp"
 	"strings"
+	"time"
 
-	"github.com/llmariner/common/pkg/aws"
 	gerrors "github.com/llmariner/common/pkg/gormlib/errors"
 	"github.com/llmariner/common/pkg/id"
 	"github.com/llmariner/rbac-manager/pkg/auth"
 	v1 "github.com/llmariner/user-manager/api/v1"
+	"github.com/llmariner/user-manager/pkg/apikey"
+	"github.com/llmariner/user-manager/pkg/scope"
+	"github.com/llmariner/user-manager/pkg/serviceaccount"
 	"github.com/llmariner/user-manager/server/internal/config"
 	"github.com/llmariner/user-manager/server/internal/store"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
 	"gorm.io/gorm"
 )
 
+const (
+	defaultListAPIKeysLimit = 50
+	maxListAPIKeysLimit     = 1000
+
+	maxAPIKeyLabels            = 64
+	maxAPIKeyLabelKeyLength    = 63
+	maxAPIKeyLabelValueLength  = 256
+	maxAPIKeyDescriptionLength = 1024
+
+	// minImportedSecretEntropyBits is the minimum estimated entropy of imported secrets.
+	minImportedSecretEntropyBits = 80
+
+	maxRotationGracePeriod = 30 * 24 * time.Hour
+)
+
+var labelKeyRE = regexp.MustCompile(`^[a-zA-Z0-9][-a-zA-Z0-9_./]*$`)
+
 // CreateAPIKey creates an API key.
 func (s *S) CreateAPIKey(
 	ctx context.Context,
@@ -73,38 +97,235 @@ func (s *S) ListAPIKeys(
 		return nil, status.Errorf(codes.Internal, "failed to extract user info from context")
 	}
 
-	ks, err := s.store.ListAPIKeysByTenantID(userInfo.TenantID)
+	params, err := s.listAPIKeysParams(req, userInfo.TenantID, userInfo.UserID)
+	if err != nil {
+		return nil, err
+	}
+	ks, hasMore, err := s.store.ListAPIKeys(*params)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "list api keys: %s", err)
 	}
 
-	// Show all API keys if the user is an owner. Otherwise only show API keys owned by the user.
-	var filtered []*store.APIKey
+	apiKeyProtos, err := s.toAPIKeyProtos(ctx, ks)
+	if err != nil {
+		return nil, err
+	}
+	return &v1.ListAPIKeysResponse{
+		Object:  "list",
+		Data:    apiKeyProtos,
+		HasMore: hasMore,
+	}, nil
+}
+
+// toAPIKeyProtos converts the API keys to protos. The organizations, projects, and memberships
+// of the keys are loaded in batches so that the cost is bounded by the number of keys.
+func (s *S) toAPIKeyProtos(ctx context.Context, ks []*store.APIKey) ([]*v1.APIKey, error) {
+	if len(ks) == 0 {
+		return nil, nil
+	}
+
+	orgIDs := map[string]bool{}
+	projectIDs := map[string]bool{}
+	userIDs := map[string]bool{}
 	for _, k := range ks {
-		isOwner := s.validateProjectOwner(k.ProjectID, k.OrganizationID, userInfo.UserID) == nil
-		if isOwner || k.UserID == userInfo.UserID {
-			filtered = append(filtered, k)
-		}
+		orgIDs[k.OrganizationID] = true
+		projectIDs[k.ProjectID] = true
+		userIDs[k.UserID] = true
+	}
+
+	orgs, err := s.store.ListOrganizationsByIDs(mapKeys(orgIDs))
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "list organizations: %s", err)
+	}
+	orgsByID := map[string]*store.Organization{}
+	for _, o := range orgs {
+		orgsByID[o.OrganizationID] = o
+	}
+	projects, err := s.store.ListProjectsByIDs(mapKeys(projectIDs))
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "list projects: %s", err)
+	}
+	projectsByID := map[string]*store.Project{}
+	for _, p := range projects {
+		projectsByID[p.ProjectID] = p
 	}
 
-	orgsByID, projectsByID, err := getOrgsAndProjects(s.store, userInfo.TenantID)
+	ous, err := s.store.ListOrganizationUsersByOrganizationIDsAndUserIDs(mapKeys(orgIDs), mapKeys(userIDs))
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "get orgs and projects: %s", err)
+		return nil, status.Errorf(codes.Internal, "list organization users: %s", err)
+	}
+	orgRoles := map[string]v1.OrganizationRole{}
+	for _, ou := range ous {
+		orgRoles[store.MembershipResourceID(ou.OrganizationID, ou.UserID)] = v1.OrganizationRole(v1.OrganizationRole_value[ou.Role])
+	}
+	pus, err := s.store.ListProjectUsersByProjectIDsAndUserIDs(mapKeys(projectIDs), mapKeys(userIDs))
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "list project users: %s", err)
+	}
+	projectRoles := map[string]v1.ProjectRole{}
+	for _, pu := range pus {
+		projectRoles[store.MembershipResourceID(pu.ProjectID, pu.UserID)] = v1.ProjectRole(v1.ProjectRole_value[pu.Role])
 	}
 
 	var apiKeyProtos []*v1.APIKey
-	for _, k := range filtered {
+	for _, k := range ks {
+		orgRole := orgRoles[store.MembershipResourceID(k.OrganizationID, k.UserID)]
+		projectRole := projectRoles[store.MembershipResourceID(k.ProjectID, k.UserID)]
 		// Do not populate the internal User ID for non-internal gRPC.
-		kp, err := toAPIKeyProto(ctx, s.store, s.dataKey, k, "", false, orgsByID, projectsByID)
+		kp, err := toAPIKeyProtoWithRoles(ctx, s.dataKey, k, "", false, orgsByID, projectsByID, orgRole, projectRole)
 		if err != nil {
 			return nil, status.Errorf(codes.Internal, "to api key proto")
 		}
 		apiKeyProtos = append(apiKeyProtos, kp)
 	}
-	return &v1.ListAPIKeysResponse{
-		Object: "list",
-		Data:   apiKeyProtos,
-	}, nil
+	return apiKeyProtos, nil
+}
+
+func mapKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// listAPIKeysParams validates the request and converts it to the store parameters.
+func (s *S) listAPIKeysParams(req *v1.ListAPIKeysRequest, tenantID, userID string) (*store.ListAPIKeysParams, error) {
+	p := store.ListAPIKeysParams{
+		TenantID:       tenantID,
+		OrganizationID: req.OrganizationId,
+		ProjectID:      req.ProjectId,
+		UserID:         req.UserId,
+		NamePrefix:     req.NamePrefix,
+	}
+
+	labels, err := parseLabelSelector(req.LabelSelector)
+	if err != nil {
+		return nil, err
+	}
+	p.Labels = labels
+
+	switch {
+	case req.Limit < 0:
+		return nil, status.Errorf(codes.InvalidArgument, "limit must be non-negative")
+	case req.Limit == 0:
+		p.Limit = defaultListAPIKeysLimit
+	case req.Limit > maxListAPIKeysLimit:
+		return nil, status.Errorf(codes.InvalidArgument, "limit must be less than or equal to %d", maxListAPIKeysLimit)
+	default:
+		p.Limit = int(req.Limit)
+	}
+
+	switch req.ServiceAccountFilter {
+	case v1.ListAPIKeysRequest_SERVICE_ACCOUNT_FILTER_UNSPECIFIED:
+	case v1.ListAPIKeysRequest_SERVICE_ACCOUNT_FILTER_ONLY:
+		isSA := true
+		p.IsServiceAccount = &isSA
+	case v1.ListAPIKeysRequest_SERVICE_ACCOUNT_FILTER_EXCLUDE:
+		isSA := false
+		p.IsServiceAccount = &isSA
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "unknown service account filter %q", req.ServiceAccountFilter)
+	}
+
+	switch req.OrderBy {
+	case "", string(store.APIKeyOrderByName):
+		p.OrderBy = store.APIKeyOrderByName
+	case string(store.APIKeyOrderByCreatedAt):
+		p.OrderBy = store.APIKeyOrderByCreatedAt
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "order_by must be %q or %q", store.APIKeyOrderByName, store.APIKeyOrderByCreatedAt)
+	}
+	switch req.Order {
+	case "", "asc":
+	case "desc":
+		p.Descending = true
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "order must be %q or %q", "asc", "desc")
+	}
+
+	if req.After != "" {
+		k, err := s.store.GetAPIKeyByID(req.After)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, status.Errorf(codes.InvalidArgument, "api key %q not found", req.After)
+			}
+			return nil, status.Errorf(codes.Internal, "get api key: %s", err)
+		}
+		if k.TenantID != tenantID {
+			return nil, status.Errorf(codes.InvalidArgument, "api key %q not found", req.After)
+		}
+		p.After = k
+	}
+
+	if s.enableAuth {
+		// Show all API keys in the projects that the user owns. Otherwise only show API keys owned by the user.
+		projectIDs, err := s.ownedProjectIDs(tenantID, userID)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "list owned projects: %s", err)
+		}
+		p.RestrictVisibility = true
+		p.VisibleProjectIDs = projectIDs
+		p.VisibleUserID = userID
+	}
+	return &p, nil
+}
+
+// parseLabelSelector parses a comma-separated list of "key=value" pairs.
+func parseLabelSelector(selector string) (map[string]string, error) {
+	if selector == "" {
+		return nil, nil
+	}
+	labels := map[string]string{}
+	for _, kv := range strings.Split(selector, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(kv), "=")
+		if !ok || k == "" {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid label selector %q: each requirement must be in the form of \"key=value\"", selector)
+		}
+		if prev, ok := labels[k]; ok && prev != v {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid label selector %q: conflicting values for label %q", selector, k)
+		}
+		labels[k] = v
+	}
+	return labels, nil
+}
+
+// ownedProjectIDs returns the IDs of the projects that the user owns either directly or as an organization owner.
+func (s *S) ownedProjectIDs(tenantID, userID string) ([]string, error) {
+	ous, err := s.store.ListOrganizationUsersByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	ownedOrgs := map[string]bool{}
+	for _, ou := range ous {
+		if ou.Role == v1.OrganizationRole_ORGANIZATION_ROLE_OWNER.String() {
+			ownedOrgs[ou.OrganizationID] = true
+		}
+	}
+
+	var ids []string
+	if len(ownedOrgs) > 0 {
+		ps, err := s.store.ListProjectsByTenantID(tenantID)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range ps {
+			if ownedOrgs[p.OrganizationID] {
+				ids = append(ids, p.ProjectID)
+			}
+		}
+	}
+
+	pus, err := s.store.ListProjectUsersByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, pu := range pus {
+		if pu.Role == v1.ProjectRole_PROJECT_ROLE_OWNER.String() {
+			ids = append(ids, pu.ProjectID)
+		}
+	}
+	return ids, nil
 }
 
 // UpdateAPIKey updates an API key.
@@ -142,22 +363,94 @@ func (s *S) UpdateAPIKey(
 		return nil, status.Errorf(codes.NotFound, "api key %q not found", req.ApiKey.Id)
 	}
 
-	// Currently only support the update of the name field and the "excluded_from_rate_limiting" field.
+	// Currently only support the update of the name field, the "excluded_from_rate_limiting" field,
+	// the "enabled" field, the "labels" and "description" fields, and the access restriction fields.
 	if req.UpdateMask == nil {
 		return nil, status.Error(codes.InvalidArgument, "update mask is required")
 	}
+	// Only the columns in the update mask are written so that concurrent updates of
+	// other columns (e.g., secret rotations) are not reverted.
+	updates := map[string]interface{}{}
 	for _, p := range req.UpdateMask.Paths {
 		switch p {
 		case "name":
 			key.Name = req.ApiKey.Name
+			updates["name"] = key.Name
 		case "excluded_from_rate_limiting":
 			key.ExcludedFromRateLimiting = req.ApiKey.ExcludedFromRateLimiting
+			updates["excluded_from_rate_limiting"] = key.ExcludedFromRateLimiting
+		case "enabled":
+			if req.ApiKey.Enabled && key.Disabled && !serviceaccount.IsServiceAccount(key.UserID) {
+				// Do not re-enable a key whose owner has been removed from the project.
+				if _, err := s.store.GetProjectUser(key.ProjectID, key.UserID); err != nil {
+					if errors.Is(err, gorm.ErrRecordNotFound) {
+						return nil, status.Errorf(codes.FailedPrecondition, "owner %q of api key %q is not a member of the project", key.UserID, key.APIKeyID)
+					}
+					return nil, status.Errorf(codes.Internal, "get project user: %s", err)
+				}
+			}
+			key.Disabled = !req.ApiKey.Enabled
+			updates["disabled"] = key.Disabled
+		case "labels":
+			if err := validateAPIKeyLabels(req.ApiKey.Labels); err != nil {
+				return nil, err
+			}
+			if err := key.SetLabels(req.ApiKey.Labels); err != nil {
+				return nil, status.Errorf(codes.Internal, "set labels: %s", err)
+			}
+			updates["labels"] = key.Labels
+		case "description":
+			if err := validateAPIKeyDescription(req.ApiKey.Description); err != nil {
+				return nil, err
+			}
+			key.Description = req.ApiKey.Description
+			updates["description"] = key.Description
+		case "allowed_models":
+			if err := validateAllowedModels(req.ApiKey.AllowedModels); err != nil {
+				return nil, err
+			}
+			key.SetAllowedModels(req.ApiKey.AllowedModels)
+			updates["allowed_models"] = key.AllowedModels
+		case "allowed_cidrs":
+			cidrs, err := normalizeCIDRs(req.ApiKey.AllowedCidrs)
+			if err != nil {
+				return nil, err
+			}
+			key.SetAllowedCIDRs(cidrs)
+			// Use the field name as gorm names the column "allowed_c_id_rs".
+			updates["AllowedCIDRs"] = key.AllowedCIDRs
+		case "limits", "limits.requests_per_minute", "limits.tokens_per_minute", "limits.monthly_token_budget":
+			l := req.ApiKey.Limits
+			if l == nil {
+				l = &v1.APIKeyLimits{}
+			}
+			if err := validateAPIKeyLimits(l); err != nil {
+				return nil, err
+			}
+			if p == "limits" || p == "limits.requests_per_minute" {
+				key.RequestsPerMinute = l.RequestsPerMinute
+				updates["requests_per_minute"] = key.RequestsPerMinute
+			}
+			if p == "limits" || p == "limits.tokens_per_minute" {
+				key.TokensPerMinute = l.TokensPerMinute
+				updates["tokens_per_minute"] = key.TokensPerMinute
+			}
+			if p == "limits" || p == "limits.monthly_token_budget" {
+				key.MonthlyTokenBudget = l.MonthlyTokenBudget
+				updates["monthly_token_budget"] = key.MonthlyTokenBudget
+			}
 		default:
-			return nil, status.Error(codes.InvalidArgument, "only the 'name' and 'excluded_from_rate_limiting' fields can be updated")
+			return nil, status.Error(codes.InvalidArgument, "only the 'name', 'excluded_from_rate_limiting', 'enabled', 'labels', 'description', 'allowed_models', 'allowed_cidrs', and 'limits' fields can be updated")
 		}
 	}
 
-	if err := s.store.UpdateAPIKey(key); err != nil {
+	if len(updates) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "update mask must not be empty")
+	}
+	if err := s.store.UpdateAPIKey(key.APIKeyID, updates); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.NotFound, "api key %q not found", req.ApiKey.Id)
+		}
 		return nil, status.Errorf(codes.Internal, "update api key: %s", err)
 	}
 
@@ -173,6 +466,82 @@ func (s *S) UpdateAPIKey(
 	return kProto, nil
 }
 
+// RotateAPIKey replaces the secret of an API key while keeping its ID, name, and role bindings.
+func (s *S) RotateAPIKey(
+	ctx context.Context,
+	req *v1.RotateAPIKeyRequest,
+) (*v1.APIKey, error) {
+	userInfo, ok := auth.ExtractUserInfoFromContext(ctx)
+	if !ok {
+		return nil, status.Errorf(codes.Internal, "failed to extract user info from context")
+	}
+
+	if req.Id == "" {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+	if req.GracePeriodSeconds < 0 {
+		return nil, status.Error(codes.InvalidArgument, "grace_period_seconds must not be negative")
+	}
+	if req.GracePeriodSeconds > int64(maxRotationGracePeriod/time.Second) {
+		return nil, status.Errorf(codes.InvalidArgument, "grace_period_seconds must not exceed %s", maxRotationGracePeriod)
+	}
+
+	key, err := s.store.GetAPIKeyByID(req.Id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.NotFound, "api key %q not found", req.Id)
+		}
+		return nil, status.Errorf(codes.Internal, "get api key: %s", err)
+	}
+
+	isOwner := s.validateProjectOwner(key.ProjectID, key.OrganizationID, userInfo.UserID) == nil
+	if !isOwner && userInfo.UserID != key.UserID {
+		return nil, status.Errorf(codes.NotFound, "api key %q not found", req.Id)
+	}
+
+	secKey, err := apikey.Generate(key.APIKeyID, key.TenantID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "generate secret: %s", err)
+	}
+	ss, err := newStoredSecret(ctx, s.dataKey, s.hashAPIKeySecrets, key.APIKeyID, secKey)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "create secret: %s", err)
+	}
+	var prevExpiresAt int64
+	if req.GracePeriodSeconds > 0 {
+		prevExpiresAt = time.Now().Unix() + req.GracePeriodSeconds
+	}
+	if err := s.store.RotateAPIKeySecret(store.RotateAPIKeySecretParams{
+		APIKeyID:                key.APIKeyID,
+		Secret:                  ss.secret,
+		EncryptedSecret:         ss.encrypted,
+		SecretPrefix:            ss.prefix,
+		SecretHash:              ss.hash,
+		SecretSalt:              ss.salt,
+		PreviousSecretExpiresAt: prevExpiresAt,
+	}); err != nil {
+		return nil, status.Errorf(codes.Internal, "rotate api key: %s", err)
+	}
+
+	key, err = s.store.GetAPIKeyByID(req.Id)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "get api key: %s", err)
+	}
+
+	orgsByID, projectsByID, err := getOrgAndProject(s.store, userInfo.TenantID, key.OrganizationID, key.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	// Do not populate the internal User ID for non-internal gRPC.
+	kProto, err := toAPIKeyProto(ctx, s.store, s.dataKey, key, "", true, orgsByID, projectsByID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "to api key proto: %s", err)
+	}
+	// This is the only time the new secret is shown.
+	kProto.Secret = secKey
+	return kProto, nil
+}
+
 // CreateProjectAPIKey creates an API key.
 func (s *S) CreateProjectAPIKey(
 	ctx context.Context,
@@ -183,57 +552,214 @@ func (s *S) CreateProjectAPIKey(
 		return nil, status.Errorf(codes.Internal, "failed to extract user info from context")
 	}
 
+	spec, err := s.newAPIKeySpec(req, userInfo)
+	if err != nil {
+		return nil, err
+	}
+	secKey, err := apikey.Generate(spec.APIKeyID, userInfo.TenantID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "generate secret: %s", err)
+	}
+	kProto, err := s.createAPIKey(ctx, spec, secKey, req.Role)
+	if err != nil {
+		return nil, err
+	}
+	// The secret is not recoverable when only its hash is stored. This is the only time the secret is shown.
+	kProto.Secret = secKey
+	return kProto, nil
+}
+
+// ImportAPIKey creates an API key with an externally generated secret.
+func (s *S) ImportAPIKey(
+	ctx context.Context,
+	req *v1.ImportAPIKeyRequest,
+) (*v1.APIKey, error) {
+	userInfo, ok := auth.ExtractUserInfoFromContext(ctx)
+	if !ok {
+		return nil, status.Errorf(codes.Internal, "failed to extract user info from context")
+	}
+
+	if req.ApiKey == nil {
+		return nil, status.Error(codes.InvalidArgument, "api key is required")
+	}
+	if req.Source == "" {
+		return nil, status.Error(codes.InvalidArgument, "source is required")
+	}
+	if err := validateImportedSecret(req.Secret); err != nil {
+		return nil, err
+	}
+
+	if req.ApiKey.OrganizationId != "" && !s.isOrganizationOwner(req.ApiKey.OrganizationId, userInfo.UserID) {
+		return nil, status.Error(codes.PermissionDenied, "only organization owner can import api keys")
+	}
+
+	spec, err := s.newAPIKeySpec(req.ApiKey, userInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	inUse, err := secretInUse(ctx, s.store, s.dataKey, req.Secret, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if inUse {
+		return nil, status.Error(codes.AlreadyExists, "secret is already used by another api key")
+	}
+
+	spec.ImportedFrom = req.Source
+	spec.ImportedBy = userInfo.UserID
+	kProto, err := s.createAPIKey(ctx, spec, req.Secret, req.ApiKey.Role)
+	if err != nil {
+		return nil, err
+	}
+	// Do not echo back the imported secret.
+	kProto.Secret = obfuscateSecret(req.Secret)
+
+	s.log.Info("Imported API key", "apiKeyID", spec.APIKeyID, "source", req.Source, "importedBy", userInfo.UserID)
+	return kProto, nil
+}
+
+// validateImportedSecret validates an externally generated secret. Secrets in the version 1 format
+// are rejected as they embed the ID of the API key that they were generated for.
+func validateImportedSecret(secret string) error {
+	if secret == "" {
+		return status.Error(codes.InvalidArgument, "secret is required")
+	}
+	if strings.HasPrefix(secret, apikey.V1Prefix) {
+		return status.Errorf(codes.InvalidArgument, "secret in the %q format cannot be imported", apikey.V1Prefix)
+	}
+	if err := apikey.Validate(secret); err != nil {
+		return status.Errorf(codes.InvalidArgument, "invalid secret: %s", err)
+	}
+	if b := apikey.EntropyBits(secret); b < minImportedSecretEntropyBits {
+		return status.Errorf(codes.InvalidArgument, "secret is not random enough: estimated entropy is %.0f bits, but at least %d bits are required", b, minImportedSecretEntropyBits)
+	}
+	return nil
+}
+
+// newAPIKeySpec validates the request and returns the spec of a new API key. The secret fields are not populated.
+func (s *S) newAPIKeySpec(req *v1.CreateAPIKeyRequest, userInfo *auth.UserInfo) (store.APIKeySpec, error) {
 	if req.Name == "" {
-		return nil, status.Error(codes.InvalidArgument, "name is required")
+		return store.APIKeySpec{}, status.Error(codes.InvalidArgument, "name is required")
 	}
 	if req.ProjectId == "" {
-		return nil, status.Error(codes.InvalidArgument, "project id is required")
+		return store.APIKeySpec{}, status.Error(codes.InvalidArgument, "project id is required")
 	}
 	if req.OrganizationId == "" {
-		return nil, status.Error(codes.InvalidArgument, "organization id is required")
+		return store.APIKeySpec{}, status.Error(codes.InvalidArgument, "organization id is required")
 	}
-	if req.IsServiceAccount {
+	if req.ServiceAccountId != "" {
+		if req.IsServiceAccount || req.Role != v1.OrganizationRole_ORGANIZATION_ROLE_UNSPECIFIED {
+			return store.APIKeySpec{}, status.Error(codes.InvalidArgument, "is_service_account and role must not be set together with service_account_id")
+		}
+	} else if req.IsServiceAccount {
 		if req.Role == v1.OrganizationRole_ORGANIZATION_ROLE_UNSPECIFIED {
-			return nil, status.Error(codes.InvalidArgument, "role is required for service account")
+			return store.APIKeySpec{}, status.Error(codes.InvalidArgument, "role is required for service account")
+		}
+		if err := validateAssignableOrganizationRole(req.Role); err != nil {
+			return store.APIKeySpec{}, err
 		}
 		if !s.isOrganizationOwner(req.OrganizationId, userInfo.UserID) {
-			return nil, status.Error(codes.PermissionDenied, "only organization owner can create service account")
+			return store.APIKeySpec{}, status.Error(codes.PermissionDenied, "only organization owner can create service account")
 		}
 	}
 
 	if _, err := validateProjectID(s.store, req.ProjectId, req.OrganizationId, userInfo.TenantID); err != nil {
-		return nil, err
+		return store.APIKeySpec{}, err
 	}
 
 	if err := s.validateProjectMember(req.ProjectId, req.OrganizationId, userInfo.UserID); err != nil {
-		return nil, err
+		return store.APIKeySpec{}, err
+	}
+
+	userID, isServiceAccount := userInfo.UserID, req.IsServiceAccount
+	if req.ServiceAccountId != "" {
+		// Only project owners can create API keys on behalf of service accounts.
+		if err := s.validateProjectOwner(req.ProjectId, req.OrganizationId, userInfo.UserID); err != nil {
+			return store.APIKeySpec{}, err
+		}
+		sa, err := s.store.GetServiceAccount(req.ServiceAccountId, req.ProjectId)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return store.APIKeySpec{}, status.Errorf(codes.FailedPrecondition, "service account %q not found", req.ServiceAccountId)
+			}
+			return store.APIKeySpec{}, status.Errorf(codes.Internal, "get service account: %s", err)
+		}
+		userID, isServiceAccount = sa.UserID, true
 	}
 
-	// TODO(kenji): Make sure this gives sufficient randomness.
-	secKey, err := id.GenerateID("sk-", 48)
+	expiresAt, err := apiKeyExpiresAt(req, time.Now())
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "generate api key id: %s", err)
+		return store.APIKeySpec{}, err
 	}
 
-	key, err := s.createProjectAPIKey(
-		ctx,
-		req.Name,
-		secKey,
-		userInfo.UserID,
-		req.OrganizationId,
-		req.ProjectId,
-		userInfo.TenantID,
-		req.IsServiceAccount,
-		req.Role,
-		req.ExcludedFromRateLimiting,
-	)
+	for _, sc := range req.Scopes {
+		if _, err := scope.Parse(sc); err != nil {
+			return store.APIKeySpec{}, status.Errorf(codes.InvalidArgument, "invalid scope: %s", err)
+		}
+	}
+	if err := validateAllowedModels(req.AllowedModels); err != nil {
+		return store.APIKeySpec{}, err
+	}
+	cidrs, err := normalizeCIDRs(req.AllowedCidrs)
+	if err != nil {
+		return store.APIKeySpec{}, err
+	}
+	limits := req.Limits
+	if limits == nil {
+		limits = &v1.APIKeyLimits{}
+	}
+	if err := validateAPIKeyLimits(limits); err != nil {
+		return store.APIKeySpec{}, err
+	}
+	if err := validateAPIKeyLabels(req.Labels); err != nil {
+		return store.APIKeySpec{}, err
+	}
+	if err := validateAPIKeyDescription(req.Description); err != nil {
+		return store.APIKeySpec{}, err
+	}
+
+	keyID, err := id.GenerateID(apikey.KeyIDPrefix, 16)
+	if err != nil {
+		return store.APIKeySpec{}, status.Errorf(codes.Internal, "generate api key id: %s", err)
+	}
+
+	return store.APIKeySpec{
+		APIKeyID:                 keyID,
+		TenantID:                 userInfo.TenantID,
+		OrganizationID:           req.OrganizationId,
+		ProjectID:                req.ProjectId,
+		UserID:                   userID,
+		IsServiceAccount:         isServiceAccount,
+		ExcludedFromRateLimiting: req.ExcludedFromRateLimiting,
+		Name:                     req.Name,
+		Description:              req.Description,
+		Labels:                   req.Labels,
+		ExpiresAt:                expiresAt,
+		Scopes:                   req.Scopes,
+		AllowedModels:            req.AllowedModels,
+		AllowedCIDRs:             cidrs,
+		RequestsPerMinute:        limits.RequestsPerMinute,
+		TokensPerMinute:          limits.TokensPerMinute,
+		MonthlyTokenBudget:       limits.MonthlyTokenBudget,
+	}, nil
+}
+
+// createAPIKey creates an API key with the given spec and secret, and returns its proto.
+func (s *S) createAPIKey(
+	ctx context.Context,
+	spec store.APIKeySpec,
+	secKey string,
+	role v1.OrganizationRole,
+) (*v1.APIKey, error) {
+	key, err := s.createProjectAPIKey(ctx, spec, secKey, role)
 	if err != nil {
 		if gerrors.IsUniqueConstraintViolation(err) {
-			return nil, status.Errorf(codes.AlreadyExists, "api key %q already exists", req.Name)
+			return nil, status.Errorf(codes.AlreadyExists, "api key %q already exists", spec.Name)
 		}
 		return nil, status.Errorf(codes.Internal, "create api key: %s", err)
 	}
-	orgsByID, projectsByID, err := getOrgAndProject(s.store, userInfo.TenantID, req.OrganizationId, req.ProjectId)
+	orgsByID, projectsByID, err := getOrgAndProject(s.store, spec.TenantID, spec.OrganizationID, spec.ProjectID)
 	if err != nil {
 		return nil, err
 	}
@@ -245,45 +771,125 @@ func (s *S) CreateProjectAPIKey(
 	return kProto, nil
 }
 
+func validateAllowedModels(models []string) error {
+	for _, m := range models {
+		if m == "" {
+			return status.Error(codes.InvalidArgument, "model id in allowed_models must not be empty")
+		}
+		if strings.Contains(m, ",") {
+			return status.Errorf(codes.InvalidArgument, "model id %q in allowed_models must not contain ','", m)
+		}
+	}
+	return nil
+}
+
+// normalizeCIDRs validates CIDR ranges and converts them to their canonical forms.
+func normalizeCIDRs(cidrs []string) ([]string, error) {
+	var normalized []string
+	for _, c := range cidrs {
+		_, ipnet, err := net.ParseCIDR(c)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid cidr %q: %s", c, err)
+		}
+		normalized = append(normalized, ipnet.String())
+	}
+	return normalized, nil
+}
+
+func validateAPIKeyLimits(l *v1.APIKeyLimits) error {
+	if l.RequestsPerMinute < 0 {
+		return status.Error(codes.InvalidArgument, "requests_per_minute must not be negative")
+	}
+	if l.TokensPerMinute < 0 {
+		return status.Error(codes.InvalidArgument, "tokens_per_minute must not be negative")
+	}
+	if l.MonthlyTokenBudget < 0 {
+		return status.Error(codes.InvalidArgument, "monthly_token_budget must not be negative")
+	}
+	return nil
+}
+
+func validateAPIKeyLabels(labels map[string]string) error {
+	if len(labels) > maxAPIKeyLabels {
+		return status.Errorf(codes.InvalidArgument, "the number of labels must not exceed %d", maxAPIKeyLabels)
+	}
+	for k, v := range labels {
+		if len(k) > maxAPIKeyLabelKeyLength || !labelKeyRE.MatchString(k) {
+			return status.Errorf(codes.InvalidArgument, "invalid label key %q: it must consist of at most %d alphanumeric characters, '-', '_', '.', or '/', and start with an alphanumeric character", k, maxAPIKeyLabelKeyLength)
+		}
+		if len(v) > maxAPIKeyLabelValueLength {
+			return status.Errorf(codes.InvalidArgument, "value of label %q must not exceed %d characters", k, maxAPIKeyLabelValueLength)
+		}
+		// Values must be expressible in a label selector.
+		if strings.Contains(v, ",") {
+			return status.Errorf(codes.InvalidArgument, "value of label %q must not contain ','", k)
+		}
+	}
+	return nil
+}
+
+func validateAPIKeyDescription(desc string) error {
+	if len(desc) > maxAPIKeyDescriptionLength {
+		return status.Errorf(codes.InvalidArgument, "description must not exceed %d characters", maxAPIKeyDescriptionLength)
+	}
+	return nil
+}
+
+// apiKeyExpiresAt returns the expiration time (Unix time in seconds) of a new API key.
+// It returns zero if the key does not expire.
+func apiKeyExpiresAt(req *v1.CreateAPIKeyRequest, now time.Time) (int64, error) {
+	if req.ExpiresAt != 0 && req.TtlSeconds != 0 {
+		return 0, status.Error(codes.InvalidArgument, "only one of expires_at and ttl_seconds can be set")
+	}
+	if req.TtlSeconds < 0 {
+		return 0, status.Error(codes.InvalidArgument, "ttl_seconds must be positive")
+	}
+	if req.TtlSeconds > 0 {
+		return now.Unix() + req.TtlSeconds, nil
+	}
+	if req.ExpiresAt != 0 && req.ExpiresAt <= now.Unix() {
+		return 0, status.Error(codes.InvalidArgument, "expires_at must be in the future")
+	}
+	return req.ExpiresAt, nil
+}
+
+// createProjectAPIKey creates an API key with the given spec. The secret fields of the spec are populated
+// from secKey. For a service account key that is not owned by an existing service account, a user for
+// the service account is also created with the given role.
 func (s *S) createProjectAPIKey(
 	ctx context.Context,
-	name string,
+	spec store.APIKeySpec,
 	secKey string,
-	userID string,
-	organizationID string,
-	projectID string,
-	tenantID string,
-	isServiceAccount bool,
 	role v1.OrganizationRole,
-	excludedFromRateLimiting bool,
 ) (*store.APIKey, error) {
-	if isServiceAccount {
+	if err := s.setAPIKeySecret(ctx, &spec, secKey); err != nil {
+		return nil, err
+	}
+
+	if spec.IsServiceAccount && !serviceaccount.IsServiceAccount(spec.UserID) {
 		var key *store.APIKey
 		err := s.store.Transaction(func(tx *gorm.DB) error {
-			userID := toServiceAccountUserID(name)
-			if _, err := findOrCreateUserInTransaction(tx, userID); err != nil {
+			spec.UserID = serviceaccount.UserID(spec.TenantID, spec.ProjectID, spec.Name)
+			if _, err := findOrCreateUserInTransaction(tx, spec.UserID); err != nil {
 				return err
 			}
 			if _, err := store.CreateOrganizationUserInTransaction(
 				tx,
-				organizationID,
-				userID,
+				spec.OrganizationID,
+				spec.UserID,
 				role.String(),
 			); err != nil {
 				return err
 			}
 			if _, err := store.CreateProjectUserInTransaction(tx, store.CreateProjectUserParams{
-				ProjectID:      projectID,
-				OrganizationID: organizationID,
-				UserID:         userID,
+				ProjectID:      spec.ProjectID,
+				OrganizationID: spec.OrganizationID,
+				UserID:         spec.UserID,
 				Role:           v1.ProjectRole_PROJECT_ROLE_OWNER,
 			}); err != nil {
 				return err
 			}
-			spec, err := createAPIKeySpec(ctx, s.dataKey, name, secKey, userID, organizationID, projectID, tenantID, true, excludedFromRateLimiting)
-			if err != nil {
-				return err
-			}
+			var err error
 			key, err = store.CreateAPIKeyInTransaction(tx, spec)
 			if err != nil {
 				return err
@@ -296,49 +902,102 @@ func (s *S) createProjectAPIKey(
 		return key, nil
 	}
 
-	spec, err := createAPIKeySpec(ctx, s.dataKey, name, secKey, userID, organizationID, projectID, tenantID, false, excludedFromRateLimiting)
-	if err != nil {
-		return nil, err
-	}
 	return s.store.CreateAPIKey(spec)
 }
 
-func createAPIKeySpec(
+// setAPIKeySecret sets the secret fields of the spec.
+func (s *S) setAPIKeySecret(ctx context.Context, spec *store.APIKeySpec, secKey string) error {
+	ss, err := newStoredSecret(ctx, s.dataKey, s.hashAPIKeySecrets, spec.APIKeyID, secKey)
+	if err != nil {
+		return err
+	}
+	spec.Secret = ss.secret
+	spec.EncryptedSecret = ss.encrypted
+	spec.SecretPrefix = ss.prefix
+	spec.SecretHash = ss.hash
+	spec.SecretSalt = ss.salt
+	return nil
+}
+
+// TransferAPIKey changes the owner of an API key to another member of the project.
+func (s *S) TransferAPIKey(
 	ctx context.Context,
-	dataKey []byte,
-	name string,
-	secKey string,
-	userID string,
-	organizationID string,
-	projectID string,
-	tenantID string,
-	isServiceAccount bool,
-	excludedFromRateLimiting bool,
-) (store.APIKeySpec, error) {
-	trackID, err := id.GenerateID("key_", 16)
+	req *v1.TransferAPIKeyRequest,
+) (*v1.TransferAPIKeyResponse, error) {
+	userInfo, ok := auth.ExtractUserInfoFromContext(ctx)
+	if !ok {
+		return nil, status.Errorf(codes.Internal, "failed to extract user info from context")
+	}
+
+	if req.Id == "" {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+	if req.UserId == "" {
+		return nil, status.Error(codes.InvalidArgument, "user id is required")
+	}
+
+	key, err := s.store.GetAPIKeyByID(req.Id)
 	if err != nil {
-		return store.APIKeySpec{}, fmt.Errorf("generate api key id: %s", err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.NotFound, "api key %q not found", req.Id)
+		}
+		return nil, status.Errorf(codes.Internal, "get api key: %s", err)
 	}
-	spec := store.APIKeySpec{
-		APIKeyID:                 trackID,
-		TenantID:                 tenantID,
-		OrganizationID:           organizationID,
-		ProjectID:                projectID,
-		UserID:                   userID,
-		Name:                     name,
-		IsServiceAccount:         isServiceAccount,
-		ExcludedFromRateLimiting: excludedFromRateLimiting,
+	if key.TenantID != userInfo.TenantID {
+		return nil, status.Errorf(codes.NotFound, "api key %q not found", req.Id)
 	}
-	if len(dataKey) > 0 {
-		encryptedAPIKey, err := aws.Encrypt(ctx, secKey, trackID, dataKey)
-		if err != nil {
-			return store.APIKeySpec{}, err
+
+	if err := s.validateProjectOwner(key.ProjectID, key.OrganizationID, userInfo.UserID); err != nil {
+		return nil, err
+	}
+
+	if req.UserId == key.UserID {
+		return nil, status.Errorf(codes.InvalidArgument, "api key %q is already owned by user %q", req.Id, req.UserId)
+	}
+	if _, err := s.store.GetProjectUser(key.ProjectID, req.UserId); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.FailedPrecondition, "user %q is not a member of project %q", req.UserId, key.ProjectID)
 		}
-		spec.EncryptedSecret = encryptedAPIKey
-	} else {
-		spec.Secret = secKey
+		return nil, status.Errorf(codes.Internal, "get project user: %s", err)
+	}
+
+	prevUserID := key.UserID
+	if err := s.store.Transaction(func(tx *gorm.DB) error {
+		if err := store.TransferAPIKeyInTransaction(tx, key.APIKeyID, req.UserId, serviceaccount.IsServiceAccount(req.UserId)); err != nil {
+			return err
+		}
+		if !key.IsServiceAccount {
+			return nil
+		}
+		// Delete the user of a legacy service account once its last API key is transferred away
+		// as DeleteProjectAPIKey does when the key is deleted.
+		return deleteOrphanedLegacyServiceAccountInTransaction(tx, key.OrganizationID, key.ProjectID, prevUserID)
+	}); err != nil {
+		if gerrors.IsUniqueConstraintViolation(err) {
+			return nil, status.Errorf(codes.AlreadyExists, "user %q already has an api key named %q", req.UserId, key.Name)
+		}
+		return nil, status.Errorf(codes.Internal, "transfer api key: %s", err)
+	}
+	s.log.Info("Transferred API key", "apiKeyID", key.APIKeyID, "from", prevUserID, "to", req.UserId, "by", userInfo.UserID)
+
+	key, err = s.store.GetAPIKeyByID(req.Id)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "get api key: %s", err)
+	}
+
+	orgsByID, projectsByID, err := getOrgAndProject(s.store, userInfo.TenantID, key.OrganizationID, key.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	// Do not populate the internal User ID for non-internal gRPC.
+	kProto, err := toAPIKeyProto(ctx, s.store, s.dataKey, key, "", false, orgsByID, projectsByID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "to api key proto: %s", err)
 	}
-	return spec, nil
+	return &v1.TransferAPIKeyResponse{
+		ApiKey:         kProto,
+		PreviousUserId: prevUserID,
+	}, nil
 }
 
 // ListProjectAPIKeys lists API keys.
@@ -380,20 +1039,10 @@ func (s *S) ListProjectAPIKeys(
 		}
 	}
 
-	orgsByID, projectsByID, err := getOrgAndProject(s.store, userInfo.TenantID, req.OrganizationId, req.ProjectId)
+	apiKeyProtos, err := s.toAPIKeyProtos(ctx, filtered)
 	if err != nil {
 		return nil, err
 	}
-
-	var apiKeyProtos []*v1.APIKey
-	for _, k := range filtered {
-		// Do not populate the internal User ID for non-internal gRPC.
-		kp, err := toAPIKeyProto(ctx, s.store, s.dataKey, k, "", false, orgsByID, projectsByID)
-		if err != nil {
-			return nil, status.Errorf(codes.Internal, "to api key proto")
-		}
-		apiKeyProtos = append(apiKeyProtos, kp)
-	}
 	return &v1.ListAPIKeysResponse{
 		Object: "list",
 		Data:   apiKeyProtos,
@@ -445,22 +1094,11 @@ func (s *S) DeleteProjectAPIKey(
 
 	if key.IsServiceAccount {
 		if err := s.store.Transaction(func(tx *gorm.DB) error {
-			if err := store.DeleteProjectUserInTransaction(tx, key.ProjectID, key.UserID); err != nil {
-				if !errors.Is(err, gorm.ErrRecordNotFound) {
-					return fmt.Errorf("delete project user %s: %s", key.UserID, err)
-				}
-			}
-			if err := store.DeleteOrganizationUserInTransaction(tx, req.OrganizationId, key.UserID); err != nil {
-				if !errors.Is(err, gorm.ErrRecordNotFound) {
-					return fmt.Errorf("delete project user %s: %s", key.UserID, err)
-				}
-			}
-			if err := store.DeleteUserInTransaction(tx, key.UserID); err != nil {
-				if !errors.Is(err, gorm.ErrRecordNotFound) {
-					return fmt.Errorf("delete user %s: %s", key.UserID, err)
-				}
+			if err := store.DeleteAPIKeyInTransaction(tx, req.Id, req.ProjectId); err != nil {
+				return err
 			}
-			return store.DeleteAPIKeyInTransaction(tx, req.Id, req.ProjectId)
+			// Keep the service account if it still owns other API keys transferred to it.
+			return deleteOrphanedLegacyServiceAccountInTransaction(tx, req.OrganizationId, key.ProjectID, key.UserID)
 		}); err != nil {
 			return nil, status.Errorf(codes.Internal, "delete api key: %s", err)
 		}
@@ -481,41 +1119,98 @@ func (s *S) DeleteProjectAPIKey(
 func (s *S) CreateDefaultAPIKey(ctx context.Context, c *config.DefaultAPIKeyConfig, orgID, projectID, tenantID string) error {
 	userID := c.UserID
 	if c.IsServiceAccount {
-		userID = toServiceAccountUserID(c.Name)
+		userID = serviceaccount.UserID(tenantID, projectID, c.Name)
 	}
 	if _, err := s.store.GetAPIKeyByNameAndUserID(c.Name, userID); err == nil {
 		// Do nothing.
 		return nil
 	}
 
-	_, err := s.createProjectAPIKey(
-		ctx,
-		c.Name,
-		c.Secret,
-		c.UserID,
-		orgID,
-		projectID,
-		tenantID,
-		c.IsServiceAccount,
-		v1.OrganizationRole_ORGANIZATION_ROLE_TENANT_SYSTEM,
-		c.ExcludedFromRateLimiting,
-	)
+	keyID, err := id.GenerateID(apikey.KeyIDPrefix, 16)
+	if err != nil {
+		return fmt.Errorf("generate api key id: %s", err)
+	}
+	spec := store.APIKeySpec{
+		APIKeyID:                 keyID,
+		TenantID:                 tenantID,
+		OrganizationID:           orgID,
+		ProjectID:                projectID,
+		UserID:                   c.UserID,
+		IsServiceAccount:         c.IsServiceAccount,
+		ExcludedFromRateLimiting: c.ExcludedFromRateLimiting,
+		Name:                     c.Name,
+	}
+	_, err = s.createProjectAPIKey(ctx, spec, c.Secret, v1.OrganizationRole_ORGANIZATION_ROLE_TENANT_SYSTEM)
 	return err
 }
 
-// ListInternalAPIKeys lists all API keys.
+// ListInternalAPIKeys lists all API keys that have not expired, or the ones that have changed after the given revision.
 func (s *IS) ListInternalAPIKeys(
 	ctx context.Context,
 	req *v1.ListInternalAPIKeysRequest,
 ) (*v1.ListInternalAPIKeysResponse, error) {
-	ks, err := s.store.ListAllAPIKeys()
+	rev, err := s.latestRevision(req.SinceRevision)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "list api keys: %s", err)
+		return nil, err
 	}
 
-	us, err := s.store.ListAllUsers()
-	if err != nil {
-		return nil, status.Errorf(codes.Internal, "list users: %s", err)
+	now := time.Now()
+	var (
+		ks         []*store.APIKey
+		us         []store.User
+		deletedIDs []string
+	)
+	if req.SinceRevision == 0 {
+		ks, err = s.store.ListAllActiveAPIKeys(now)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "list api keys: %s", err)
+		}
+		us, err = s.store.ListAllUsers()
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "list users: %s", err)
+		}
+	} else {
+		ids, err := s.listChangedResourceIDs(store.ResourceKindAPIKey, req.SinceRevision)
+		if err != nil {
+			return nil, err
+		}
+		// Expirations are not recorded in the change log. Also return the API keys whose secrets
+		// or previous secrets have expired after the change of the revision.
+		c, err := s.sinceRevisionChange(req.SinceRevision)
+		if err != nil {
+			return nil, err
+		}
+		eks, err := s.store.ListAPIKeysExpiredBetween(c.CreatedAt, now)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "list api keys: %s", err)
+		}
+		changed := map[string]bool{}
+		for _, id := range ids {
+			changed[id] = true
+		}
+		for _, k := range eks {
+			if !changed[k.APIKeyID] {
+				ids = append(ids, k.APIKeyID)
+			}
+		}
+
+		ks, err = s.store.ListActiveAPIKeysByIDs(ids, now)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "list api keys: %s", err)
+		}
+		found := map[string]bool{}
+		var userIDs []string
+		for _, k := range ks {
+			found[k.APIKeyID] = true
+			userIDs = append(userIDs, k.UserID)
+		}
+		// Deleted and expired API keys are returned as tombstones.
+		deletedIDs = tombstones(ids, found)
+
+		us, err = s.store.ListUsersByUserIDs(userIDs)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "list users: %s", err)
+		}
 	}
 	internalUserIDs := map[string]string{}
 	for _, u := range us {
@@ -528,40 +1223,242 @@ func (s *IS) ListInternalAPIKeys(
 		if !ok {
 			return nil, status.Errorf(codes.Internal, "internal user ID not found for user %q", k.UserID)
 		}
-		kp, err := toAPIKeyProto(ctx, s.store, s.dataKey, k, id, true, nil, nil)
+		kps, err := toInternalAPIKeyProtos(ctx, s.store, s.dataKey, k, id, now)
 		if err != nil {
-			return nil, status.Errorf(codes.Internal, "to api key proto")
+			return nil, status.Errorf(codes.Internal, "to api key proto: %s", err)
 		}
-		apiKeyProtos = append(apiKeyProtos, &v1.InternalAPIKey{
-			ApiKey:   kp,
-			TenantId: k.TenantID,
-		})
+		apiKeyProtos = append(apiKeyProtos, kps...)
 	}
 	return &v1.ListInternalAPIKeysResponse{
-		ApiKeys: apiKeyProtos,
+		ApiKeys:          apiKeyProtos,
+		Revision:         rev,
+		DeletedApiKeyIds: deletedIDs,
 	}, nil
 }
 
-func getOrgsAndProjects(s *store.S, tenantID string) (map[string]*store.Organization, map[string]*store.Project, error) {
-	orgs, err := s.ListOrganizations(tenantID)
+// AuthenticateAPIKey returns the unexpired API key that has the given secret.
+func (s *IS) AuthenticateAPIKey(
+	ctx context.Context,
+	req *v1.AuthenticateAPIKeyRequest,
+) (*v1.InternalAPIKey, error) {
+	if req.Secret == "" {
+		return nil, status.Error(codes.InvalidArgument, "secret is required")
+	}
+
+	now := time.Now()
+	cands, err := findAPIKeyCandidates(s.store, req.Secret, now)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
-	orgsByID := map[string]*store.Organization{}
-	for _, o := range orgs {
-		orgsByID[o.OrganizationID] = o
+	for _, c := range cands {
+		k := c.k
+		if k.ExpiresAt != 0 && k.ExpiresAt <= now.Unix() {
+			continue
+		}
+		ok, err := secretMatches(ctx, s.dataKey, k.APIKeyID, c.ss, req.Secret)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "verify secret: %s", err)
+		}
+		if !ok {
+			continue
+		}
+		if k.Disabled {
+			return nil, status.Errorf(codes.PermissionDenied, "api key %q is disabled", k.APIKeyID)
+		}
+
+		u, err := s.store.GetUserByUserID(k.UserID)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "get user: %s", err)
+		}
+		kp, err := toAPIKeyProto(ctx, s.store, s.dataKey, k, u.InternalUserID, false, nil, nil)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "to api key proto: %s", err)
+		}
+		return &v1.InternalAPIKey{
+			ApiKey:   kp,
+			TenantId: k.TenantID,
+		}, nil
+	}
+	return nil, status.Error(codes.NotFound, "api key not found")
+}
+
+type apiKeyCandidate struct {
+	k  *store.APIKey
+	ss storedSecret
+}
+
+// findAPIKeyCandidates returns the stored secrets that can match the given secret.
+func findAPIKeyCandidates(st *store.S, secret string, now time.Time) ([]apiKeyCandidate, error) {
+	// Secrets in the structured format embed the key ID.
+	if strings.HasPrefix(secret, apikey.V1Prefix) {
+		sec, err := apikey.Parse(secret)
+		if err != nil {
+			// Reject malformed secrets without further lookups.
+			return nil, nil
+		}
+		k, err := st.GetAPIKeyByID(sec.KeyID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, nil
+			}
+			return nil, status.Errorf(codes.Internal, "get api key: %s", err)
+		}
+		cands := []apiKeyCandidate{{k: k, ss: currentSecret(k)}}
+		// Secrets before rotations remain valid during their grace periods.
+		if k.PreviousSecretExpiresAt > now.Unix() {
+			cands = append(cands, apiKeyCandidate{k: k, ss: previousSecret(k)})
+		}
+		return cands, nil
 	}
 
-	projects, err := s.ListProjectsByTenantID(tenantID)
+	prefix := secretPrefix(secret)
+	ks, err := st.ListAPIKeysBySecretPrefix(prefix)
 	if err != nil {
-		return nil, nil, err
+		return nil, status.Errorf(codes.Internal, "list api keys: %s", err)
 	}
-	projectsByID := map[string]*store.Project{}
-	for _, p := range projects {
-		projectsByID[p.ProjectID] = p
+	var cands []apiKeyCandidate
+	for _, k := range ks {
+		cands = append(cands, apiKeyCandidate{k: k, ss: currentSecret(k)})
 	}
+	ks, err = st.ListAPIKeysByPreviousSecretPrefix(prefix, now)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "list api keys: %s", err)
+	}
+	for _, k := range ks {
+		cands = append(cands, apiKeyCandidate{k: k, ss: previousSecret(k)})
+	}
+	return cands, nil
+}
 
-	return orgsByID, projectsByID, nil
+// secretInUse returns true if the secret matches the current or previous secret of an existing API key.
+func secretInUse(ctx context.Context, st *store.S, dataKey []byte, secret string, now time.Time) (bool, error) {
+	cands, err := findAPIKeyCandidates(st, secret, now)
+	if err != nil {
+		return false, err
+	}
+	for _, c := range cands {
+		ok, err := secretMatches(ctx, dataKey, c.k.APIKeyID, c.ss, secret)
+		if err != nil {
+			return false, status.Errorf(codes.Internal, "verify secret: %s", err)
+		}
+		if ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ReportAPIKeyUsages records the last-used times and sources of API keys.
+func (s *IS) ReportAPIKeyUsages(
+	ctx context.Context,
+	req *v1.ReportAPIKeyUsagesRequest,
+) (*emptypb.Empty, error) {
+	// Keep only the latest usage of each API key.
+	latest := map[string]*v1.APIKeyUsage{}
+	for _, u := range req.Usages {
+		if u.ApiKeyId == "" {
+			return nil, status.Error(codes.InvalidArgument, "api key id is required")
+		}
+		if u.UsedAt <= 0 {
+			return nil, status.Error(codes.InvalidArgument, "used_at must be positive")
+		}
+		if l, ok := latest[u.ApiKeyId]; ok && l.UsedAt >= u.UsedAt {
+			continue
+		}
+		latest[u.ApiKeyId] = u
+	}
+
+	for _, u := range latest {
+		if err := s.store.UpdateAPIKeyLastUsed(u.ApiKeyId, u.UsedAt, u.SourceIp); err != nil {
+			return nil, status.Errorf(codes.Internal, "update api key: %s", err)
+		}
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// toInternalAPIKeyProtos converts an API key to protos with full secrets. It returns two protos if
+// the secret before the last rotation is still valid.
+//
+// Hashed secrets cannot be recovered, so their protos have empty secrets and carry the hashes instead.
+func toInternalAPIKeyProtos(
+	ctx context.Context,
+	s *store.S,
+	dataKey []byte,
+	k *store.APIKey,
+	internalUserID string,
+	now time.Time,
+) ([]*v1.InternalAPIKey, error) {
+	kp, err := toAPIKeyProto(ctx, s, dataKey, k, internalUserID, true, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	kps := []*v1.InternalAPIKey{
+		newInternalAPIKey(kp, k.TenantID, currentSecret(k)),
+	}
+
+	if k.PreviousSecretExpiresAt <= now.Unix() {
+		return kps, nil
+	}
+	ps := previousSecret(k)
+	pkp := proto.Clone(kp).(*v1.APIKey)
+	pkp.Secret = ""
+	if !ps.isHashed() {
+		pkp.Secret, err = decryptSecret(ctx, dataKey, k.APIKeyID, ps)
+		if err != nil {
+			return nil, fmt.Errorf("decrypt previous secret: %s", err)
+		}
+	}
+	return append(kps, newInternalAPIKey(pkp, k.TenantID, ps)), nil
+}
+
+func newInternalAPIKey(kp *v1.APIKey, tenantID string, ss storedSecret) *v1.InternalAPIKey {
+	ik := &v1.InternalAPIKey{
+		ApiKey:   kp,
+		TenantId: tenantID,
+	}
+	if ss.isHashed() {
+		ik.SecretHashed = true
+		ik.SecretPrefix = ss.prefix
+		ik.SecretSalt = ss.salt
+		ik.SecretHash = ss.hash
+	}
+	return ik
+}
+
+// deleteOrphanedLegacyServiceAccountInTransaction deletes the user and the memberships of a service account
+// created together with its API key if the service account no longer owns any API key.
+func deleteOrphanedLegacyServiceAccountInTransaction(tx *gorm.DB, orgID, projectID, userID string) error {
+	if _, err := store.GetServiceAccountByUserIDInTransaction(tx, userID); err == nil {
+		// The service account is deleted only by DeleteServiceAccount.
+		return nil
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("get service account: %s", err)
+	}
+
+	n, err := store.CountAPIKeysByUserIDInTransaction(tx, userID)
+	if err != nil {
+		return fmt.Errorf("count api keys: %s", err)
+	}
+	if n > 0 {
+		return nil
+	}
+
+	if err := store.DeleteProjectUserInTransaction(tx, projectID, userID); err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("delete project user %s: %s", userID, err)
+		}
+	}
+	if err := store.DeleteOrganizationUserInTransaction(tx, orgID, userID); err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("delete organization user %s: %s", userID, err)
+		}
+	}
+	if err := store.DeleteUserInTransaction(tx, userID); err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("delete user %s: %s", userID, err)
+		}
+	}
+	return nil
 }
 
 func getOrgAndProject(st *store.S, tenantID, organizationID, projectID string) (map[string]*store.Organization, map[string]*store.Project, error) {
@@ -600,19 +1497,41 @@ func toAPIKeyProto(
 	if err != nil {
 		return nil, err
 	}
+	return toAPIKeyProtoWithRoles(ctx, dataKey, k, internalUserID, showFullSecret, orgsByID, projectsByID, orgRole, projectRole)
+}
 
+// toAPIKeyProtoWithRoles is toAPIKeyProto with the roles of the key's user already looked up.
+func toAPIKeyProtoWithRoles(
+	ctx context.Context,
+	dataKey []byte,
+	k *store.APIKey,
+	internalUserID string,
+	showFullSecret bool,
+	orgsByID map[string]*store.Organization,
+	projectsByID map[string]*store.Project,
+	orgRole v1.OrganizationRole,
+	projectRole v1.ProjectRole,
+) (*v1.APIKey, error) {
 	var secret string
-	if len(dataKey) > 0 {
-		secret, err = aws.Decrypt(ctx, k.EncryptedSecret, k.APIKeyID, dataKey)
+	var err error
+	if ss := currentSecret(k); ss.isHashed() {
+		// The secret cannot be recovered from its hash.
+		if !showFullSecret {
+			secret = obfuscateSecretPrefix(ss.prefix)
+		}
+	} else {
+		secret, err = decryptSecret(ctx, dataKey, k.APIKeyID, ss)
 		if err != nil {
 			return nil, err
 		}
-	} else {
-		secret = k.Secret
+		if !showFullSecret {
+			secret = obfuscateSecret(secret)
+		}
 	}
 
-	if !showFullSecret {
-		secret = obfuscateSecret(secret)
+	labels, err := k.GetLabels()
+	if err != nil {
+		return nil, err
 	}
 
 	var orgTitle string
@@ -654,6 +1573,23 @@ func toAPIKeyProto(
 		ProjectRole:              projectRole,
 		Secret:                   secret,
 		ExcludedFromRateLimiting: k.ExcludedFromRateLimiting,
+		Enabled:                  !k.Disabled,
+		Labels:                   labels,
+		Description:              k.Description,
+		ImportedFrom:             k.ImportedFrom,
+		ImportedBy:               k.ImportedBy,
+		ExpiresAt:                k.ExpiresAt,
+		PreviousSecretExpiresAt:  k.PreviousSecretExpiresAt,
+		LastUsedAt:               k.LastUsedAt,
+		LastUsedSourceIp:         k.LastUsedSourceIP,
+		Scopes:                   k.GetScopes(),
+		AllowedModels:            k.GetAllowedModels(),
+		AllowedCidrs:             k.GetAllowedCIDRs(),
+		Limits: &v1.APIKeyLimits{
+			RequestsPerMinute:  k.RequestsPerMinute,
+			TokensPerMinute:    k.TokensPerMinute,
+			MonthlyTokenBudget: k.MonthlyTokenBudget,
+		},
 	}, nil
 }
 
@@ -695,6 +1631,11 @@ func obfuscateSecret(secret string) string {
 	return prefix + obfuscated + suffix
 }
 
-func toServiceAccountUserID(name string) string {
-	return fmt.Sprintf("system:serviceaccount:%s", name)
+// obfuscateSecretPrefix obfuscates the secret from its prefix.
+// The function returns the first 5 characters of the prefix followed by '*'.
+func obfuscateSecretPrefix(prefix string) string {
+	if len(prefix) > 5 {
+		prefix = prefix[0:5]
+	}
+	return prefix + strings.Repeat("*", 5)
 }
@@ -2,12 +2,18 @@ package server
 
 import (
 	"context"
+	"errors"
+	"math"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-logr/logr/testr"
 	"github.com/llmariner/common/pkg/aws"
 	"github.com/llmariner/rbac-manager/pkg/auth"
 	v1 "github.com/llmariner/user-manager/api/v1"
+	"github.com/llmariner/user-manager/pkg/apikey"
+	"github.com/llmariner/user-manager/server/internal/config"
 	"github.com/llmariner/user-manager/server/internal/store"
 	"github.com/stretchr/testify/assert"
 	"google.golang.org/grpc/codes"
@@ -47,17 +53,7 @@ func TestAPIKey(t *testing.T) {
 			srv := New(st, dataKey, testr.New(t))
 
 			ctx := fakeAuthInto(context.Background())
-			org, err := srv.CreateOrganization(ctx, &v1.CreateOrganizationRequest{
-				Title: "Test organization",
-			})
-			assert.NoError(t, err)
-
-			proj, err := srv.CreateProject(ctx, &v1.CreateProjectRequest{
-				Title:               "Test project",
-				OrganizationId:      org.Id,
-				KubernetesNamespace: "test",
-			})
-			assert.NoError(t, err)
+			org, proj := createOrganizationAndProject(ctx, t, srv)
 
 			// Test default value of excluded_from_rate_limiting (should be false)
 			cresp, err := srv.CreateAPIKey(ctx, &v1.CreateAPIKeyRequest{
@@ -69,6 +65,11 @@ func TestAPIKey(t *testing.T) {
 			assert.Equal(t, "dummy", cresp.Name)
 			assert.False(t, cresp.ExcludedFromRateLimiting, "excluded_from_rate_limiting should default to false")
 
+			sec, err := apikey.Parse(cresp.Secret)
+			assert.NoError(t, err)
+			assert.Equal(t, cresp.Id, sec.KeyID)
+			assert.Equal(t, apikey.TenantHint(defaultTenantID), sec.TenantHint)
+
 			apiKey, err := st.GetAPIKey(cresp.Id, proj.Id)
 			assert.NoError(t, err)
 			assert.False(t, apiKey.ExcludedFromRateLimiting, "excluded_from_rate_limiting in database should be false")
@@ -100,80 +101,1036 @@ func TestAPIKey(t *testing.T) {
 			assert.NoError(t, err)
 			assert.True(t, apiKey2.ExcludedFromRateLimiting, "excluded_from_rate_limiting in database should be true")
 
-			_, err = srv.CreateAPIKey(ctx, &v1.CreateAPIKeyRequest{
-				Name:           "dummy",
-				OrganizationId: org.Id,
-				ProjectId:      proj.Id,
-			})
-			assert.Error(t, err)
-			assert.Equal(t, codes.AlreadyExists, status.Code(err))
+			_, err = srv.CreateAPIKey(ctx, &v1.CreateAPIKeyRequest{
+				Name:           "dummy",
+				OrganizationId: org.Id,
+				ProjectId:      proj.Id,
+			})
+			assert.Error(t, err)
+			assert.Equal(t, codes.AlreadyExists, status.Code(err))
+
+			lresp, err := srv.ListAPIKeys(ctx, &v1.ListAPIKeysRequest{})
+			assert.NoError(t, err)
+			assert.Len(t, lresp.Data, 2)
+
+			// Check that the field value is preserved in list responses
+			for _, key := range lresp.Data {
+				switch key.Name {
+				case "dummy":
+					assert.False(t, key.ExcludedFromRateLimiting, "regular key should have excluded_from_rate_limiting=false")
+				case "excluded-key":
+					assert.True(t, key.ExcludedFromRateLimiting, "excluded key should have excluded_from_rate_limiting=true")
+				}
+			}
+
+			_, err = srv.DeleteAPIKey(ctx, &v1.DeleteAPIKeyRequest{Id: cresp.Id})
+			assert.NoError(t, err)
+			_, err = srv.DeleteAPIKey(ctx, &v1.DeleteAPIKeyRequest{Id: cresp2.Id})
+			assert.NoError(t, err)
+
+			lresp, err = srv.ListAPIKeys(ctx, &v1.ListAPIKeysRequest{})
+			assert.NoError(t, err)
+			assert.Empty(t, lresp.Data)
+		})
+	}
+}
+
+func TestListAPIKeys_PaginationAndFilters(t *testing.T) {
+	st, tearDown := store.NewTest(t)
+	defer tearDown()
+
+	srv := New(st, nil, testr.New(t))
+
+	ctx := fakeAuthInto(context.Background())
+	org, err := srv.CreateOrganization(ctx, &v1.CreateOrganizationRequest{
+		Title: "Test organization",
+	})
+	assert.NoError(t, err)
+
+	var projs []*v1.Project
+	for _, title := range []string{"p0", "p1"} {
+		proj, err := srv.CreateProject(ctx, &v1.CreateProjectRequest{
+			Title:               title,
+			OrganizationId:      org.Id,
+			KubernetesNamespace: "test",
+		})
+		assert.NoError(t, err)
+		projs = append(projs, proj)
+	}
+
+	// Create keys in the reverse order of their names.
+	for _, name := range []string{"prod-2", "prod-1", "dev-1", "prod-0"} {
+		_, err := srv.CreateAPIKey(ctx, &v1.CreateAPIKeyRequest{
+			Name:           name,
+			OrganizationId: org.Id,
+			ProjectId:      projs[0].Id,
+		})
+		assert.NoError(t, err)
+	}
+	_, err = srv.CreateAPIKey(ctx, &v1.CreateAPIKeyRequest{
+		Name:             "sa",
+		OrganizationId:   org.Id,
+		ProjectId:        projs[1].Id,
+		IsServiceAccount: true,
+		Role:             v1.OrganizationRole_ORGANIZATION_ROLE_READER,
+	})
+	assert.NoError(t, err)
+
+	names := func(resp *v1.ListAPIKeysResponse) []string {
+		var ns []string
+		for _, k := range resp.Data {
+			ns = append(ns, k.Name)
+		}
+		return ns
+	}
+
+	// Paginate by name.
+	resp, err := srv.ListAPIKeys(ctx, &v1.ListAPIKeysRequest{Limit: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"dev-1", "prod-0"}, names(resp))
+	assert.True(t, resp.HasMore)
+	resp, err = srv.ListAPIKeys(ctx, &v1.ListAPIKeysRequest{Limit: 2, After: resp.Data[1].Id})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"prod-1", "prod-2"}, names(resp))
+	assert.True(t, resp.HasMore)
+	resp, err = srv.ListAPIKeys(ctx, &v1.ListAPIKeysRequest{Limit: 2, After: resp.Data[1].Id})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"sa"}, names(resp))
+	assert.False(t, resp.HasMore)
+
+	// Paginate by creation time in the descending order.
+	resp, err = srv.ListAPIKeys(ctx, &v1.ListAPIKeysRequest{Limit: 3, OrderBy: "created_at", Order: "desc"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"sa", "prod-0", "dev-1"}, names(resp))
+	assert.True(t, resp.HasMore)
+	resp, err = srv.ListAPIKeys(ctx, &v1.ListAPIKeysRequest{Limit: 3, OrderBy: "created_at", Order: "desc", After: resp.Data[2].Id})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"prod-1", "prod-2"}, names(resp))
+	assert.False(t, resp.HasMore)
+
+	// Filter.
+	resp, err = srv.ListAPIKeys(ctx, &v1.ListAPIKeysRequest{NamePrefix: "prod-", Order: "desc"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"prod-2", "prod-1", "prod-0"}, names(resp))
+	resp, err = srv.ListAPIKeys(ctx, &v1.ListAPIKeysRequest{ProjectId: projs[1].Id})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"sa"}, names(resp))
+	assert.Equal(t, "Test organization", resp.Data[0].Organization.Title)
+	assert.Equal(t, "p1", resp.Data[0].Project.Title)
+	assert.Equal(t, v1.OrganizationRole_ORGANIZATION_ROLE_READER, resp.Data[0].OrganizationRole)
+	resp, err = srv.ListAPIKeys(ctx, &v1.ListAPIKeysRequest{ProjectId: projs[0].Id})
+	assert.NoError(t, err)
+	for _, k := range resp.Data {
+		assert.Equal(t, "p0", k.Project.Title)
+		assert.Equal(t, v1.OrganizationRole_ORGANIZATION_ROLE_OWNER, k.OrganizationRole)
+		assert.Equal(t, v1.ProjectRole_PROJECT_ROLE_OWNER, k.ProjectRole)
+	}
+	resp, err = srv.ListAPIKeys(ctx, &v1.ListAPIKeysRequest{ServiceAccountFilter: v1.ListAPIKeysRequest_SERVICE_ACCOUNT_FILTER_EXCLUDE})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"dev-1", "prod-0", "prod-1", "prod-2"}, names(resp))
+	resp, err = srv.ListAPIKeys(ctx, &v1.ListAPIKeysRequest{ServiceAccountFilter: v1.ListAPIKeysRequest_SERVICE_ACCOUNT_FILTER_ONLY})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"sa"}, names(resp))
+	resp, err = srv.ListAPIKeys(ctx, &v1.ListAPIKeysRequest{UserId: defaultUserID, OrganizationId: org.Id})
+	assert.NoError(t, err)
+	assert.Len(t, resp.Data, 4)
+
+	// Invalid requests.
+	for _, req := range []*v1.ListAPIKeysRequest{
+		{Limit: -1},
+		{Limit: maxListAPIKeysLimit + 1},
+		{OrderBy: "title"},
+		{Order: "random"},
+		{After: "unknown"},
+	} {
+		_, err = srv.ListAPIKeys(ctx, req)
+		assert.Error(t, err)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	}
+}
+
+func TestAPIKey_Update(t *testing.T) {
+	st, tearDown := store.NewTest(t)
+	defer tearDown()
+
+	srv := New(st, nil, testr.New(t))
+
+	ctx := fakeAuthInto(context.Background())
+	org, proj := createOrganizationAndProject(ctx, t, srv)
+
+	key, err := srv.CreateAPIKey(ctx, &v1.CreateAPIKeyRequest{
+		Name:           "dummy",
+		OrganizationId: org.Id,
+		ProjectId:      proj.Id,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "dummy", key.Name)
+
+	key.Name = "dummy2"
+	_, err = srv.UpdateAPIKey(ctx, &v1.UpdateAPIKeyRequest{
+		ApiKey: key,
+		UpdateMask: &fieldmaskpb.FieldMask{
+			Paths: []string{"name"},
+		},
+	})
+	assert.NoError(t, err)
+
+	resp, err := srv.ListAPIKeys(ctx, &v1.ListAPIKeysRequest{})
+	assert.NoError(t, err)
+	assert.Len(t, resp.Data, 1)
+	assert.Equal(t, "dummy2", resp.Data[0].Name)
+}
+
+func TestAPIKey_Labels(t *testing.T) {
+	st, tearDown := store.NewTest(t)
+	defer tearDown()
+
+	srv := New(st, nil, testr.New(t))
+
+	ctx := fakeAuthInto(context.Background())
+	org, proj := createOrganizationAndProject(ctx, t, srv)
+
+	_, err := srv.CreateAPIKey(ctx, &v1.CreateAPIKeyRequest{
+		Name:           "invalid",
+		OrganizationId: org.Id,
+		ProjectId:      proj.Id,
+		Labels:         map[string]string{"env=prod": "x"},
+	})
+	assert.Error(t, err)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	k0, err := srv.CreateAPIKey(ctx, &v1.CreateAPIKeyRequest{
+		Name:           "k0",
+		OrganizationId: org.Id,
+		ProjectId:      proj.Id,
+		Labels:         map[string]string{"env": "prod", "service": "chat"},
+		Description:    "production chat service",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"env": "prod", "service": "chat"}, k0.Labels)
+	assert.Equal(t, "production chat service", k0.Description)
+
+	k1, err := srv.CreateAPIKey(ctx, &v1.CreateAPIKeyRequest{
+		Name:           "k1",
+		OrganizationId: org.Id,
+		ProjectId:      proj.Id,
+		Labels:         map[string]string{"env": "dev", "service": "chat"},
+	})
+	assert.NoError(t, err)
+
+	list := func(selector string) []string {
+		resp, err := srv.ListAPIKeys(ctx, &v1.ListAPIKeysRequest{LabelSelector: selector})
+		assert.NoError(t, err)
+		var names []string
+		for _, k := range resp.Data {
+			names = append(names, k.Name)
+		}
+		return names
+	}
+	assert.Equal(t, []string{"k0", "k1"}, list("service=chat"))
+	assert.Equal(t, []string{"k0"}, list("service=chat, env=prod"))
+	assert.Empty(t, list("env=staging"))
+
+	_, err = srv.ListAPIKeys(ctx, &v1.ListAPIKeysRequest{LabelSelector: "env"})
+	assert.Error(t, err)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	k1.Labels = map[string]string{"env": "prod"}
+	k1.Description = "moved to production"
+	got, err := srv.UpdateAPIKey(ctx, &v1.UpdateAPIKeyRequest{
+		ApiKey: k1,
+		UpdateMask: &fieldmaskpb.FieldMask{
+			Paths: []string{"labels", "description"},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"env": "prod"}, got.Labels)
+	assert.Equal(t, "moved to production", got.Description)
+	assert.Equal(t, []string{"k0", "k1"}, list("env=prod"))
+	assert.Equal(t, []string{"k0"}, list("service=chat"))
+}
+
+func TestAPIKey_AllowedModels(t *testing.T) {
+	st, tearDown := store.NewTest(t)
+	defer tearDown()
+
+	srv := New(st, nil, testr.New(t))
+	isrv := NewInternal(st, nil, testr.New(t))
+
+	ctx := fakeAuthInto(context.Background())
+	org, proj := createOrganizationAndProject(ctx, t, srv)
+
+	key, err := srv.CreateAPIKey(ctx, &v1.CreateAPIKeyRequest{
+		Name:           "dummy",
+		OrganizationId: org.Id,
+		ProjectId:      proj.Id,
+		AllowedModels:  []string{"text-embedding-small"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"text-embedding-small"}, key.AllowedModels)
+
+	key.AllowedModels = []string{"text-embedding-small", "meta-llama/Llama-3.1-8B"}
+	_, err = srv.UpdateAPIKey(ctx, &v1.UpdateAPIKeyRequest{
+		ApiKey: key,
+		UpdateMask: &fieldmaskpb.FieldMask{
+			Paths: []string{"allowed_models"},
+		},
+	})
+	assert.NoError(t, err)
+
+	ilresp, err := isrv.ListInternalAPIKeys(ctx, &v1.ListInternalAPIKeysRequest{})
+	assert.NoError(t, err)
+	assert.Len(t, ilresp.ApiKeys, 1)
+	assert.Equal(t, key.AllowedModels, ilresp.ApiKeys[0].ApiKey.AllowedModels)
+
+	// Clear the allowlist.
+	key.AllowedModels = nil
+	_, err = srv.UpdateAPIKey(ctx, &v1.UpdateAPIKeyRequest{
+		ApiKey: key,
+		UpdateMask: &fieldmaskpb.FieldMask{
+			Paths: []string{"allowed_models"},
+		},
+	})
+	assert.NoError(t, err)
+
+	resp, err := srv.ListAPIKeys(ctx, &v1.ListAPIKeysRequest{})
+	assert.NoError(t, err)
+	assert.Len(t, resp.Data, 1)
+	assert.Empty(t, resp.Data[0].AllowedModels)
+
+	key.AllowedModels = []string{"a,b"}
+	_, err = srv.UpdateAPIKey(ctx, &v1.UpdateAPIKeyRequest{
+		ApiKey: key,
+		UpdateMask: &fieldmaskpb.FieldMask{
+			Paths: []string{"allowed_models"},
+		},
+	})
+	assert.Error(t, err)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestAPIKey_AllowedCIDRs(t *testing.T) {
+	st, tearDown := store.NewTest(t)
+	defer tearDown()
+
+	srv := New(st, nil, testr.New(t))
+	isrv := NewInternal(st, nil, testr.New(t))
+
+	ctx := fakeAuthInto(context.Background())
+	org, proj := createOrganizationAndProject(ctx, t, srv)
+
+	key, err := srv.CreateAPIKey(ctx, &v1.CreateAPIKeyRequest{
+		Name:           "dummy",
+		OrganizationId: org.Id,
+		ProjectId:      proj.Id,
+		AllowedCidrs:   []string{"10.1.2.3/8", "2001:db8::/32"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.0/8", "2001:db8::/32"}, key.AllowedCidrs)
+
+	_, err = srv.CreateAPIKey(ctx, &v1.CreateAPIKeyRequest{
+		Name:           "invalid",
+		OrganizationId: org.Id,
+		ProjectId:      proj.Id,
+		AllowedCidrs:   []string{"10.0.0.1"},
+	})
+	assert.Error(t, err)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	key.AllowedCidrs = []string{"192.168.0.0/16"}
+	_, err = srv.UpdateAPIKey(ctx, &v1.UpdateAPIKeyRequest{
+		ApiKey: key,
+		UpdateMask: &fieldmaskpb.FieldMask{
+			Paths: []string{"allowed_cidrs"},
+		},
+	})
+	assert.NoError(t, err)
+
+	ilresp, err := isrv.ListInternalAPIKeys(ctx, &v1.ListInternalAPIKeysRequest{})
+	assert.NoError(t, err)
+	assert.Len(t, ilresp.ApiKeys, 1)
+	assert.Equal(t, []string{"192.168.0.0/16"}, ilresp.ApiKeys[0].ApiKey.AllowedCidrs)
+
+	key.AllowedCidrs = []string{"invalid"}
+	_, err = srv.UpdateAPIKey(ctx, &v1.UpdateAPIKeyRequest{
+		ApiKey: key,
+		UpdateMask: &fieldmaskpb.FieldMask{
+			Paths: []string{"allowed_cidrs"},
+		},
+	})
+	assert.Error(t, err)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestAPIKey_Limits(t *testing.T) {
+	st, tearDown := store.NewTest(t)
+	defer tearDown()
+
+	srv := New(st, nil, testr.New(t))
+	isrv := NewInternal(st, nil, testr.New(t))
+
+	ctx := fakeAuthInto(context.Background())
+	org, proj := createOrganizationAndProject(ctx, t, srv)
+
+	key, err := srv.CreateAPIKey(ctx, &v1.CreateAPIKeyRequest{
+		Name:           "dummy",
+		OrganizationId: org.Id,
+		ProjectId:      proj.Id,
+		Limits: &v1.APIKeyLimits{
+			RequestsPerMinute:  60,
+			TokensPerMinute:    10000,
+			MonthlyTokenBudget: 1000000,
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(60), key.Limits.RequestsPerMinute)
+	assert.Equal(t, int64(10000), key.Limits.TokensPerMinute)
+	assert.Equal(t, int64(1000000), key.Limits.MonthlyTokenBudget)
+
+	// Update only the requests per minute.
+	key.Limits = &v1.APIKeyLimits{
+		RequestsPerMinute: 120,
+	}
+	_, err = srv.UpdateAPIKey(ctx, &v1.UpdateAPIKeyRequest{
+		ApiKey: key,
+		UpdateMask: &fieldmaskpb.FieldMask{
+			Paths: []string{"limits.requests_per_minute"},
+		},
+	})
+	assert.NoError(t, err)
+
+	ilresp, err := isrv.ListInternalAPIKeys(ctx, &v1.ListInternalAPIKeysRequest{})
+	assert.NoError(t, err)
+	assert.Len(t, ilresp.ApiKeys, 1)
+	l := ilresp.ApiKeys[0].ApiKey.Limits
+	assert.Equal(t, int64(120), l.RequestsPerMinute)
+	assert.Equal(t, int64(10000), l.TokensPerMinute)
+	assert.Equal(t, int64(1000000), l.MonthlyTokenBudget)
+
+	// Update all the limits.
+	_, err = srv.UpdateAPIKey(ctx, &v1.UpdateAPIKeyRequest{
+		ApiKey: key,
+		UpdateMask: &fieldmaskpb.FieldMask{
+			Paths: []string{"limits"},
+		},
+	})
+	assert.NoError(t, err)
+
+	resp, err := srv.ListAPIKeys(ctx, &v1.ListAPIKeysRequest{})
+	assert.NoError(t, err)
+	assert.Len(t, resp.Data, 1)
+	l = resp.Data[0].Limits
+	assert.Equal(t, int64(120), l.RequestsPerMinute)
+	assert.Zero(t, l.TokensPerMinute)
+	assert.Zero(t, l.MonthlyTokenBudget)
+
+	key.Limits = &v1.APIKeyLimits{
+		TokensPerMinute: -1,
+	}
+	_, err = srv.UpdateAPIKey(ctx, &v1.UpdateAPIKeyRequest{
+		ApiKey: key,
+		UpdateMask: &fieldmaskpb.FieldMask{
+			Paths: []string{"limits"},
+		},
+	})
+	assert.Error(t, err)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestAPIKey_Expiration(t *testing.T) {
+	st, tearDown := store.NewTest(t)
+	defer tearDown()
+
+	srv := New(st, nil, testr.New(t))
+	isrv := NewInternal(st, nil, testr.New(t))
+
+	ctx := fakeAuthInto(context.Background())
+	org, proj := createOrganizationAndProject(ctx, t, srv)
+
+	now := time.Now()
+
+	key, err := srv.CreateAPIKey(ctx, &v1.CreateAPIKeyRequest{
+		Name:           "ttl",
+		OrganizationId: org.Id,
+		ProjectId:      proj.Id,
+		TtlSeconds:     3600,
+	})
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, key.ExpiresAt, now.Unix()+3600)
+
+	expiresAt := now.Add(time.Hour).Unix()
+	key, err = srv.CreateAPIKey(ctx, &v1.CreateAPIKeyRequest{
+		Name:           "expires-at",
+		OrganizationId: org.Id,
+		ProjectId:      proj.Id,
+		ExpiresAt:      expiresAt,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, expiresAt, key.ExpiresAt)
+
+	key, err = srv.CreateAPIKey(ctx, &v1.CreateAPIKeyRequest{
+		Name:           "no-expiration",
+		OrganizationId: org.Id,
+		ProjectId:      proj.Id,
+	})
+	assert.NoError(t, err)
+	assert.Zero(t, key.ExpiresAt)
+
+	_, err = srv.CreateAPIKey(ctx, &v1.CreateAPIKeyRequest{
+		Name:           "past",
+		OrganizationId: org.Id,
+		ProjectId:      proj.Id,
+		ExpiresAt:      now.Add(-time.Hour).Unix(),
+	})
+	assert.Error(t, err)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = srv.CreateAPIKey(ctx, &v1.CreateAPIKeyRequest{
+		Name:           "both",
+		OrganizationId: org.Id,
+		ProjectId:      proj.Id,
+		ExpiresAt:      expiresAt,
+		TtlSeconds:     3600,
+	})
+	assert.Error(t, err)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// Expire the "ttl" key.
+	k, err := st.GetAPIKeyByNameAndUserID("ttl", defaultUserID)
+	assert.NoError(t, err)
+	err = st.UpdateAPIKey(k.APIKeyID, map[string]interface{}{"expires_at": now.Add(-time.Minute).Unix()})
+	assert.NoError(t, err)
+
+	// The expired key is still visible to the owner.
+	lresp, err := srv.ListAPIKeys(ctx, &v1.ListAPIKeysRequest{})
+	assert.NoError(t, err)
+	assert.Len(t, lresp.Data, 3)
+
+	ilresp, err := isrv.ListInternalAPIKeys(ctx, &v1.ListInternalAPIKeysRequest{})
+	assert.NoError(t, err)
+	var names []string
+	for _, k := range ilresp.ApiKeys {
+		names = append(names, k.ApiKey.Name)
+	}
+	assert.ElementsMatch(t, []string{"expires-at", "no-expiration"}, names)
+}
+
+func TestListInternalAPIKeys_SinceRevision(t *testing.T) {
+	st, tearDown := store.NewTest(t)
+	defer tearDown()
+
+	srv := New(st, nil, testr.New(t))
+	isrv := NewInternal(st, nil, testr.New(t))
+
+	ctx := fakeAuthInto(context.Background())
+	org, proj := createOrganizationAndProject(ctx, t, srv)
+
+	var keys []*v1.APIKey
+	for _, name := range []string{"k0", "k1", "k2"} {
+		k, err := srv.CreateAPIKey(ctx, &v1.CreateAPIKeyRequest{
+			Name:           name,
+			OrganizationId: org.Id,
+			ProjectId:      proj.Id,
+		})
+		assert.NoError(t, err)
+		keys = append(keys, k)
+	}
+
+	resp, err := isrv.ListInternalAPIKeys(ctx, &v1.ListInternalAPIKeysRequest{})
+	assert.NoError(t, err)
+	assert.Len(t, resp.ApiKeys, 3)
+	assert.Empty(t, resp.DeletedApiKeyIds)
+	rev := resp.Revision
+
+	// Update k0, delete k1, and expire k2.
+	keys[0].Name = "k0-renamed"
+	_, err = srv.UpdateAPIKey(ctx, &v1.UpdateAPIKeyRequest{
+		ApiKey: keys[0],
+		UpdateMask: &fieldmaskpb.FieldMask{
+			Paths: []string{"name"},
+		},
+	})
+	assert.NoError(t, err)
+	_, err = srv.DeleteAPIKey(ctx, &v1.DeleteAPIKeyRequest{Id: keys[1].Id})
+	assert.NoError(t, err)
+	k, err := st.GetAPIKeyByID(keys[2].Id)
+	assert.NoError(t, err)
+	err = st.UpdateAPIKey(k.APIKeyID, map[string]interface{}{"expires_at": time.Now().Add(-time.Minute).Unix()})
+	assert.NoError(t, err)
+
+	resp, err = isrv.ListInternalAPIKeys(ctx, &v1.ListInternalAPIKeysRequest{SinceRevision: rev})
+	assert.NoError(t, err)
+	assert.Len(t, resp.ApiKeys, 1)
+	assert.Equal(t, "k0-renamed", resp.ApiKeys[0].ApiKey.Name)
+	assert.Equal(t, keys[0].Secret, resp.ApiKeys[0].ApiKey.Secret)
+	assert.ElementsMatch(t, []string{keys[1].Id, keys[2].Id}, resp.DeletedApiKeyIds)
+	assert.Greater(t, resp.Revision, rev)
+
+	resp, err = isrv.ListInternalAPIKeys(ctx, &v1.ListInternalAPIKeysRequest{SinceRevision: resp.Revision})
+	assert.NoError(t, err)
+	assert.Empty(t, resp.ApiKeys)
+	assert.Empty(t, resp.DeletedApiKeyIds)
+
+	_, err = isrv.ListInternalAPIKeys(ctx, &v1.ListInternalAPIKeysRequest{SinceRevision: -1})
+	assert.Error(t, err)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestAPIKey_Disable(t *testing.T) {
+	st, tearDown := store.NewTest(t)
+	defer tearDown()
+
+	srv := New(st, nil, testr.New(t))
+	isrv := NewInternal(st, nil, testr.New(t))
+
+	ctx := fakeAuthInto(context.Background())
+	org, proj := createOrganizationAndProject(ctx, t, srv)
+
+	key, err := srv.CreateAPIKey(ctx, &v1.CreateAPIKeyRequest{
+		Name:           "k0",
+		OrganizationId: org.Id,
+		ProjectId:      proj.Id,
+	})
+	assert.NoError(t, err)
+	assert.True(t, key.Enabled)
+
+	lresp, err := isrv.ListInternalAPIKeys(ctx, &v1.ListInternalAPIKeysRequest{})
+	assert.NoError(t, err)
+	assert.Len(t, lresp.ApiKeys, 1)
+	rev := lresp.Revision
+
+	key.Enabled = false
+	got, err := srv.UpdateAPIKey(ctx, &v1.UpdateAPIKeyRequest{
+		ApiKey: key,
+		UpdateMask: &fieldmaskpb.FieldMask{
+			Paths: []string{"enabled"},
+		},
+	})
+	assert.NoError(t, err)
+	assert.False(t, got.Enabled)
+
+	// The disabled key is excluded from the internal list and cannot be used.
+	lresp, err = isrv.ListInternalAPIKeys(ctx, &v1.ListInternalAPIKeysRequest{})
+	assert.NoError(t, err)
+	assert.Empty(t, lresp.ApiKeys)
+	lresp, err = isrv.ListInternalAPIKeys(ctx, &v1.ListInternalAPIKeysRequest{SinceRevision: rev})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{key.Id}, lresp.DeletedApiKeyIds)
+
+	_, err = isrv.AuthenticateAPIKey(ctx, &v1.AuthenticateAPIKeyRequest{Secret: key.Secret})
+	assert.Error(t, err)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	// The disabled key is still listed to the owner.
+	resp, err := srv.ListAPIKeys(ctx, &v1.ListAPIKeysRequest{})
+	assert.NoError(t, err)
+	assert.Len(t, resp.Data, 1)
+	assert.False(t, resp.Data[0].Enabled)
+
+	key.Enabled = true
+	got, err = srv.UpdateAPIKey(ctx, &v1.UpdateAPIKeyRequest{
+		ApiKey: key,
+		UpdateMask: &fieldmaskpb.FieldMask{
+			Paths: []string{"enabled"},
+		},
+	})
+	assert.NoError(t, err)
+	assert.True(t, got.Enabled)
+
+	ik, err := isrv.AuthenticateAPIKey(ctx, &v1.AuthenticateAPIKeyRequest{Secret: key.Secret})
+	assert.NoError(t, err)
+	assert.Equal(t, key.Id, ik.ApiKey.Id)
+}
+
+func TestAPIKey_HashSecrets(t *testing.T) {
+	st, tearDown := store.NewTest(t)
+	defer tearDown()
+
+	srv := New(st, nil, testr.New(t))
+	isrv := NewInternal(st, nil, testr.New(t))
+
+	ctx := fakeAuthInto(context.Background())
+	org, proj := createOrganizationAndProject(ctx, t, srv)
+
+	// Create a key before hashing is enabled.
+	oldKey, err := srv.CreateAPIKey(ctx, &v1.CreateAPIKeyRequest{
+		Name:           "old",
+		OrganizationId: org.Id,
+		ProjectId:      proj.Id,
+	})
+	assert.NoError(t, err)
+
+	err = srv.EnableAPIKeySecretHashing(ctx)
+	assert.NoError(t, err)
+
+	k, err := st.GetAPIKeyByID(oldKey.Id)
+	assert.NoError(t, err)
+	assert.Empty(t, k.Secret)
+	assert.Empty(t, k.EncryptedSecret)
+	assert.NotEmpty(t, k.SecretHash)
+
+	newKey, err := srv.CreateAPIKey(ctx, &v1.CreateAPIKeyRequest{
+		Name:           "new",
+		OrganizationId: org.Id,
+		ProjectId:      proj.Id,
+	})
+	assert.NoError(t, err)
+	// The full secret is shown at creation.
+	assert.True(t, strings.HasPrefix(newKey.Secret, "sk-"))
+
+	k, err = st.GetAPIKeyByID(newKey.Id)
+	assert.NoError(t, err)
+	assert.Empty(t, k.Secret)
+	assert.Empty(t, k.EncryptedSecret)
+	assert.NotEmpty(t, k.SecretHash)
+
+	lresp, err := srv.ListAPIKeys(ctx, &v1.ListAPIKeysRequest{})
+	assert.NoError(t, err)
+	assert.Len(t, lresp.Data, 2)
+	for _, k := range lresp.Data {
+		assert.Equal(t, 10, len(k.Secret))
+		assert.True(t, strings.HasSuffix(k.Secret, "*****"))
+	}
+
+	ilresp, err := isrv.ListInternalAPIKeys(ctx, &v1.ListInternalAPIKeysRequest{})
+	assert.NoError(t, err)
+	assert.Len(t, ilresp.ApiKeys, 2)
+	secrets := map[string]string{oldKey.Id: oldKey.Secret, newKey.Id: newKey.Secret}
+	for _, k := range ilresp.ApiKeys {
+		assert.Empty(t, k.ApiKey.Secret)
+		// Consumers can verify secrets with the hashes.
+		assert.True(t, k.SecretHashed)
+		secret := secrets[k.ApiKey.Id]
+		assert.Equal(t, secretPrefix(secret), k.SecretPrefix)
+		assert.Equal(t, hashSecret(secret, k.SecretSalt), k.SecretHash)
+	}
+
+	for _, key := range []*v1.APIKey{oldKey, newKey} {
+		vresp, err := isrv.AuthenticateAPIKey(ctx, &v1.AuthenticateAPIKeyRequest{Secret: key.Secret})
+		assert.NoError(t, err)
+		assert.Equal(t, key.Id, vresp.ApiKey.Id)
+		assert.Equal(t, defaultTenantID, vresp.TenantId)
+		assert.Equal(t, key.Secret[0:5]+"*****", vresp.ApiKey.Secret)
+	}
+
+	_, err = isrv.AuthenticateAPIKey(ctx, &v1.AuthenticateAPIKeyRequest{Secret: newKey.Secret + "x"})
+	assert.Error(t, err)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	// Expired keys cannot be verified.
+	err = st.UpdateAPIKey(k.APIKeyID, map[string]interface{}{"expires_at": time.Now().Add(-time.Minute).Unix()})
+	assert.NoError(t, err)
+	_, err = isrv.AuthenticateAPIKey(ctx, &v1.AuthenticateAPIKeyRequest{Secret: newKey.Secret})
+	assert.Error(t, err)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestAPIKey_Rotate(t *testing.T) {
+	st, tearDown := store.NewTest(t)
+	defer tearDown()
+
+	srv := New(st, nil, testr.New(t))
+	isrv := NewInternal(st, nil, testr.New(t))
+
+	ctx := fakeAuthInto(context.Background())
+	org, proj := createOrganizationAndProject(ctx, t, srv)
+
+	key, err := srv.CreateAPIKey(ctx, &v1.CreateAPIKeyRequest{
+		Name:           "k0",
+		OrganizationId: org.Id,
+		ProjectId:      proj.Id,
+	})
+	assert.NoError(t, err)
+
+	rkey, err := srv.RotateAPIKey(ctx, &v1.RotateAPIKeyRequest{
+		Id:                 key.Id,
+		GracePeriodSeconds: 3600,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, key.Id, rkey.Id)
+	assert.Equal(t, key.Name, rkey.Name)
+	assert.Equal(t, key.ProjectRole, rkey.ProjectRole)
+	assert.NotEqual(t, key.Secret, rkey.Secret)
+	assert.Greater(t, rkey.PreviousSecretExpiresAt, time.Now().Unix())
+
+	// Both the old and the new secrets are valid during the grace period.
+	ilresp, err := isrv.ListInternalAPIKeys(ctx, &v1.ListInternalAPIKeysRequest{})
+	assert.NoError(t, err)
+	var secrets []string
+	for _, k := range ilresp.ApiKeys {
+		assert.Equal(t, key.Id, k.ApiKey.Id)
+		secrets = append(secrets, k.ApiKey.Secret)
+	}
+	assert.ElementsMatch(t, []string{key.Secret, rkey.Secret}, secrets)
+
+	for _, secret := range []string{key.Secret, rkey.Secret} {
+		vresp, err := isrv.AuthenticateAPIKey(ctx, &v1.AuthenticateAPIKeyRequest{Secret: secret})
+		assert.NoError(t, err)
+		assert.Equal(t, key.Id, vresp.ApiKey.Id)
+	}
 
-			lresp, err := srv.ListAPIKeys(ctx, &v1.ListAPIKeysRequest{})
-			assert.NoError(t, err)
-			assert.Len(t, lresp.Data, 2)
+	// Rotate again without a grace period. Only the newest secret is valid.
+	err = srv.EnableAPIKeySecretHashing(ctx)
+	assert.NoError(t, err)
+	rkey2, err := srv.RotateAPIKey(ctx, &v1.RotateAPIKeyRequest{
+		Id: key.Id,
+	})
+	assert.NoError(t, err)
+	assert.Zero(t, rkey2.PreviousSecretExpiresAt)
 
-			// Check that the field value is preserved in list responses
-			for _, key := range lresp.Data {
-				switch key.Name {
-				case "dummy":
-					assert.False(t, key.ExcludedFromRateLimiting, "regular key should have excluded_from_rate_limiting=false")
-				case "excluded-key":
-					assert.True(t, key.ExcludedFromRateLimiting, "excluded key should have excluded_from_rate_limiting=true")
-				}
-			}
+	for _, secret := range []string{key.Secret, rkey.Secret} {
+		_, err = isrv.AuthenticateAPIKey(ctx, &v1.AuthenticateAPIKeyRequest{Secret: secret})
+		assert.Error(t, err)
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	}
+	vresp, err := isrv.AuthenticateAPIKey(ctx, &v1.AuthenticateAPIKeyRequest{Secret: rkey2.Secret})
+	assert.NoError(t, err)
+	assert.Equal(t, key.Id, vresp.ApiKey.Id)
 
-			_, err = srv.DeleteAPIKey(ctx, &v1.DeleteAPIKeyRequest{Id: cresp.Id})
-			assert.NoError(t, err)
-			_, err = srv.DeleteAPIKey(ctx, &v1.DeleteAPIKeyRequest{Id: cresp2.Id})
-			assert.NoError(t, err)
+	_, err = srv.RotateAPIKey(ctx, &v1.RotateAPIKeyRequest{
+		Id: "unknown",
+	})
+	assert.Error(t, err)
+	assert.Equal(t, codes.NotFound, status.Code(err))
 
-			lresp, err = srv.ListAPIKeys(ctx, &v1.ListAPIKeysRequest{})
-			assert.NoError(t, err)
-			assert.Empty(t, lresp.Data)
+	for _, p := range []int64{-1, int64(maxRotationGracePeriod/time.Second) + 1, math.MaxInt64} {
+		_, err = srv.RotateAPIKey(ctx, &v1.RotateAPIKeyRequest{
+			Id:                 key.Id,
+			GracePeriodSeconds: p,
 		})
+		assert.Error(t, err)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
 	}
 }
 
-func TestAPIKey_Update(t *testing.T) {
+func TestAPIKey_ReportUsages(t *testing.T) {
 	st, tearDown := store.NewTest(t)
 	defer tearDown()
 
 	srv := New(st, nil, testr.New(t))
+	isrv := NewInternal(st, nil, testr.New(t))
 
 	ctx := fakeAuthInto(context.Background())
-	org, err := srv.CreateOrganization(ctx, &v1.CreateOrganizationRequest{
-		Title: "Test organization",
+	org, proj := createOrganizationAndProject(ctx, t, srv)
+
+	key, err := srv.CreateAPIKey(ctx, &v1.CreateAPIKeyRequest{
+		Name:           "k0",
+		OrganizationId: org.Id,
+		ProjectId:      proj.Id,
+	})
+	assert.NoError(t, err)
+	assert.Zero(t, key.LastUsedAt)
+
+	_, err = isrv.ReportAPIKeyUsages(ctx, &v1.ReportAPIKeyUsagesRequest{
+		Usages: []*v1.APIKeyUsage{
+			{ApiKeyId: key.Id, UsedAt: 200, SourceIp: "10.0.0.2"},
+			{ApiKeyId: key.Id, UsedAt: 100, SourceIp: "10.0.0.1"},
+			// Usages of unknown keys are ignored.
+			{ApiKeyId: "unknown", UsedAt: 100, SourceIp: "10.0.0.1"},
+		},
 	})
 	assert.NoError(t, err)
 
-	proj, err := srv.CreateProject(ctx, &v1.CreateProjectRequest{
-		Title:               "Test project",
-		OrganizationId:      org.Id,
-		KubernetesNamespace: "test",
+	lresp, err := srv.ListAPIKeys(ctx, &v1.ListAPIKeysRequest{})
+	assert.NoError(t, err)
+	assert.Len(t, lresp.Data, 1)
+	assert.Equal(t, int64(200), lresp.Data[0].LastUsedAt)
+	assert.Equal(t, "10.0.0.2", lresp.Data[0].LastUsedSourceIp)
+
+	// An older usage does not overwrite the newer one.
+	_, err = isrv.ReportAPIKeyUsages(ctx, &v1.ReportAPIKeyUsagesRequest{
+		Usages: []*v1.APIKeyUsage{
+			{ApiKeyId: key.Id, UsedAt: 150, SourceIp: "10.0.0.3"},
+		},
 	})
 	assert.NoError(t, err)
 
-	key, err := srv.CreateAPIKey(ctx, &v1.CreateAPIKeyRequest{
-		Name:           "dummy",
+	plresp, err := srv.ListProjectAPIKeys(ctx, &v1.ListProjectAPIKeysRequest{
 		OrganizationId: org.Id,
 		ProjectId:      proj.Id,
 	})
 	assert.NoError(t, err)
-	assert.Equal(t, "dummy", key.Name)
+	assert.Len(t, plresp.Data, 1)
+	assert.Equal(t, int64(200), plresp.Data[0].LastUsedAt)
+	assert.Equal(t, "10.0.0.2", plresp.Data[0].LastUsedSourceIp)
 
-	key.Name = "dummy2"
-	_, err = srv.UpdateAPIKey(ctx, &v1.UpdateAPIKeyRequest{
-		ApiKey: key,
-		UpdateMask: &fieldmaskpb.FieldMask{
-			Paths: []string{"name"},
+	_, err = isrv.ReportAPIKeyUsages(ctx, &v1.ReportAPIKeyUsagesRequest{
+		Usages: []*v1.APIKeyUsage{
+			{ApiKeyId: key.Id},
 		},
 	})
+	assert.Error(t, err)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestAPIKey_Scopes(t *testing.T) {
+	st, tearDown := store.NewTest(t)
+	defer tearDown()
+
+	srv := New(st, nil, testr.New(t))
+	isrv := NewInternal(st, nil, testr.New(t))
+
+	ctx := fakeAuthInto(context.Background())
+	org, proj := createOrganizationAndProject(ctx, t, srv)
+
+	scopes := []string{"api.models:read", "api.fine_tuning.jobs:write"}
+	key, err := srv.CreateAPIKey(ctx, &v1.CreateAPIKeyRequest{
+		Name:           "scoped",
+		OrganizationId: org.Id,
+		ProjectId:      proj.Id,
+		Scopes:         scopes,
+	})
 	assert.NoError(t, err)
+	assert.Equal(t, scopes, key.Scopes)
 
-	resp, err := srv.ListAPIKeys(ctx, &v1.ListAPIKeysRequest{})
+	_, err = srv.CreateAPIKey(ctx, &v1.CreateAPIKeyRequest{
+		Name:           "unscoped",
+		OrganizationId: org.Id,
+		ProjectId:      proj.Id,
+	})
 	assert.NoError(t, err)
-	assert.Len(t, resp.Data, 1)
-	assert.Equal(t, "dummy2", resp.Data[0].Name)
+
+	ilresp, err := isrv.ListInternalAPIKeys(ctx, &v1.ListInternalAPIKeysRequest{})
+	assert.NoError(t, err)
+	assert.Len(t, ilresp.ApiKeys, 2)
+	for _, k := range ilresp.ApiKeys {
+		if k.ApiKey.Name == "scoped" {
+			assert.Equal(t, scopes, k.ApiKey.Scopes)
+		} else {
+			assert.Empty(t, k.ApiKey.Scopes)
+		}
+	}
+
+	for _, sc := range []string{"api.models", "models:read", "api.models:delete", "api.organizations.project:read"} {
+		_, err = srv.CreateAPIKey(ctx, &v1.CreateAPIKeyRequest{
+			Name:           "invalid",
+			OrganizationId: org.Id,
+			ProjectId:      proj.Id,
+			Scopes:         []string{sc},
+		})
+		assert.Error(t, err)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	}
+}
+
+func TestAuthenticateAPIKey(t *testing.T) {
+	st, tearDown := store.NewTest(t)
+	defer tearDown()
+
+	srv := New(st, nil, testr.New(t))
+	isrv := NewInternal(st, nil, testr.New(t))
+
+	ctx := fakeAuthInto(context.Background())
+	org, proj := createOrganizationAndProject(ctx, t, srv)
+
+	key, err := srv.CreateAPIKey(ctx, &v1.CreateAPIKeyRequest{
+		Name:                     "k0",
+		OrganizationId:           org.Id,
+		ProjectId:                proj.Id,
+		ExcludedFromRateLimiting: true,
+	})
+	assert.NoError(t, err)
+
+	err = srv.CreateDefaultAPIKey(ctx, &config.DefaultAPIKeyConfig{
+		Name:   "default",
+		Secret: "default-secret",
+		UserID: defaultUserID,
+	}, org.Id, proj.Id, defaultTenantID)
+	assert.NoError(t, err)
+
+	u, err := st.GetUserByUserID(defaultUserID)
+	assert.NoError(t, err)
+
+	resp, err := isrv.AuthenticateAPIKey(ctx, &v1.AuthenticateAPIKeyRequest{Secret: key.Secret})
+	assert.NoError(t, err)
+	assert.Equal(t, key.Id, resp.ApiKey.Id)
+	assert.Equal(t, defaultTenantID, resp.TenantId)
+	assert.Equal(t, org.Id, resp.ApiKey.Organization.Id)
+	assert.Equal(t, proj.Id, resp.ApiKey.Project.Id)
+	assert.Equal(t, v1.OrganizationRole_ORGANIZATION_ROLE_OWNER, resp.ApiKey.OrganizationRole)
+	assert.Equal(t, v1.ProjectRole_PROJECT_ROLE_OWNER, resp.ApiKey.ProjectRole)
+	assert.Equal(t, u.InternalUserID, resp.ApiKey.User.InternalId)
+	assert.True(t, resp.ApiKey.ExcludedFromRateLimiting)
+	assert.NotEqual(t, key.Secret, resp.ApiKey.Secret)
+
+	// Secrets not in the structured format are looked up by their prefixes.
+	resp, err = isrv.AuthenticateAPIKey(ctx, &v1.AuthenticateAPIKeyRequest{Secret: "default-secret"})
+	assert.NoError(t, err)
+	assert.Equal(t, "default", resp.ApiKey.Name)
+
+	// Change the checksum.
+	malformed := []byte(key.Secret)
+	malformed[len(malformed)-1]++
+	for _, secret := range []string{"unknown", string(malformed)} {
+		_, err = isrv.AuthenticateAPIKey(ctx, &v1.AuthenticateAPIKeyRequest{Secret: secret})
+		assert.Error(t, err)
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	}
+
+	_, err = isrv.AuthenticateAPIKey(ctx, &v1.AuthenticateAPIKeyRequest{})
+	assert.Error(t, err)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestBackfillAPIKeySecretPrefixes(t *testing.T) {
+	st, tearDown := store.NewTest(t)
+	defer tearDown()
+
+	srv := New(st, nil, testr.New(t))
+	isrv := NewInternal(st, nil, testr.New(t))
+
+	ctx := fakeAuthInto(context.Background())
+	org, proj := createOrganizationAndProject(ctx, t, srv)
+
+	// Create keys without secret prefixes as they were before the prefixes were stored.
+	for _, spec := range []store.APIKeySpec{
+		{Name: "k0", Secret: "sk-legacysecret0"},
+		{Name: "k1", Secret: "sk-legacysecret1"},
+	} {
+		spec.APIKeyID = spec.Name
+		spec.TenantID = defaultTenantID
+		spec.OrganizationID = org.Id
+		spec.ProjectID = proj.Id
+		spec.UserID = defaultUserID
+		_, err := st.CreateAPIKey(spec)
+		assert.NoError(t, err)
+	}
+	// The rotation copies the empty prefix of the old secret.
+	err := st.RotateAPIKeySecret(store.RotateAPIKeySecretParams{
+		APIKeyID:                "k1",
+		Secret:                  "sk-legacysecret2",
+		SecretPrefix:            secretPrefix("sk-legacysecret2"),
+		PreviousSecretExpiresAt: time.Now().Add(time.Hour).Unix(),
+	})
+	assert.NoError(t, err)
+
+	for _, secret := range []string{"sk-legacysecret0", "sk-legacysecret1"} {
+		_, err = isrv.AuthenticateAPIKey(ctx, &v1.AuthenticateAPIKeyRequest{Secret: secret})
+		assert.Error(t, err)
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	}
+
+	err = srv.BackfillAPIKeySecretPrefixes(ctx)
+	assert.NoError(t, err)
+
+	for secret, id := range map[string]string{
+		"sk-legacysecret0": "k0",
+		"sk-legacysecret1": "k1",
+		"sk-legacysecret2": "k1",
+	} {
+		resp, err := isrv.AuthenticateAPIKey(ctx, &v1.AuthenticateAPIKeyRequest{Secret: secret})
+		assert.NoError(t, err)
+		assert.Equal(t, id, resp.ApiKey.Id)
+	}
+
+	// The secrets of the existing keys cannot be imported again.
+	inUse, err := secretInUse(ctx, st, nil, "sk-legacysecret0", time.Now())
+	assert.NoError(t, err)
+	assert.True(t, inUse)
+
+	ks, err := st.ListAPIKeysWithoutSecretPrefix()
+	assert.NoError(t, err)
+	assert.Empty(t, ks)
 }
 
 func TestProjectAPIKey(t *testing.T) {
@@ -208,17 +1165,7 @@ func TestProjectAPIKey(t *testing.T) {
 			isrv := NewInternal(st, dataKey, testr.New(t))
 
 			ctx := fakeAuthInto(context.Background())
-			org, err := srv.CreateOrganization(ctx, &v1.CreateOrganizationRequest{
-				Title: "Test organization",
-			})
-			assert.NoError(t, err)
-
-			proj, err := srv.CreateProject(ctx, &v1.CreateProjectRequest{
-				Title:               "Test project",
-				OrganizationId:      org.Id,
-				KubernetesNamespace: "test",
-			})
-			assert.NoError(t, err)
+			org, proj := createOrganizationAndProject(ctx, t, srv)
 
 			// Test default value for excluded_from_rate_limiting (should be false)
 			cresp, err := srv.CreateProjectAPIKey(ctx, &v1.CreateAPIKeyRequest{
@@ -344,6 +1291,203 @@ func TestProjectAPIKey(t *testing.T) {
 	}
 }
 
+func TestImportAPIKey(t *testing.T) {
+	st, tearDown := store.NewTest(t)
+	defer tearDown()
+
+	srv := New(st, nil, testr.New(t))
+	srv.enableAuth = true
+	srv.hashAPIKeySecrets = true
+	isrv := NewInternal(st, nil, testr.New(t))
+	org := createDefaultOrg(t, srv, "u0")
+
+	// "u0" is an owner of the organization. "u1" is a member of the project.
+	u0Ctx := auth.AppendUserInfoToContext(context.Background(), auth.UserInfo{
+		UserID: "u0",
+	})
+	proj, err := srv.CreateProject(u0Ctx, &v1.CreateProjectRequest{
+		Title:               "title",
+		OrganizationId:      org.OrganizationID,
+		KubernetesNamespace: "n0",
+	})
+	assert.NoError(t, err)
+
+	u1Ctx := auth.AppendUserInfoToContext(context.Background(), auth.UserInfo{
+		UserID: "u1",
+	})
+	_, err = srv.CreateOrganizationUser(u0Ctx, &v1.CreateOrganizationUserRequest{
+		OrganizationId: org.OrganizationID,
+		UserId:         "u1",
+		Role:           v1.OrganizationRole_ORGANIZATION_ROLE_READER,
+	})
+	assert.NoError(t, err)
+	_, err = srv.CreateProjectUser(u0Ctx, &v1.CreateProjectUserRequest{
+		OrganizationId: org.OrganizationID,
+		ProjectId:      proj.Id,
+		UserId:         "u1",
+		Role:           v1.ProjectRole_PROJECT_ROLE_MEMBER,
+	})
+	assert.NoError(t, err)
+
+	const secret = "sk-9fQ2xLr7TzK8vWm3NpB6yHc1JdG5sAe4RuX0kVbYtZqPoLiM"
+	newReq := func(name, secret string) *v1.ImportAPIKeyRequest {
+		return &v1.ImportAPIKeyRequest{
+			ApiKey: &v1.CreateAPIKeyRequest{
+				Name:           name,
+				OrganizationId: org.OrganizationID,
+				ProjectId:      proj.Id,
+			},
+			Secret: secret,
+			Source: "litellm",
+		}
+	}
+
+	_, err = srv.ImportAPIKey(u1Ctx, newReq("k0", secret))
+	assert.Error(t, err)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	for _, s := range []string{
+		"",
+		"sk-aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+		"sk-abc123",
+		"sk-abcdefghijklmnopqrstuvwxyz",
+		"sk-0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ",
+		"sk-" + strings.Repeat("q7Xz", 20),
+		"pk-9fQ2xLr7TzK8vWm3NpB6yHc1JdG5sAe4RuX0kVbYtZqPoLiM",
+	} {
+		_, err = srv.ImportAPIKey(u0Ctx, newReq("k0", s))
+		assert.Error(t, err)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err), s)
+	}
+	// A secret in the version 1 format embeds the ID of another API key.
+	v1Secret, err := apikey.Generate("key_0123456789abcdef", defaultTenantID)
+	assert.NoError(t, err)
+	_, err = srv.ImportAPIKey(u0Ctx, newReq("k0", v1Secret))
+	assert.Error(t, err)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	key, err := srv.ImportAPIKey(u0Ctx, newReq("k0", secret))
+	assert.NoError(t, err)
+	assert.Equal(t, "k0", key.Name)
+	assert.Equal(t, "litellm", key.ImportedFrom)
+	assert.Equal(t, "u0", key.ImportedBy)
+	assert.NotEqual(t, secret, key.Secret)
+
+	ik, err := isrv.AuthenticateAPIKey(context.Background(), &v1.AuthenticateAPIKeyRequest{Secret: secret})
+	assert.NoError(t, err)
+	assert.Equal(t, key.Id, ik.ApiKey.Id)
+
+	// The secret must be unique.
+	_, err = srv.ImportAPIKey(u0Ctx, newReq("k1", secret))
+	assert.Error(t, err)
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+}
+
+func TestTransferAPIKey(t *testing.T) {
+	st, tearDown := store.NewTest(t)
+	defer tearDown()
+
+	srv := New(st, nil, testr.New(t))
+	srv.enableAuth = true
+	isrv := NewInternal(st, nil, testr.New(t))
+	org := createDefaultOrg(t, srv, "u0")
+
+	// "u0" is an owner of the organization and the project.
+	u0Ctx := auth.AppendUserInfoToContext(context.Background(), auth.UserInfo{
+		UserID: "u0",
+	})
+	proj, err := srv.CreateProject(u0Ctx, &v1.CreateProjectRequest{
+		Title:               "title",
+		OrganizationId:      org.OrganizationID,
+		KubernetesNamespace: "n0",
+	})
+	assert.NoError(t, err)
+
+	// "u1" is a member of the project, but not the owner. "u2" is not a member of the project.
+	u1Ctx := auth.AppendUserInfoToContext(context.Background(), auth.UserInfo{
+		UserID: "u1",
+	})
+	_, err = srv.CreateOrganizationUser(u0Ctx, &v1.CreateOrganizationUserRequest{
+		OrganizationId: org.OrganizationID,
+		UserId:         "u1",
+		Role:           v1.OrganizationRole_ORGANIZATION_ROLE_READER,
+	})
+	assert.NoError(t, err)
+	_, err = srv.CreateProjectUser(u0Ctx, &v1.CreateProjectUserRequest{
+		OrganizationId: org.OrganizationID,
+		ProjectId:      proj.Id,
+		UserId:         "u1",
+		Role:           v1.ProjectRole_PROJECT_ROLE_MEMBER,
+	})
+	assert.NoError(t, err)
+
+	key, err := srv.CreateProjectAPIKey(u1Ctx, &v1.CreateAPIKeyRequest{
+		Name:           "k0",
+		OrganizationId: org.OrganizationID,
+		ProjectId:      proj.Id,
+	})
+	assert.NoError(t, err)
+
+	// A project member cannot transfer API keys even if it owns them.
+	_, err = srv.TransferAPIKey(u1Ctx, &v1.TransferAPIKeyRequest{Id: key.Id, UserId: "u0"})
+	assert.Error(t, err)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = srv.TransferAPIKey(u0Ctx, &v1.TransferAPIKeyRequest{Id: key.Id, UserId: "u2"})
+	assert.Error(t, err)
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	_, err = srv.TransferAPIKey(u0Ctx, &v1.TransferAPIKeyRequest{Id: key.Id, UserId: "u1"})
+	assert.Error(t, err)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	resp, err := srv.TransferAPIKey(u0Ctx, &v1.TransferAPIKeyRequest{Id: key.Id, UserId: "u0"})
+	assert.NoError(t, err)
+	assert.Equal(t, key.Id, resp.ApiKey.Id)
+	assert.Equal(t, "u0", resp.ApiKey.User.Id)
+	assert.Equal(t, "u1", resp.PreviousUserId)
+
+	// The secret is kept.
+	ik, err := isrv.AuthenticateAPIKey(context.Background(), &v1.AuthenticateAPIKeyRequest{Secret: key.Secret})
+	assert.NoError(t, err)
+	assert.Equal(t, "u0", ik.ApiKey.User.Id)
+
+	// Transfer the API key to a service account.
+	saKey, err := srv.CreateProjectAPIKey(u0Ctx, &v1.CreateAPIKeyRequest{
+		Name:             "sa",
+		OrganizationId:   org.OrganizationID,
+		ProjectId:        proj.Id,
+		IsServiceAccount: true,
+		Role:             v1.OrganizationRole_ORGANIZATION_ROLE_READER,
+	})
+	assert.NoError(t, err)
+	resp, err = srv.TransferAPIKey(u0Ctx, &v1.TransferAPIKeyRequest{Id: key.Id, UserId: saKey.User.Id})
+	assert.NoError(t, err)
+	assert.True(t, resp.ApiKey.User.IsServiceAccount)
+
+	// The service account is kept when its original API key is deleted.
+	_, err = srv.DeleteProjectAPIKey(u0Ctx, &v1.DeleteProjectAPIKeyRequest{
+		OrganizationId: org.OrganizationID,
+		ProjectId:      proj.Id,
+		Id:             saKey.Id,
+	})
+	assert.NoError(t, err)
+	_, err = st.GetProjectUser(proj.Id, saKey.User.Id)
+	assert.NoError(t, err)
+	_, err = isrv.AuthenticateAPIKey(context.Background(), &v1.AuthenticateAPIKeyRequest{Secret: key.Secret})
+	assert.NoError(t, err)
+
+	// The service account is deleted when its last API key is transferred away.
+	_, err = srv.TransferAPIKey(u0Ctx, &v1.TransferAPIKeyRequest{Id: key.Id, UserId: "u0"})
+	assert.NoError(t, err)
+	_, err = st.GetProjectUser(proj.Id, saKey.User.Id)
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+	_, err = st.GetOrganizationUser(org.OrganizationID, saKey.User.Id)
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+	_, err = st.GetUserByUserID(saKey.User.Id)
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+}
+
 func TestAPIKey_EnableAuth(t *testing.T) {
 	st, tearDown := store.NewTest(t)
 	defer tearDown()
@@ -410,7 +1554,7 @@ func TestAPIKey_EnableAuth(t *testing.T) {
 		OrganizationId:   org.OrganizationID,
 		ProjectId:        proj.Id,
 		IsServiceAccount: true,
-		Role:             v1.OrganizationRole_ORGANIZATION_ROLE_TENANT_SYSTEM,
+		Role:             v1.OrganizationRole_ORGANIZATION_ROLE_READER,
 	}
 	key3, err := srv.CreateProjectAPIKey(u0Ctx, saKeyReq)
 	assert.NoError(t, err)
@@ -448,6 +1592,19 @@ func TestAPIKey_EnableAuth(t *testing.T) {
 	assert.Len(t, resp.Data, 1)
 	assert.Equal(t, "u2", resp.Data[0].User.Id)
 
+	resp, err = srv.ListAPIKeys(u0Ctx, &v1.ListAPIKeysRequest{})
+	assert.NoError(t, err)
+	assert.Len(t, resp.Data, 3)
+
+	resp, err = srv.ListAPIKeys(u1Ctx, &v1.ListAPIKeysRequest{})
+	assert.NoError(t, err)
+	assert.Empty(t, resp.Data)
+
+	resp, err = srv.ListAPIKeys(u2Ctx, &v1.ListAPIKeysRequest{})
+	assert.NoError(t, err)
+	assert.Len(t, resp.Data, 1)
+	assert.Equal(t, "u2", resp.Data[0].User.Id)
+
 	// Delete API keys.
 
 	// "u2" cannot delete the API key.
@@ -1,14 +1,30 @@
 package server
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net"
+	"time"
 
 	"github.com/go-logr/logr"
 	v1 "github.com/llmariner/user-manager/api/v1"
 	"github.com/llmariner/user-manager/server/internal/store"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+const (
+	// changeSettleWindow is the maximum duration of a transaction that records a change.
+	// A change that becomes visible later than this might not be delivered to clients.
+	changeSettleWindow = 10 * time.Second
+	// changeRetention is the period for which changes are kept. Clients that have not
+	// synced for longer than this must resync.
+	changeRetention     = 7 * 24 * time.Hour
+	changePruneInterval = time.Hour
 )
 
 // NewInternal creates an internal server.
@@ -18,9 +34,10 @@ func NewInternal(
 	log logr.Logger,
 ) *IS {
 	return &IS{
-		dataKey: dataKey,
-		store:   store,
-		log:     log.WithName("internal"),
+		dataKey:           dataKey,
+		store:             store,
+		log:               log.WithName("internal"),
+		watchPollInterval: defaultWatchPollInterval,
 	}
 }
 
@@ -32,6 +49,9 @@ type IS struct {
 	dataKey []byte
 	store   *store.S
 	log     logr.Logger
+
+	// watchPollInterval is the interval at which watchers poll the change log.
+	watchPollInterval time.Duration
 }
 
 // Run starts the gRPC server.
@@ -58,3 +78,95 @@ func (s *IS) Run(port int) error {
 func (s *IS) GracefulStop() {
 	s.srv.GracefulStop()
 }
+
+// latestRevision validates sinceRevision of a list request and returns the latest revision up to which
+// all changes are visible.
+func (s *IS) latestRevision(sinceRevision int64) (int64, error) {
+	if sinceRevision < 0 {
+		return 0, status.Errorf(codes.InvalidArgument, "since_revision must be non-negative")
+	}
+	rev, err := s.store.GetStableRevision(time.Now(), changeSettleWindow)
+	if err != nil {
+		return 0, status.Errorf(codes.Internal, "get latest revision: %s", err)
+	}
+	latest, err := s.store.GetLatestRevision()
+	if err != nil {
+		return 0, status.Errorf(codes.Internal, "get latest revision: %s", err)
+	}
+	if sinceRevision > latest {
+		return 0, status.Errorf(codes.FailedPrecondition, "since_revision %d is newer than the latest revision %d", sinceRevision, latest)
+	}
+	if _, err := s.sinceRevisionChange(sinceRevision); err != nil {
+		return 0, err
+	}
+	return rev, nil
+}
+
+// sinceRevisionChange returns the change of sinceRevision. It returns nil if sinceRevision is zero.
+//
+// A revision can be missing from the change log as it has been rolled back or pruned. In that case,
+// the latest change before the revision is returned instead, or an empty change if there is no such
+// change. OutOfRange is returned only if the changes after the revision have been pruned, in which
+// case the client must resync with since_revision set to zero.
+func (s *IS) sinceRevisionChange(sinceRevision int64) (*store.Change, error) {
+	if sinceRevision == 0 {
+		return nil, nil
+	}
+	oldest, err := s.store.GetOldestRevision()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "get oldest revision: %s", err)
+	}
+	if sinceRevision < oldest-1 {
+		return nil, status.Errorf(codes.OutOfRange, "since_revision %d is too old; resync with since_revision 0", sinceRevision)
+	}
+	c, err := s.store.GetLatestChangeAtOrBefore(sinceRevision)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// The change of the revision and all the changes before it have been pruned. Return an
+			// empty change so that the callers treat all the expirations as new.
+			return &store.Change{}, nil
+		}
+		return nil, status.Errorf(codes.Internal, "get change: %s", err)
+	}
+	return c, nil
+}
+
+// RunChangePruner periodically deletes the changes older than the retention period until the context is canceled.
+func (s *IS) RunChangePruner(ctx context.Context) {
+	ticker := time.NewTicker(changePruneInterval)
+	defer ticker.Stop()
+	for {
+		n, err := s.store.DeleteChangesBefore(time.Now().Add(-changeRetention))
+		if err != nil {
+			s.log.Error(err, "Failed to prune changes")
+		} else if n > 0 {
+			s.log.Info("Pruned changes", "count", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// listChangedResourceIDs returns the IDs of the resources of the given kind that have changed after sinceRevision.
+func (s *IS) listChangedResourceIDs(kind string, sinceRevision int64) ([]string, error) {
+	ids, err := s.store.ListChangedResourceIDs(kind, sinceRevision)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "list changes: %s", err)
+	}
+	return ids, nil
+}
+
+// tombstones returns the IDs of the changed resources that no longer exist.
+func tombstones(changedIDs []string, found map[string]bool) []string {
+	var ids []string
+	for _, id := range changedIDs {
+		if !found[id] {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,433 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"time"
+
+	"github.com/llmariner/common/pkg/id"
+	"github.com/llmariner/rbac-manager/pkg/auth"
+	v1 "github.com/llmariner/user-manager/api/v1"
+	"github.com/llmariner/user-manager/pkg/serviceaccount"
+	"github.com/llmariner/user-manager/pkg/userid"
+	"github.com/llmariner/user-manager/server/internal/invitation"
+	"github.com/llmariner/user-manager/server/internal/store"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultInvitationTTL = 7 * 24 * time.Hour
+	maxInvitationTTL     = 30 * 24 * time.Hour
+)
+
+// CreateInvitation invites a user to an organization. The user becomes a member
+// of the organization when the invitation is accepted.
+func (s *S) CreateInvitation(ctx context.Context, req *v1.CreateInvitationRequest) (*v1.Invitation, error) {
+	userInfo, ok := auth.ExtractUserInfoFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("failed to extract user info from context")
+	}
+
+	if req.OrganizationId == "" {
+		return nil, status.Error(codes.InvalidArgument, "organization id is required")
+	}
+	if req.UserId == "" {
+		return nil, status.Error(codes.InvalidArgument, "user id is required")
+	}
+	if serviceaccount.IsServiceAccount(req.UserId) {
+		return nil, status.Error(codes.InvalidArgument, "service accounts cannot be invited")
+	}
+	if addr, err := mail.ParseAddress(req.UserId); err != nil || addr.Address != req.UserId {
+		return nil, status.Errorf(codes.InvalidArgument, "user id %q must be an email address", req.UserId)
+	}
+	if req.Role == v1.OrganizationRole_ORGANIZATION_ROLE_UNSPECIFIED {
+		return nil, status.Error(codes.InvalidArgument, "role is required")
+	}
+	if err := validateAssignableOrganizationRole(req.Role); err != nil {
+		return nil, err
+	}
+	if req.TtlSeconds < 0 {
+		return nil, status.Error(codes.InvalidArgument, "ttl seconds must not be negative")
+	}
+	ttl := defaultInvitationTTL
+	if req.TtlSeconds > 0 {
+		ttl = time.Duration(req.TtlSeconds) * time.Second
+	}
+	if ttl > maxInvitationTTL {
+		return nil, status.Errorf(codes.InvalidArgument, "ttl must not exceed %s", maxInvitationTTL)
+	}
+
+	org, err := validateOrganizationID(s.store, req.OrganizationId, userInfo.TenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.validateOrganizationOwner(req.OrganizationId, userInfo.UserID); err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	for _, pb := range req.ProjectBindings {
+		if pb.ProjectId == "" {
+			return nil, status.Error(codes.InvalidArgument, "project id is required")
+		}
+		if pb.Role == v1.ProjectRole_PROJECT_ROLE_UNSPECIFIED {
+			return nil, status.Error(codes.InvalidArgument, "project role is required")
+		}
+		if seen[pb.ProjectId] {
+			return nil, status.Errorf(codes.InvalidArgument, "duplicate project %q", pb.ProjectId)
+		}
+		seen[pb.ProjectId] = true
+
+		if _, err := validateProjectID(s.store, pb.ProjectId, req.OrganizationId, userInfo.TenantID); err != nil {
+			return nil, err
+		}
+	}
+
+	userID := userid.Normalize(req.UserId)
+	role, err := findOrgRole(s.store, req.OrganizationId, userID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "find organization role: %s", err)
+	}
+	if role != v1.OrganizationRole_ORGANIZATION_ROLE_UNSPECIFIED {
+		return nil, status.Errorf(codes.AlreadyExists, "user %q is already a member of organization %q", userID, req.OrganizationId)
+	}
+
+	now := time.Now()
+	pending, err := s.store.ListPendingInvitationsByUserID(userInfo.TenantID, userID, now)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "list pending invitations: %s", err)
+	}
+	for _, inv := range pending {
+		if inv.OrganizationID == req.OrganizationId {
+			return nil, status.Errorf(codes.AlreadyExists, "user %q already has a pending invitation to organization %q", userID, req.OrganizationId)
+		}
+	}
+
+	invitationID, err := id.GenerateID("inv_", 24)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "generate invitation id: %s", err)
+	}
+	expiresAt := now.Add(ttl)
+	inv, err := s.store.CreateInvitation(store.CreateInvitationParams{
+		InvitationID:    invitationID,
+		TenantID:        userInfo.TenantID,
+		OrganizationID:  req.OrganizationId,
+		UserID:          userID,
+		Role:            req.Role,
+		ProjectBindings: req.ProjectBindings,
+		InviterUserID:   userInfo.UserID,
+		ExpiresAt:       expiresAt.Unix(),
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "create invitation: %s", err)
+	}
+
+	// The invitation is still visible to the invitee even if the delivery fails, so do not return an error here.
+	if err := s.invitationSender.Send(ctx, &invitation.Message{
+		InvitationID:      invitationID,
+		Email:             userID,
+		OrganizationTitle: org.Title,
+		InviterUserID:     userInfo.UserID,
+		ExpiresAt:         expiresAt,
+	}); err != nil {
+		s.log.Error(err, "Failed to send invitation", "invitationID", invitationID)
+	}
+
+	return toInvitationProto(inv, now)
+}
+
+// ListInvitations lists the pending invitations for the caller.
+func (s *S) ListInvitations(ctx context.Context, req *v1.ListInvitationsRequest) (*v1.ListInvitationsResponse, error) {
+	userInfo, ok := auth.ExtractUserInfoFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("failed to extract user info from context")
+	}
+
+	now := time.Now()
+	invs, err := s.store.ListPendingInvitationsByUserID(userInfo.TenantID, userid.Normalize(userInfo.UserID), now)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "list pending invitations: %s", err)
+	}
+	return toListInvitationsResponse(invs, now)
+}
+
+// ListOrganizationInvitations lists the invitations of an organization. Only owners of the organization can list them.
+func (s *S) ListOrganizationInvitations(ctx context.Context, req *v1.ListOrganizationInvitationsRequest) (*v1.ListInvitationsResponse, error) {
+	userInfo, ok := auth.ExtractUserInfoFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("failed to extract user info from context")
+	}
+
+	if req.OrganizationId == "" {
+		return nil, status.Error(codes.InvalidArgument, "organization id is required")
+	}
+	if _, err := validateOrganizationID(s.store, req.OrganizationId, userInfo.TenantID); err != nil {
+		return nil, err
+	}
+	if err := s.validateOrganizationOwner(req.OrganizationId, userInfo.UserID); err != nil {
+		return nil, err
+	}
+
+	invs, err := s.store.ListInvitationsByOrganizationID(req.OrganizationId)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "list invitations: %s", err)
+	}
+	return toListInvitationsResponse(invs, time.Now())
+}
+
+func toListInvitationsResponse(invs []*store.Invitation, now time.Time) (*v1.ListInvitationsResponse, error) {
+	var invProtos []*v1.Invitation
+	for _, inv := range invs {
+		invProto, err := toInvitationProto(inv, now)
+		if err != nil {
+			return nil, err
+		}
+		invProtos = append(invProtos, invProto)
+	}
+	return &v1.ListInvitationsResponse{
+		Invitations: invProtos,
+	}, nil
+}
+
+// AcceptInvitation accepts an invitation and adds the caller to the organization and the projects.
+func (s *S) AcceptInvitation(ctx context.Context, req *v1.AcceptInvitationRequest) (*v1.Invitation, error) {
+	userInfo, ok := auth.ExtractUserInfoFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("failed to extract user info from context")
+	}
+
+	if req.Id == "" {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+
+	inv, err := s.getInvitationForInvitee(req.Id, userInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if !inv.IsPending(now) {
+		return nil, status.Errorf(codes.FailedPrecondition, "invitation %q is no longer pending", req.Id)
+	}
+
+	if err := s.acceptInvitation(inv); err != nil {
+		return nil, err
+	}
+
+	return s.getInvitationProto(req.Id, now)
+}
+
+// DeclineInvitation declines an invitation.
+func (s *S) DeclineInvitation(ctx context.Context, req *v1.DeclineInvitationRequest) (*v1.Invitation, error) {
+	userInfo, ok := auth.ExtractUserInfoFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("failed to extract user info from context")
+	}
+
+	if req.Id == "" {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+
+	inv, err := s.getInvitationForInvitee(req.Id, userInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if err := s.updateInvitationState(inv, now, v1.InvitationState_INVITATION_STATE_DECLINED); err != nil {
+		return nil, err
+	}
+	return s.getInvitationProto(req.Id, now)
+}
+
+// RevokeInvitation revokes a pending invitation.
+func (s *S) RevokeInvitation(ctx context.Context, req *v1.RevokeInvitationRequest) (*v1.Invitation, error) {
+	userInfo, ok := auth.ExtractUserInfoFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("failed to extract user info from context")
+	}
+
+	if req.OrganizationId == "" {
+		return nil, status.Error(codes.InvalidArgument, "organization id is required")
+	}
+	if req.Id == "" {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+
+	if _, err := validateOrganizationID(s.store, req.OrganizationId, userInfo.TenantID); err != nil {
+		return nil, err
+	}
+	if err := s.validateOrganizationOwner(req.OrganizationId, userInfo.UserID); err != nil {
+		return nil, err
+	}
+
+	inv, err := s.store.GetInvitation(req.Id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.NotFound, "invitation %q not found", req.Id)
+		}
+		return nil, status.Errorf(codes.Internal, "get invitation: %s", err)
+	}
+	if inv.OrganizationID != req.OrganizationId {
+		return nil, status.Errorf(codes.NotFound, "invitation %q not found", req.Id)
+	}
+
+	now := time.Now()
+	if err := s.updateInvitationState(inv, now, v1.InvitationState_INVITATION_STATE_REVOKED); err != nil {
+		return nil, err
+	}
+	return s.getInvitationProto(req.Id, now)
+}
+
+// acceptPendingInvitations accepts all the pending invitations for the user.
+// Failures are logged so that they do not block the caller.
+func (s *S) acceptPendingInvitations(userInfo *auth.UserInfo) {
+	invs, err := s.store.ListPendingInvitationsByUserID(userInfo.TenantID, userid.Normalize(userInfo.UserID), time.Now())
+	if err != nil {
+		s.log.Error(err, "Failed to list pending invitations", "userID", userInfo.UserID)
+		return
+	}
+	for _, inv := range invs {
+		if err := s.acceptInvitation(inv); err != nil {
+			s.log.Error(err, "Failed to accept invitation", "invitationID", inv.InvitationID)
+		}
+	}
+}
+
+// acceptInvitation marks the invitation as accepted and creates the organization user and the project users
+// in the same transaction.
+//
+// The existing roles of the user are kept as they are.
+func (s *S) acceptInvitation(inv *store.Invitation) error {
+	if _, err := validateOrganizationID(s.store, inv.OrganizationID, inv.TenantID); err != nil {
+		return err
+	}
+
+	pbs, err := inv.GetProjectBindings()
+	if err != nil {
+		return status.Errorf(codes.Internal, "get project bindings: %s", err)
+	}
+
+	orgRole, err := findOrgRole(s.store, inv.OrganizationID, inv.UserID)
+	if err != nil {
+		return status.Errorf(codes.Internal, "find organization role: %s", err)
+	}
+	isNewMember := orgRole == v1.OrganizationRole_ORGANIZATION_ROLE_UNSPECIFIED
+	role := v1.OrganizationRole(v1.OrganizationRole_value[inv.Role])
+
+	projects, err := s.store.ListProjectsByTenantIDAndOrganizationID(inv.TenantID, inv.OrganizationID)
+	if err != nil {
+		return status.Errorf(codes.Internal, "list projects: %s", err)
+	}
+	projectsByID := map[string]*store.Project{}
+	for _, p := range projects {
+		projectsByID[p.ProjectID] = p
+	}
+
+	// Skip the projects that have been deleted since the invitation was created
+	// and the projects that the user already belongs to.
+	var projectUsers []store.CreateProjectUserParams
+	for _, pb := range pbs {
+		p, ok := projectsByID[pb.ProjectId]
+		if !ok {
+			continue
+		}
+		projectRole, err := findProjectRole(s.store, pb.ProjectId, inv.UserID)
+		if err != nil {
+			return status.Errorf(codes.Internal, "find project role: %s", err)
+		}
+		if projectRole != v1.ProjectRole_PROJECT_ROLE_UNSPECIFIED {
+			continue
+		}
+		projectUsers = append(projectUsers, store.CreateProjectUserParams{
+			ProjectID:      p.ProjectID,
+			OrganizationID: p.OrganizationID,
+			UserID:         inv.UserID,
+			Role:           pb.Role,
+		})
+	}
+
+	if err := s.store.Transaction(func(tx *gorm.DB) error {
+		if err := store.UpdateInvitationStateInTransaction(tx, inv.InvitationID, v1.InvitationState_INVITATION_STATE_ACCEPTED); err != nil {
+			return err
+		}
+
+		if isNewMember {
+			if _, err := findOrCreateUserInTransaction(tx, inv.UserID); err != nil {
+				return fmt.Errorf("create new user: %s", err)
+			}
+			if _, err := store.CreateOrganizationUserInTransaction(tx, inv.OrganizationID, inv.UserID, inv.Role); err != nil {
+				return fmt.Errorf("create organization user: %s", err)
+			}
+			// An owner of the organization is also an owner of all its projects.
+			if role == v1.OrganizationRole_ORGANIZATION_ROLE_OWNER {
+				return addProjectOwnerInTransaction(tx, projects, inv.UserID)
+			}
+		}
+
+		for _, p := range projectUsers {
+			if _, err := store.CreateProjectUserInTransaction(tx, p); err != nil {
+				return fmt.Errorf("create project user: %s", err)
+			}
+		}
+		return nil
+	}); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return status.Errorf(codes.FailedPrecondition, "invitation %q is no longer pending", inv.InvitationID)
+		}
+		return status.Errorf(codes.Internal, "accept invitation: %s", err)
+	}
+	return nil
+}
+
+// getInvitationForInvitee gets the invitation. NotFound is returned if the user is not the invitee
+// so that the existence of other users' invitations is not revealed.
+func (s *S) getInvitationForInvitee(id string, userInfo *auth.UserInfo) (*store.Invitation, error) {
+	inv, err := s.store.GetInvitation(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.NotFound, "invitation %q not found", id)
+		}
+		return nil, status.Errorf(codes.Internal, "get invitation: %s", err)
+	}
+	if inv.TenantID != userInfo.TenantID || inv.UserID != userid.Normalize(userInfo.UserID) {
+		return nil, status.Errorf(codes.NotFound, "invitation %q not found", id)
+	}
+	return inv, nil
+}
+
+func (s *S) updateInvitationState(inv *store.Invitation, now time.Time, state v1.InvitationState) error {
+	if !inv.IsPending(now) {
+		return status.Errorf(codes.FailedPrecondition, "invitation %q is no longer pending", inv.InvitationID)
+	}
+	if err := s.store.Transaction(func(tx *gorm.DB) error {
+		return store.UpdateInvitationStateInTransaction(tx, inv.InvitationID, state)
+	}); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return status.Errorf(codes.FailedPrecondition, "invitation %q is no longer pending", inv.InvitationID)
+		}
+		return status.Errorf(codes.Internal, "update invitation: %s", err)
+	}
+	return nil
+}
+
+func (s *S) getInvitationProto(id string, now time.Time) (*v1.Invitation, error) {
+	inv, err := s.store.GetInvitation(id)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "get invitation: %s", err)
+	}
+	return toInvitationProto(inv, now)
+}
+
+func toInvitationProto(inv *store.Invitation, now time.Time) (*v1.Invitation, error) {
+	invProto, err := inv.ToProto(now)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "convert invitation to proto: %s", err)
+	}
+	return invProto, nil
+}
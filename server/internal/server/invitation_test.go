@@ -0,0 +1,260 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/llmariner/rbac-manager/pkg/auth"
+	v1 "github.com/llmariner/user-manager/api/v1"
+	"github.com/llmariner/user-manager/server/internal/invitation"
+	"github.com/llmariner/user-manager/server/internal/store"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type fakeInvitationSender struct {
+	msgs []*invitation.Message
+}
+
+func (s *fakeInvitationSender) Send(ctx context.Context, m *invitation.Message) error {
+	s.msgs = append(s.msgs, m)
+	return nil
+}
+
+func TestInvitation_EnableAuth(t *testing.T) {
+	st, tearDown := store.NewTest(t)
+	defer tearDown()
+
+	srv := New(st, nil, testr.New(t))
+	srv.enableAuth = true
+	sender := &fakeInvitationSender{}
+	srv.SetInvitationSender(sender)
+
+	o := createDefaultOrg(t, srv, "u0")
+
+	u0Ctx := auth.AppendUserInfoToContext(context.Background(), auth.UserInfo{
+		UserID: "u0",
+	})
+	var projectIDs []string
+	for _, title := range []string{"p0", "p1"} {
+		p, err := srv.CreateProject(u0Ctx, &v1.CreateProjectRequest{
+			Title:               title,
+			OrganizationId:      o.OrganizationID,
+			KubernetesNamespace: title,
+		})
+		assert.NoError(t, err)
+		projectIDs = append(projectIDs, p.Id)
+	}
+
+	// Invalid requests.
+	for _, req := range []*v1.CreateInvitationRequest{
+		{OrganizationId: o.OrganizationID, UserId: "bob", Role: v1.OrganizationRole_ORGANIZATION_ROLE_READER},
+		{OrganizationId: o.OrganizationID, UserId: "Bob <bob@example.com>", Role: v1.OrganizationRole_ORGANIZATION_ROLE_READER},
+		{OrganizationId: o.OrganizationID, UserId: "bob@example.com"},
+		{OrganizationId: o.OrganizationID, UserId: "bob@example.com", Role: v1.OrganizationRole_ORGANIZATION_ROLE_TENANT_SYSTEM},
+		{OrganizationId: o.OrganizationID, UserId: "bob@example.com", Role: v1.OrganizationRole_ORGANIZATION_ROLE_READER, TtlSeconds: -1},
+		{OrganizationId: o.OrganizationID, UserId: "bob@example.com", Role: v1.OrganizationRole_ORGANIZATION_ROLE_READER, TtlSeconds: int64(maxInvitationTTL/time.Second) + 1},
+		{
+			OrganizationId: o.OrganizationID,
+			UserId:         "bob@example.com",
+			Role:           v1.OrganizationRole_ORGANIZATION_ROLE_READER,
+			ProjectBindings: []*v1.Invitation_ProjectBinding{
+				{ProjectId: projectIDs[0], Role: v1.ProjectRole_PROJECT_ROLE_MEMBER},
+				{ProjectId: projectIDs[0], Role: v1.ProjectRole_PROJECT_ROLE_OWNER},
+			},
+		},
+	} {
+		_, err := srv.CreateInvitation(u0Ctx, req)
+		assert.Error(t, err)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	}
+
+	inv, err := srv.CreateInvitation(u0Ctx, &v1.CreateInvitationRequest{
+		OrganizationId: o.OrganizationID,
+		UserId:         "Bob@example.com",
+		Role:           v1.OrganizationRole_ORGANIZATION_ROLE_READER,
+		ProjectBindings: []*v1.Invitation_ProjectBinding{
+			{ProjectId: projectIDs[0], Role: v1.ProjectRole_PROJECT_ROLE_MEMBER},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "bob@example.com", inv.UserId)
+	assert.Equal(t, "u0", inv.InviterUserId)
+	assert.Equal(t, v1.InvitationState_INVITATION_STATE_PENDING, inv.State)
+	assert.Len(t, sender.msgs, 1)
+	assert.Equal(t, "bob@example.com", sender.msgs[0].Email)
+
+	// The user already has a pending invitation.
+	_, err = srv.CreateInvitation(u0Ctx, &v1.CreateInvitationRequest{
+		OrganizationId: o.OrganizationID,
+		UserId:         "bob@example.com",
+		Role:           v1.OrganizationRole_ORGANIZATION_ROLE_READER,
+	})
+	assert.Error(t, err)
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	bobCtx := auth.AppendUserInfoToContext(context.Background(), auth.UserInfo{
+		UserID: "bob@example.com",
+	})
+	carolCtx := auth.AppendUserInfoToContext(context.Background(), auth.UserInfo{
+		UserID: "carol@example.com",
+	})
+
+	// Only an owner can invite users.
+	_, err = srv.CreateInvitation(bobCtx, &v1.CreateInvitationRequest{
+		OrganizationId: o.OrganizationID,
+		UserId:         "carol@example.com",
+		Role:           v1.OrganizationRole_ORGANIZATION_ROLE_READER,
+	})
+	assert.Error(t, err)
+
+	resp, err := srv.ListOrganizationInvitations(u0Ctx, &v1.ListOrganizationInvitationsRequest{OrganizationId: o.OrganizationID})
+	assert.NoError(t, err)
+	assert.Len(t, resp.Invitations, 1)
+	// Only an owner can list the invitations of the organization.
+	_, err = srv.ListOrganizationInvitations(bobCtx, &v1.ListOrganizationInvitationsRequest{OrganizationId: o.OrganizationID})
+	assert.Error(t, err)
+	_, err = srv.ListOrganizationInvitations(u0Ctx, &v1.ListOrganizationInvitationsRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	resp, err = srv.ListInvitations(bobCtx, &v1.ListInvitationsRequest{})
+	assert.NoError(t, err)
+	assert.Len(t, resp.Invitations, 1)
+	resp, err = srv.ListInvitations(carolCtx, &v1.ListInvitationsRequest{})
+	assert.NoError(t, err)
+	assert.Empty(t, resp.Invitations)
+
+	// Only the invitee can accept the invitation.
+	_, err = srv.AcceptInvitation(carolCtx, &v1.AcceptInvitationRequest{Id: inv.Id})
+	assert.Error(t, err)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	inv, err = srv.AcceptInvitation(bobCtx, &v1.AcceptInvitationRequest{Id: inv.Id})
+	assert.NoError(t, err)
+	assert.Equal(t, v1.InvitationState_INVITATION_STATE_ACCEPTED, inv.State)
+
+	role, err := findOrgRole(st, o.OrganizationID, "bob@example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, v1.OrganizationRole_ORGANIZATION_ROLE_READER, role)
+	prole, err := findProjectRole(st, projectIDs[0], "bob@example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, v1.ProjectRole_PROJECT_ROLE_MEMBER, prole)
+	prole, err = findProjectRole(st, projectIDs[1], "bob@example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, v1.ProjectRole_PROJECT_ROLE_UNSPECIFIED, prole)
+
+	_, err = srv.AcceptInvitation(bobCtx, &v1.AcceptInvitationRequest{Id: inv.Id})
+	assert.Error(t, err)
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	// The user is already a member.
+	_, err = srv.CreateInvitation(u0Ctx, &v1.CreateInvitationRequest{
+		OrganizationId: o.OrganizationID,
+		UserId:         "bob@example.com",
+		Role:           v1.OrganizationRole_ORGANIZATION_ROLE_READER,
+	})
+	assert.Error(t, err)
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	// Decline.
+	inv, err = srv.CreateInvitation(u0Ctx, &v1.CreateInvitationRequest{
+		OrganizationId: o.OrganizationID,
+		UserId:         "carol@example.com",
+		Role:           v1.OrganizationRole_ORGANIZATION_ROLE_READER,
+	})
+	assert.NoError(t, err)
+	inv, err = srv.DeclineInvitation(carolCtx, &v1.DeclineInvitationRequest{Id: inv.Id})
+	assert.NoError(t, err)
+	assert.Equal(t, v1.InvitationState_INVITATION_STATE_DECLINED, inv.State)
+	_, err = srv.AcceptInvitation(carolCtx, &v1.AcceptInvitationRequest{Id: inv.Id})
+	assert.Error(t, err)
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	role, err = findOrgRole(st, o.OrganizationID, "carol@example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, v1.OrganizationRole_ORGANIZATION_ROLE_UNSPECIFIED, role)
+
+	// Revoke.
+	inv, err = srv.CreateInvitation(u0Ctx, &v1.CreateInvitationRequest{
+		OrganizationId: o.OrganizationID,
+		UserId:         "carol@example.com",
+		Role:           v1.OrganizationRole_ORGANIZATION_ROLE_READER,
+	})
+	assert.NoError(t, err)
+	_, err = srv.RevokeInvitation(bobCtx, &v1.RevokeInvitationRequest{OrganizationId: o.OrganizationID, Id: inv.Id})
+	assert.Error(t, err)
+	inv, err = srv.RevokeInvitation(u0Ctx, &v1.RevokeInvitationRequest{OrganizationId: o.OrganizationID, Id: inv.Id})
+	assert.NoError(t, err)
+	assert.Equal(t, v1.InvitationState_INVITATION_STATE_REVOKED, inv.State)
+	_, err = srv.AcceptInvitation(carolCtx, &v1.AcceptInvitationRequest{Id: inv.Id})
+	assert.Error(t, err)
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	// Expired.
+	expired, err := st.CreateInvitation(store.CreateInvitationParams{
+		InvitationID:   "inv_expired",
+		OrganizationID: o.OrganizationID,
+		UserID:         "carol@example.com",
+		Role:           v1.OrganizationRole_ORGANIZATION_ROLE_READER,
+		ExpiresAt:      time.Now().Add(-time.Hour).Unix(),
+	})
+	assert.NoError(t, err)
+	_, err = srv.AcceptInvitation(carolCtx, &v1.AcceptInvitationRequest{Id: expired.InvitationID})
+	assert.Error(t, err)
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	resp, err = srv.ListOrganizationInvitations(u0Ctx, &v1.ListOrganizationInvitationsRequest{OrganizationId: o.OrganizationID})
+	assert.NoError(t, err)
+	assert.Len(t, resp.Invitations, 4)
+	assert.Equal(t, v1.InvitationState_INVITATION_STATE_EXPIRED, resp.Invitations[3].State)
+}
+
+func TestInvitation_GetUserSelf(t *testing.T) {
+	st, tearDown := store.NewTest(t)
+	defer tearDown()
+
+	srv := New(st, nil, testr.New(t))
+	srv.enableAuth = true
+	srv.SetInvitationSender(&fakeInvitationSender{})
+
+	o := createDefaultOrg(t, srv, "u0")
+
+	u0Ctx := auth.AppendUserInfoToContext(context.Background(), auth.UserInfo{
+		UserID: "u0",
+	})
+	var projectIDs []string
+	for _, title := range []string{"p0", "p1"} {
+		p, err := srv.CreateProject(u0Ctx, &v1.CreateProjectRequest{
+			Title:               title,
+			OrganizationId:      o.OrganizationID,
+			KubernetesNamespace: title,
+		})
+		assert.NoError(t, err)
+		projectIDs = append(projectIDs, p.Id)
+	}
+
+	// An owner becomes an owner of all the projects.
+	inv, err := srv.CreateInvitation(u0Ctx, &v1.CreateInvitationRequest{
+		OrganizationId: o.OrganizationID,
+		UserId:         "bob@example.com",
+		Role:           v1.OrganizationRole_ORGANIZATION_ROLE_OWNER,
+	})
+	assert.NoError(t, err)
+
+	bobCtx := auth.AppendUserInfoToContext(context.Background(), auth.UserInfo{
+		UserID: "bob@example.com",
+	})
+	u, err := srv.GetUserSelf(bobCtx, &v1.GetUserSelfRequest{})
+	assert.NoError(t, err)
+	assert.Len(t, u.OrganizationRoleBindings, 1)
+	assert.Equal(t, v1.OrganizationRole_ORGANIZATION_ROLE_OWNER, u.OrganizationRoleBindings[0].Role)
+	assert.Len(t, u.ProjectRoleBindings, 2)
+	for _, b := range u.ProjectRoleBindings {
+		assert.Equal(t, v1.ProjectRole_PROJECT_ROLE_OWNER, b.Role)
+	}
+
+	got, err := st.GetInvitation(inv.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, v1.InvitationState_INVITATION_STATE_ACCEPTED.String(), got.State)
+}
@@ -15,7 +15,6 @@ import (
 	"github.com/llmariner/user-manager/server/internal/store"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
-	"google.golang.org/protobuf/types/known/emptypb"
 	"gorm.io/gorm"
 )
 
@@ -123,36 +122,79 @@ func (s *S) ListOrganizations(ctx context.Context, req *v1.ListOrganizationsRequ
 
 	if req.IncludeSummary {
 		for _, org := range orgProtos {
-			ps, err := s.store.ListProjectsByTenantIDAndOrganizationID(userInfo.TenantID, org.Id)
+			var err error
+			org.Summary, err = s.organizationSummary(userInfo, org.Id)
 			if err != nil {
-				return nil, status.Errorf(codes.Internal, "list projects: %s", err)
+				return nil, err
 			}
+		}
+	}
 
-			var visiblePjCount int32
-			for _, p := range ps {
-				if s.validateProjectMember(p.ProjectID, p.OrganizationID, userInfo.UserID) == nil {
-					visiblePjCount++
-				}
-			}
+	return &v1.ListOrganizationsResponse{
+		Organizations: orgProtos,
+	}, nil
+}
 
-			var visibleUserCount int32
-			if s.isOrganizationOwner(org.Id, userInfo.UserID) {
-				us, err := s.store.ListOrganizationNonHiddenUsersByOrganizationID(org.Id)
-				if err != nil {
-					return nil, status.Errorf(codes.Internal, "list organization users: %s", err)
-				}
-				visibleUserCount = int32(len(us))
-			}
+// GetOrganization gets an organization.
+func (s *S) GetOrganization(ctx context.Context, req *v1.GetOrganizationRequest) (*v1.Organization, error) {
+	userInfo, ok := auth.ExtractUserInfoFromContext(ctx)
+	if !ok {
+		return nil, status.Errorf(codes.Internal, "failed to extract user info from context")
+	}
 
-			org.Summary = &v1.Organization_Summary{
-				ProjectCount: visiblePjCount,
-				UserCount:    visibleUserCount,
-			}
+	if req.Id == "" {
+		return nil, status.Error(codes.InvalidArgument, "organization id is required")
+	}
+
+	org, err := s.store.GetOrganizationByTenantIDAndOrgID(userInfo.TenantID, req.Id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.NotFound, "organization %q not found", req.Id)
 		}
+		return nil, status.Errorf(codes.Internal, "get organization: %s", err)
+	}
+	// Do not reveal the existence of the org to the user who is not a member.
+	if !s.isOrganizationMember(org.OrganizationID, userInfo.UserID) {
+		return nil, status.Errorf(codes.NotFound, "organization %q not found", req.Id)
 	}
 
-	return &v1.ListOrganizationsResponse{
-		Organizations: orgProtos,
+	orgProto := org.ToProto()
+	if req.IncludeSummary {
+		orgProto.Summary, err = s.organizationSummary(userInfo, org.OrganizationID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return orgProto, nil
+}
+
+// organizationSummary returns the summary of the organization. Only projects and users
+// visible to the user are counted.
+func (s *S) organizationSummary(userInfo *auth.UserInfo, orgID string) (*v1.Organization_Summary, error) {
+	ps, err := s.store.ListProjectsByTenantIDAndOrganizationID(userInfo.TenantID, orgID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "list projects: %s", err)
+	}
+
+	var visiblePjCount int32
+	for _, p := range ps {
+		if s.validateProjectMember(p.ProjectID, p.OrganizationID, userInfo.UserID) == nil {
+			visiblePjCount++
+		}
+	}
+
+	var visibleUserCount int32
+	if s.isOrganizationOwner(orgID, userInfo.UserID) {
+		us, err := s.store.ListOrganizationNonHiddenUsersByOrganizationID(orgID)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "list organization users: %s", err)
+		}
+		visibleUserCount = int32(len(us))
+	}
+
+	return &v1.Organization_Summary{
+		ProjectCount: visiblePjCount,
+		UserCount:    visibleUserCount,
 	}, nil
 }
 
@@ -203,6 +245,10 @@ func (s *S) DeleteOrganization(ctx context.Context, req *v1.DeleteOrganizationRe
 		if err := store.DeleteAllOrganizationUsersInTransaction(tx, req.Id); err != nil {
 			return fmt.Errorf("delete all organization users: %s", err)
 		}
+
+		if err := store.DeleteAllInvitationsInTransaction(tx, req.Id); err != nil {
+			return fmt.Errorf("delete all invitations: %s", err)
+		}
 		return nil
 	}); err != nil {
 		return nil, status.Errorf(codes.Internal, "transaction: %s", err)
@@ -215,6 +261,55 @@ func (s *S) DeleteOrganization(ctx context.Context, req *v1.DeleteOrganizationRe
 	}, nil
 }
 
+// UpdateOrganization updates an organization.
+func (s *S) UpdateOrganization(ctx context.Context, req *v1.UpdateOrganizationRequest) (*v1.Organization, error) {
+	userInfo, ok := auth.ExtractUserInfoFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("failed to extract user info from context")
+	}
+	if req.Organization == nil || req.Organization.Id == "" {
+		return nil, status.Error(codes.InvalidArgument, "organization id is required")
+	}
+	if req.UpdateMask == nil {
+		return nil, status.Error(codes.InvalidArgument, "update mask is required")
+	}
+
+	if _, err := validateOrganizationID(s.store, req.Organization.Id, userInfo.TenantID); err != nil {
+		return nil, err
+	}
+	if err := s.validateOrganizationOwner(req.Organization.Id, userInfo.UserID); err != nil {
+		return nil, err
+	}
+
+	updates := map[string]interface{}{}
+	for _, path := range req.UpdateMask.Paths {
+		switch path {
+		case "title":
+			if req.Organization.Title == "" {
+				return nil, status.Error(codes.InvalidArgument, "title is required")
+			}
+			updates["title"] = req.Organization.Title
+		default:
+			return nil, status.Errorf(codes.InvalidArgument, "unsupported update path: %s", path)
+		}
+	}
+
+	if len(updates) > 0 {
+		if err := s.store.UpdateOrganization(req.Organization.Id, updates); err != nil {
+			if gerrors.IsUniqueConstraintViolation(err) {
+				return nil, status.Errorf(codes.AlreadyExists, "organization %q already exists", req.Organization.Title)
+			}
+			return nil, status.Errorf(codes.Internal, "update organization: %s", err)
+		}
+	}
+
+	o, err := s.store.GetOrganizationByTenantIDAndOrgID(userInfo.TenantID, req.Organization.Id)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "get organization: %s", err)
+	}
+	return o.ToProto(), nil
+}
+
 // CreateOrganizationUser adds a user to an organization.
 func (s *S) CreateOrganizationUser(ctx context.Context, req *v1.CreateOrganizationUserRequest) (*v1.OrganizationUser, error) {
 	userInfo, ok := auth.ExtractUserInfoFromContext(ctx)
@@ -231,6 +326,9 @@ func (s *S) CreateOrganizationUser(ctx context.Context, req *v1.CreateOrganizati
 	if req.Role == v1.OrganizationRole_ORGANIZATION_ROLE_UNSPECIFIED {
 		return nil, status.Error(codes.InvalidArgument, "role is required")
 	}
+	if err := validateAssignableOrganizationRole(req.Role); err != nil {
+		return nil, err
+	}
 
 	if _, err := validateOrganizationID(s.store, req.OrganizationId, userInfo.TenantID); err != nil {
 		return nil, err
@@ -305,7 +403,7 @@ func (s *S) ListOrganizationUsers(ctx context.Context, req *v1.ListOrganizationU
 }
 
 // DeleteOrganizationUser deletes an organization user.
-func (s *S) DeleteOrganizationUser(ctx context.Context, req *v1.DeleteOrganizationUserRequest) (*emptypb.Empty, error) {
+func (s *S) DeleteOrganizationUser(ctx context.Context, req *v1.DeleteOrganizationUserRequest) (*v1.DeleteOrganizationUserResponse, error) {
 	userInfo, ok := auth.ExtractUserInfoFromContext(ctx)
 	if !ok {
 		return nil, fmt.Errorf("failed to extract user info from context")
@@ -322,26 +420,35 @@ func (s *S) DeleteOrganizationUser(ctx context.Context, req *v1.DeleteOrganizati
 		return nil, err
 	}
 
-	if err := s.validateOrganizationOwner(req.OrganizationId, userInfo.UserID); err != nil {
-		return nil, err
+	override := s.canRemoveLastOwner(req.OrganizationId, userInfo.UserID)
+	if !override {
+		if err := s.validateOrganizationOwner(req.OrganizationId, userInfo.UserID); err != nil {
+			return nil, err
+		}
 	}
 
 	userID := userid.Normalize(req.UserId)
-	if _, err := s.store.GetOrganizationUser(req.OrganizationId, userID); err != nil {
+	ou, err := s.store.GetOrganizationUser(req.OrganizationId, userID)
+	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, status.Errorf(codes.NotFound, "organization user %q not found", userID)
 		}
 		return nil, status.Errorf(codes.Internal, "get organization user: %s", err)
 	}
+	checkOwner := !override && ou.Role == v1.OrganizationRole_ORGANIZATION_ROLE_OWNER.String()
 
 	// TODO(kenji): Validate the user ID.
 
 	// Delete the user from all projects in the organization as well as from the organization.
+	// The API keys of the user in the organization are revoked, or disabled if requested.
+	//
+	// Projects can be left without an owner here as the owners of the organization can still manage them.
 	projects, err := s.store.ListProjectsByTenantIDAndOrganizationID(userInfo.TenantID, req.OrganizationId)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "list projects: %s", err)
 	}
 
+	var revokedIDs, disabledIDs []string
 	if err := s.store.Transaction(func(tx *gorm.DB) error {
 		for _, p := range projects {
 			if err := store.DeleteProjectUserInTransaction(tx, p.ProjectID, userID); err != nil {
@@ -355,12 +462,138 @@ func (s *S) DeleteOrganizationUser(ctx context.Context, req *v1.DeleteOrganizati
 		if err := store.DeleteOrganizationUserInTransaction(tx, req.OrganizationId, userID); err != nil {
 			return fmt.Errorf("delete organization user: %s", err)
 		}
+		if checkOwner {
+			if err := validateOrganizationHasOwnerInTransaction(tx, req.OrganizationId); err != nil {
+				return err
+			}
+		}
+
+		var err error
+		if req.DisableApiKeys {
+			disabledIDs, err = store.DisableAPIKeysByUserIDInTransaction(tx, req.OrganizationId, "", userID)
+			if err != nil {
+				return fmt.Errorf("disable api keys: %s", err)
+			}
+			return nil
+		}
+		revokedIDs, err = store.DeleteAPIKeysByUserIDInTransaction(tx, req.OrganizationId, "", userID)
+		if err != nil {
+			return fmt.Errorf("delete api keys: %s", err)
+		}
 		return nil
 	}); err != nil {
+		if status.Code(err) == codes.FailedPrecondition {
+			return nil, err
+		}
 		return nil, status.Errorf(codes.Internal, "transaction: %s", err)
 	}
 
-	return &emptypb.Empty{}, nil
+	return &v1.DeleteOrganizationUserResponse{
+		Id:                userID,
+		Object:            "organization.user",
+		Deleted:           true,
+		RevokedApiKeyIds:  revokedIDs,
+		DisabledApiKeyIds: disabledIDs,
+	}, nil
+}
+
+// UpdateOrganizationUser changes the role of a user in an organization.
+func (s *S) UpdateOrganizationUser(ctx context.Context, req *v1.UpdateOrganizationUserRequest) (*v1.OrganizationUser, error) {
+	userInfo, ok := auth.ExtractUserInfoFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("failed to extract user info from context")
+	}
+
+	if req.OrganizationId == "" {
+		return nil, status.Error(codes.InvalidArgument, "organization id is required")
+	}
+	if req.UserId == "" {
+		return nil, status.Error(codes.InvalidArgument, "user id is required")
+	}
+	if req.Role == v1.OrganizationRole_ORGANIZATION_ROLE_UNSPECIFIED {
+		return nil, status.Error(codes.InvalidArgument, "role is required")
+	}
+	if err := validateAssignableOrganizationRole(req.Role); err != nil {
+		return nil, err
+	}
+
+	if _, err := validateOrganizationID(s.store, req.OrganizationId, userInfo.TenantID); err != nil {
+		return nil, err
+	}
+
+	override := s.canRemoveLastOwner(req.OrganizationId, userInfo.UserID)
+	if !override {
+		if err := s.validateOrganizationOwner(req.OrganizationId, userInfo.UserID); err != nil {
+			return nil, err
+		}
+	}
+
+	userID := userid.Normalize(req.UserId)
+	role, err := findOrgRole(s.store, req.OrganizationId, userID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "find organization role: %s", err)
+	}
+	checkOwner := !override && role == v1.OrganizationRole_ORGANIZATION_ROLE_OWNER
+
+	// An owner of the organization is also an owner of all its projects. Promote the user in the
+	// projects in the same transaction so that the user never has the org role without the project roles.
+	// Demoting the user keeps the project roles as they are.
+	var projects []*store.Project
+	if req.Role == v1.OrganizationRole_ORGANIZATION_ROLE_OWNER {
+		projects, err = s.store.ListProjectsByTenantIDAndOrganizationID(userInfo.TenantID, req.OrganizationId)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "list projects: %s", err)
+		}
+	}
+
+	if err := s.store.Transaction(func(tx *gorm.DB) error {
+		if err := store.UpdateOrganizationUserRoleInTransaction(tx, req.OrganizationId, userID, req.Role.String()); err != nil {
+			return err
+		}
+		if checkOwner {
+			if err := validateOrganizationHasOwnerInTransaction(tx, req.OrganizationId); err != nil {
+				return err
+			}
+		}
+		return addProjectOwnerInTransaction(tx, projects, userID)
+	}); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.NotFound, "organization user %q not found", userID)
+		}
+		if status.Code(err) == codes.FailedPrecondition {
+			return nil, err
+		}
+		return nil, status.Errorf(codes.Internal, "update organization user: %s", err)
+	}
+
+	ou, err := s.store.GetOrganizationUser(req.OrganizationId, userID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "get organization user: %s", err)
+	}
+	// Do not populate the internal User ID for non-internal RPC.
+	return ou.ToProto(""), nil
+}
+
+// addProjectOwnerInTransaction makes the user an owner of the given projects.
+func addProjectOwnerInTransaction(tx *gorm.DB, projects []*store.Project, userID string) error {
+	for _, p := range projects {
+		err := store.UpdateProjectUserRoleInTransaction(tx, p.ProjectID, userID, v1.ProjectRole_PROJECT_ROLE_OWNER)
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("update project user: %s", err)
+		}
+		if _, err := store.CreateProjectUserInTransaction(tx, store.CreateProjectUserParams{
+			ProjectID:      p.ProjectID,
+			OrganizationID: p.OrganizationID,
+			UserID:         userID,
+			Role:           v1.ProjectRole_PROJECT_ROLE_OWNER,
+		}); err != nil {
+			return fmt.Errorf("create project user: %s", err)
+		}
+	}
+	return nil
 }
 
 func validateOrganizationID(st *store.S, orgID, tenantID string) (*store.Organization, error) {
@@ -375,6 +608,24 @@ func validateOrganizationID(st *store.S, orgID, tenantID string) (*store.Organiz
 	return o, nil
 }
 
+// validateOrganizationHasOwnerInTransaction checks if the organization still has an owner.
+// This is called after removing or demoting an owner in the same transaction. The organization row
+// is locked before the owners are counted so that concurrent transactions removing different owners
+// are serialized, and the later one observes the removal committed by the earlier one.
+func validateOrganizationHasOwnerInTransaction(tx *gorm.DB, orgID string) error {
+	if err := store.LockOrganizationInTransaction(tx, orgID); err != nil {
+		return fmt.Errorf("lock organization: %s", err)
+	}
+	n, err := store.CountOrganizationOwnersInTransaction(tx, orgID)
+	if err != nil {
+		return fmt.Errorf("count organization owners: %s", err)
+	}
+	if n == 0 {
+		return status.Errorf(codes.FailedPrecondition, "organization %q must have at least one owner", orgID)
+	}
+	return nil
+}
+
 // CreateDefaultOrganization creates the default org.
 // TODO(kenji): This is not the best place for this function as there is nothing related to
 // the server itself.
@@ -396,11 +647,36 @@ func (s *S) CreateDefaultOrganization(ctx context.Context, c *config.DefaultOrga
 	return org, nil
 }
 
-// ListInternalOrganizations lists all organizations.
+// ListInternalOrganizations lists all organizations, or the ones that have changed after the given revision.
 func (s *IS) ListInternalOrganizations(ctx context.Context, req *v1.ListInternalOrganizationsRequest) (*v1.ListInternalOrganizationsResponse, error) {
-	orgs, err := s.store.ListAllOrganizations()
+	rev, err := s.latestRevision(req.SinceRevision)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "list organizations: %s", err)
+		return nil, err
+	}
+
+	var (
+		orgs       []*store.Organization
+		deletedIDs []string
+	)
+	if req.SinceRevision == 0 {
+		orgs, err = s.store.ListAllOrganizations()
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "list organizations: %s", err)
+		}
+	} else {
+		ids, err := s.listChangedResourceIDs(store.ResourceKindOrganization, req.SinceRevision)
+		if err != nil {
+			return nil, err
+		}
+		orgs, err = s.store.ListOrganizationsByIDs(ids)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "list organizations: %s", err)
+		}
+		found := map[string]bool{}
+		for _, org := range orgs {
+			found[org.OrganizationID] = true
+		}
+		deletedIDs = tombstones(ids, found)
 	}
 
 	var orgProtos []*v1.InternalOrganization
@@ -411,7 +687,9 @@ func (s *IS) ListInternalOrganizations(ctx context.Context, req *v1.ListInternal
 		})
 	}
 	return &v1.ListInternalOrganizationsResponse{
-		Organizations: orgProtos,
+		Organizations:          orgProtos,
+		Revision:               rev,
+		DeletedOrganizationIds: deletedIDs,
 	}, nil
 }
 
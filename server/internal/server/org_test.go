@@ -3,6 +3,7 @@ package server
 import (
 	"context"
 	"fmt"
+	"sync"
 	"testing"
 
 	"github.com/go-logr/logr/testr"
@@ -13,6 +14,8 @@ import (
 	"github.com/stretchr/testify/assert"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"gorm.io/gorm"
 )
 
 func TestOrganization(t *testing.T) {
@@ -33,11 +36,7 @@ func TestOrganization(t *testing.T) {
 		assert.Equal(t, title, cresp.Title)
 
 		// Delete the default user to make the rest of the test simple.
-		_, err = srv.DeleteOrganizationUser(ctx, &v1.DeleteOrganizationUserRequest{
-			OrganizationId: cresp.Id,
-			UserId:         defaultUserID,
-		})
-		assert.NoError(t, err)
+		deleteDefaultUser(t, st, cresp.Id)
 
 		_, err = srv.CreateOrganizationUser(ctx, &v1.CreateOrganizationUserRequest{
 			OrganizationId: cresp.Id,
@@ -101,6 +100,21 @@ func TestOrganization(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Len(t, laresp2.Users, 1)
 
+	// The last owner cannot be deleted.
+	_, err = srv.DeleteOrganizationUser(ctx, &v1.DeleteOrganizationUserRequest{
+		OrganizationId: lresp2.Organizations[0].Id,
+		UserId:         "user 1",
+	})
+	assert.Error(t, err)
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	_, err = srv.CreateOrganizationUser(ctx, &v1.CreateOrganizationUserRequest{
+		OrganizationId: lresp2.Organizations[0].Id,
+		UserId:         "user 2",
+		Role:           v1.OrganizationRole_ORGANIZATION_ROLE_OWNER,
+	})
+	assert.NoError(t, err)
+
 	_, err = srv.DeleteOrganizationUser(ctx, &v1.DeleteOrganizationUserRequest{
 		OrganizationId: lresp2.Organizations[0].Id,
 		UserId:         "user 1",
@@ -109,7 +123,8 @@ func TestOrganization(t *testing.T) {
 
 	laresp3, err := isrv.ListOrganizationUsers(ctx, &v1.ListOrganizationUsersRequest{})
 	assert.NoError(t, err)
-	assert.Empty(t, laresp3.Users)
+	assert.Len(t, laresp3.Users, 1)
+	assert.Equal(t, "user 2", laresp3.Users[0].UserId)
 }
 
 func TestDeleteOrganization(t *testing.T) {
@@ -213,11 +228,7 @@ func TestListOrganizationUsers(t *testing.T) {
 		orgs = append(orgs, org)
 
 		// Delete the default user to make the rest of the test simple.
-		_, err = srv.DeleteOrganizationUser(ctx, &v1.DeleteOrganizationUserRequest{
-			OrganizationId: org.Id,
-			UserId:         defaultUserID,
-		})
-		assert.NoError(t, err)
+		deleteDefaultUser(t, st, org.Id)
 
 		_, err = srv.CreateOrganizationUser(ctx, &v1.CreateOrganizationUserRequest{
 			OrganizationId: org.Id,
@@ -248,11 +259,7 @@ func TestListOrganizationUsers_HiddenUser(t *testing.T) {
 	assert.NoError(t, err)
 
 	// Delete the default user to make the rest of the test simple.
-	_, err = srv.DeleteOrganizationUser(ctx, &v1.DeleteOrganizationUserRequest{
-		OrganizationId: org.Id,
-		UserId:         defaultUserID,
-	})
-	assert.NoError(t, err)
+	deleteDefaultUser(t, st, org.Id)
 
 	_, err = srv.CreateOrganizationUser(ctx, &v1.CreateOrganizationUserRequest{
 		OrganizationId: org.Id,
@@ -297,11 +304,7 @@ func TestDeleteDeleteOrganizationUser(t *testing.T) {
 	assert.NoError(t, err)
 
 	// Delete the default user to make the rest of the test simple.
-	_, err = srv.DeleteOrganizationUser(ctx, &v1.DeleteOrganizationUserRequest{
-		OrganizationId: o.Id,
-		UserId:         defaultUserID,
-	})
-	assert.NoError(t, err)
+	deleteDefaultUser(t, st, o.Id)
 
 	p, err := srv.CreateProject(ctx, &v1.CreateProjectRequest{
 		Title:               "title",
@@ -333,6 +336,14 @@ func TestDeleteDeleteOrganizationUser(t *testing.T) {
 	assert.Len(t, resp.Users, 1)
 	assert.Equal(t, resp.Users[0].UserId, userID)
 
+	// Add another owner to keep the organization owned.
+	_, err = srv.CreateOrganizationUser(ctx, &v1.CreateOrganizationUserRequest{
+		OrganizationId: o.Id,
+		UserId:         "owner",
+		Role:           v1.OrganizationRole_ORGANIZATION_ROLE_OWNER,
+	})
+	assert.NoError(t, err)
+
 	// Delete the org user. Make sure the project user is deleted as well.
 	_, err = srv.DeleteOrganizationUser(ctx, &v1.DeleteOrganizationUserRequest{
 		OrganizationId: o.Id,
@@ -348,6 +359,62 @@ func TestDeleteDeleteOrganizationUser(t *testing.T) {
 	assert.Empty(t, resp.Users)
 }
 
+func TestDeleteOrganizationUser_RevokeAPIKeys(t *testing.T) {
+	st, tearDown := store.NewTest(t)
+	defer tearDown()
+
+	srv := New(st, nil, testr.New(t))
+	isrv := NewInternal(st, nil, testr.New(t))
+	ctx := fakeAuthInto(context.Background())
+
+	o0, err := srv.CreateOrganization(ctx, &v1.CreateOrganizationRequest{
+		Title: "o0",
+	})
+	assert.NoError(t, err)
+	o1, err := srv.CreateOrganization(ctx, &v1.CreateOrganizationRequest{
+		Title: "o1",
+	})
+	assert.NoError(t, err)
+
+	var keyIDs []string
+	for _, o := range []*v1.Organization{o0, o0, o1} {
+		p, err := srv.CreateProject(ctx, &v1.CreateProjectRequest{
+			Title:               fmt.Sprintf("p%d", len(keyIDs)),
+			OrganizationId:      o.Id,
+			KubernetesNamespace: "ns",
+		})
+		assert.NoError(t, err)
+		k, err := srv.CreateAPIKey(ctx, &v1.CreateAPIKeyRequest{
+			Name:           fmt.Sprintf("k%d", len(keyIDs)),
+			OrganizationId: o.Id,
+			ProjectId:      p.Id,
+		})
+		assert.NoError(t, err)
+		keyIDs = append(keyIDs, k.Id)
+	}
+
+	_, err = srv.CreateOrganizationUser(ctx, &v1.CreateOrganizationUserRequest{
+		OrganizationId: o0.Id,
+		UserId:         "owner",
+		Role:           v1.OrganizationRole_ORGANIZATION_ROLE_OWNER,
+	})
+	assert.NoError(t, err)
+
+	resp, err := srv.DeleteOrganizationUser(ctx, &v1.DeleteOrganizationUserRequest{
+		OrganizationId: o0.Id,
+		UserId:         defaultUserID,
+	})
+	assert.NoError(t, err)
+	assert.True(t, resp.Deleted)
+	assert.ElementsMatch(t, keyIDs[:2], resp.RevokedApiKeyIds)
+
+	// Only the API key in the other organization remains.
+	lresp, err := isrv.ListInternalAPIKeys(ctx, &v1.ListInternalAPIKeysRequest{})
+	assert.NoError(t, err)
+	assert.Len(t, lresp.ApiKeys, 1)
+	assert.Equal(t, keyIDs[2], lresp.ApiKeys[0].ApiKey.Id)
+}
+
 func TestCreateDefaultOrganization(t *testing.T) {
 	st, tearDown := store.NewTest(t)
 	defer tearDown()
@@ -560,6 +627,75 @@ func TestListOrganizations_EnableAuth(t *testing.T) {
 	assert.Empty(t, resp.Organizations)
 }
 
+func TestGetOrganization_EnableAuth(t *testing.T) {
+	st, tearDown := store.NewTest(t)
+	defer tearDown()
+
+	srv := New(st, nil, testr.New(t))
+	srv.enableAuth = true
+
+	o := createDefaultOrg(t, srv, "u0")
+
+	u0Ctx := auth.AppendUserInfoToContext(context.Background(), auth.UserInfo{
+		UserID: "u0",
+	})
+	_, err := srv.CreateProject(u0Ctx, &v1.CreateProjectRequest{
+		Title:               "title",
+		OrganizationId:      o.OrganizationID,
+		KubernetesNamespace: "n0",
+	})
+	assert.NoError(t, err)
+
+	_, err = srv.CreateOrganizationUser(u0Ctx, &v1.CreateOrganizationUserRequest{
+		OrganizationId: o.OrganizationID,
+		UserId:         "u1",
+		Role:           v1.OrganizationRole_ORGANIZATION_ROLE_READER,
+	})
+	assert.NoError(t, err)
+
+	got, err := srv.GetOrganization(u0Ctx, &v1.GetOrganizationRequest{
+		Id:             o.OrganizationID,
+		IncludeSummary: true,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "default", got.Title)
+	assert.Equal(t, int32(1), got.Summary.ProjectCount)
+	assert.Equal(t, int32(2), got.Summary.UserCount)
+
+	// A reader sees neither the project it is not a member of nor the users.
+	u1Ctx := auth.AppendUserInfoToContext(context.Background(), auth.UserInfo{
+		UserID: "u1",
+	})
+	got, err = srv.GetOrganization(u1Ctx, &v1.GetOrganizationRequest{
+		Id:             o.OrganizationID,
+		IncludeSummary: true,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(0), got.Summary.ProjectCount)
+	assert.Equal(t, int32(0), got.Summary.UserCount)
+
+	got, err = srv.GetOrganization(u1Ctx, &v1.GetOrganizationRequest{
+		Id: o.OrganizationID,
+	})
+	assert.NoError(t, err)
+	assert.Nil(t, got.Summary)
+
+	u2Ctx := auth.AppendUserInfoToContext(context.Background(), auth.UserInfo{
+		UserID: "u2",
+	})
+	_, err = srv.GetOrganization(u2Ctx, &v1.GetOrganizationRequest{
+		Id: o.OrganizationID,
+	})
+	assert.Error(t, err)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = srv.GetOrganization(u0Ctx, &v1.GetOrganizationRequest{
+		Id: "missing",
+	})
+	assert.Error(t, err)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
 func TestDeleteOrganization_EnableAuth(t *testing.T) {
 	st, tearDown := store.NewTest(t)
 	defer tearDown()
@@ -596,6 +732,78 @@ func TestDeleteOrganization_EnableAuth(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestUpdateOrganization_EnableAuth(t *testing.T) {
+	st, tearDown := store.NewTest(t)
+	defer tearDown()
+
+	srv := New(st, nil, testr.New(t))
+	srv.enableAuth = true
+
+	createDefaultOrg(t, srv, "user0")
+
+	u0Ctx := auth.AppendUserInfoToContext(context.Background(), auth.UserInfo{
+		UserID: "user0",
+	})
+	o1, err := srv.CreateOrganization(u0Ctx, &v1.CreateOrganizationRequest{
+		Title: "title1",
+	})
+	assert.NoError(t, err)
+	_, err = srv.CreateOrganization(u0Ctx, &v1.CreateOrganizationRequest{
+		Title: "title2",
+	})
+	assert.NoError(t, err)
+
+	// "user1" is a reader of the organization.
+	_, err = srv.CreateOrganizationUser(u0Ctx, &v1.CreateOrganizationUserRequest{
+		OrganizationId: o1.Id,
+		UserId:         "user1",
+		Role:           v1.OrganizationRole_ORGANIZATION_ROLE_READER,
+	})
+	assert.NoError(t, err)
+	u1Ctx := auth.AppendUserInfoToContext(context.Background(), auth.UserInfo{
+		UserID: "user1",
+	})
+
+	req := &v1.UpdateOrganizationRequest{
+		Organization: &v1.Organization{
+			Id:    o1.Id,
+			Title: "new title",
+		},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"title"}},
+	}
+	_, err = srv.UpdateOrganization(u1Ctx, req)
+	assert.Error(t, err)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	o, err := srv.UpdateOrganization(u0Ctx, req)
+	assert.NoError(t, err)
+	assert.Equal(t, "new title", o.Title)
+
+	// The title must be unique in the tenant.
+	req.Organization.Title = "title2"
+	_, err = srv.UpdateOrganization(u0Ctx, req)
+	assert.Error(t, err)
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	req.Organization.Title = ""
+	_, err = srv.UpdateOrganization(u0Ctx, req)
+	assert.Error(t, err)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	req.UpdateMask.Paths = []string{"is_default"}
+	_, err = srv.UpdateOrganization(u0Ctx, req)
+	assert.Error(t, err)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	resp, err := srv.ListOrganizations(u0Ctx, &v1.ListOrganizationsRequest{})
+	assert.NoError(t, err)
+	var titles []string
+	for _, o := range resp.Organizations {
+		titles = append(titles, o.Title)
+	}
+	assert.ElementsMatch(t, []string{"default", "new title", "title2"}, titles)
+}
+
 func TestOrganizationUser_EnableAuth(t *testing.T) {
 	st, tearDown := store.NewTest(t)
 	defer tearDown()
@@ -654,6 +862,222 @@ func TestOrganizationUser_EnableAuth(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestUpdateOrganizationUser_EnableAuth(t *testing.T) {
+	st, tearDown := store.NewTest(t)
+	defer tearDown()
+
+	srv := New(st, nil, testr.New(t))
+	srv.enableAuth = true
+
+	o := createDefaultOrg(t, srv, "u0")
+
+	u0Ctx := auth.AppendUserInfoToContext(context.Background(), auth.UserInfo{
+		UserID: "u0",
+	})
+	var projectIDs []string
+	for _, title := range []string{"p0", "p1"} {
+		p, err := srv.CreateProject(u0Ctx, &v1.CreateProjectRequest{
+			Title:               title,
+			OrganizationId:      o.OrganizationID,
+			KubernetesNamespace: title,
+		})
+		assert.NoError(t, err)
+		projectIDs = append(projectIDs, p.Id)
+	}
+
+	_, err := srv.CreateOrganizationUser(u0Ctx, &v1.CreateOrganizationUserRequest{
+		OrganizationId: o.OrganizationID,
+		UserId:         "u1",
+		Role:           v1.OrganizationRole_ORGANIZATION_ROLE_READER,
+	})
+	assert.NoError(t, err)
+	// "u1" is a member of the first project.
+	_, err = srv.CreateProjectUser(u0Ctx, &v1.CreateProjectUserRequest{
+		OrganizationId: o.OrganizationID,
+		ProjectId:      projectIDs[0],
+		UserId:         "u1",
+		Role:           v1.ProjectRole_PROJECT_ROLE_MEMBER,
+	})
+	assert.NoError(t, err)
+
+	u1Ctx := auth.AppendUserInfoToContext(context.Background(), auth.UserInfo{
+		UserID: "u1",
+	})
+	req := &v1.UpdateOrganizationUserRequest{
+		OrganizationId: o.OrganizationID,
+		UserId:         "u1",
+		Role:           v1.OrganizationRole_ORGANIZATION_ROLE_OWNER,
+	}
+	_, err = srv.UpdateOrganizationUser(u1Ctx, req)
+	assert.Error(t, err)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	ou, err := srv.UpdateOrganizationUser(u0Ctx, req)
+	assert.NoError(t, err)
+	assert.Equal(t, v1.OrganizationRole_ORGANIZATION_ROLE_OWNER, ou.Role)
+
+	// The user becomes an owner of all projects.
+	for _, pid := range projectIDs {
+		pu, err := st.GetProjectUser(pid, "u1")
+		assert.NoError(t, err)
+		assert.Equal(t, v1.ProjectRole_PROJECT_ROLE_OWNER.String(), pu.Role)
+	}
+
+	// Demote the user. The project roles are kept.
+	req.Role = v1.OrganizationRole_ORGANIZATION_ROLE_READER
+	ou, err = srv.UpdateOrganizationUser(u0Ctx, req)
+	assert.NoError(t, err)
+	assert.Equal(t, v1.OrganizationRole_ORGANIZATION_ROLE_READER, ou.Role)
+	pu, err := st.GetProjectUser(projectIDs[1], "u1")
+	assert.NoError(t, err)
+	assert.Equal(t, v1.ProjectRole_PROJECT_ROLE_OWNER.String(), pu.Role)
+
+	req.UserId = "u2"
+	_, err = srv.UpdateOrganizationUser(u0Ctx, req)
+	assert.Error(t, err)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	req.Role = v1.OrganizationRole_ORGANIZATION_ROLE_UNSPECIFIED
+	_, err = srv.UpdateOrganizationUser(u0Ctx, req)
+	assert.Error(t, err)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestOrganizationUser_LastOwner(t *testing.T) {
+	st, tearDown := store.NewTest(t)
+	defer tearDown()
+
+	srv := New(st, nil, testr.New(t))
+	srv.enableAuth = true
+
+	o := createDefaultOrg(t, srv, "u0")
+
+	u0Ctx := auth.AppendUserInfoToContext(context.Background(), auth.UserInfo{
+		UserID: "u0",
+	})
+	_, err := srv.CreateOrganizationUser(u0Ctx, &v1.CreateOrganizationUserRequest{
+		OrganizationId: o.OrganizationID,
+		UserId:         "u1",
+		Role:           v1.OrganizationRole_ORGANIZATION_ROLE_READER,
+	})
+	assert.NoError(t, err)
+
+	// Owners cannot grant the tenant-system role.
+	_, err = srv.CreateOrganizationUser(u0Ctx, &v1.CreateOrganizationUserRequest{
+		OrganizationId: o.OrganizationID,
+		UserId:         "sys",
+		Role:           v1.OrganizationRole_ORGANIZATION_ROLE_TENANT_SYSTEM,
+	})
+	assert.Error(t, err)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = srv.UpdateOrganizationUser(u0Ctx, &v1.UpdateOrganizationUserRequest{
+		OrganizationId: o.OrganizationID,
+		UserId:         "u1",
+		Role:           v1.OrganizationRole_ORGANIZATION_ROLE_TENANT_SYSTEM,
+	})
+	assert.Error(t, err)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = st.CreateOrganizationUser(o.OrganizationID, "sys", v1.OrganizationRole_ORGANIZATION_ROLE_TENANT_SYSTEM.String())
+	assert.NoError(t, err)
+
+	// "u0" is the only owner.
+	_, err = srv.UpdateOrganizationUser(u0Ctx, &v1.UpdateOrganizationUserRequest{
+		OrganizationId: o.OrganizationID,
+		UserId:         "u0",
+		Role:           v1.OrganizationRole_ORGANIZATION_ROLE_READER,
+	})
+	assert.Error(t, err)
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	_, err = srv.DeleteOrganizationUser(u0Ctx, &v1.DeleteOrganizationUserRequest{
+		OrganizationId: o.OrganizationID,
+		UserId:         "u0",
+	})
+	assert.Error(t, err)
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	// Removing a non-owner is allowed.
+	_, err = srv.DeleteOrganizationUser(u0Ctx, &v1.DeleteOrganizationUserRequest{
+		OrganizationId: o.OrganizationID,
+		UserId:         "u1",
+	})
+	assert.NoError(t, err)
+
+	// "u0" can leave once there is another owner.
+	_, err = srv.CreateOrganizationUser(u0Ctx, &v1.CreateOrganizationUserRequest{
+		OrganizationId: o.OrganizationID,
+		UserId:         "u1",
+		Role:           v1.OrganizationRole_ORGANIZATION_ROLE_OWNER,
+	})
+	assert.NoError(t, err)
+	_, err = srv.DeleteOrganizationUser(u0Ctx, &v1.DeleteOrganizationUserRequest{
+		OrganizationId: o.OrganizationID,
+		UserId:         "u0",
+	})
+	assert.NoError(t, err)
+
+	// A tenant-system user can remove the last owner.
+	sysCtx := auth.AppendUserInfoToContext(context.Background(), auth.UserInfo{
+		UserID: "sys",
+	})
+	_, err = srv.DeleteOrganizationUser(sysCtx, &v1.DeleteOrganizationUserRequest{
+		OrganizationId: o.OrganizationID,
+		UserId:         "u1",
+	})
+	assert.NoError(t, err)
+}
+
+func TestOrganizationUser_ConcurrentOwnerDemotion(t *testing.T) {
+	st, tearDown := store.NewTest(t)
+	defer tearDown()
+
+	srv := New(st, nil, testr.New(t))
+	srv.enableAuth = true
+
+	o := createDefaultOrg(t, srv, "u0")
+
+	u0Ctx := auth.AppendUserInfoToContext(context.Background(), auth.UserInfo{
+		UserID: "u0",
+	})
+	_, err := srv.CreateOrganizationUser(u0Ctx, &v1.CreateOrganizationUserRequest{
+		OrganizationId: o.OrganizationID,
+		UserId:         "u1",
+		Role:           v1.OrganizationRole_ORGANIZATION_ROLE_OWNER,
+	})
+	assert.NoError(t, err)
+
+	// Both owners demote themselves at the same time.
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i, uid := range []string{"u0", "u1"} {
+		wg.Add(1)
+		go func(i int, uid string) {
+			defer wg.Done()
+			ctx := auth.AppendUserInfoToContext(context.Background(), auth.UserInfo{
+				UserID: uid,
+			})
+			_, errs[i] = srv.UpdateOrganizationUser(ctx, &v1.UpdateOrganizationUserRequest{
+				OrganizationId: o.OrganizationID,
+				UserId:         uid,
+				Role:           v1.OrganizationRole_ORGANIZATION_ROLE_READER,
+			})
+		}(i, uid)
+	}
+	wg.Wait()
+
+	assert.False(t, errs[0] == nil && errs[1] == nil, "both owners were demoted")
+	err = st.Transaction(func(tx *gorm.DB) error {
+		n, err := store.CountOrganizationOwnersInTransaction(tx, o.OrganizationID)
+		if err != nil {
+			return err
+		}
+		assert.GreaterOrEqual(t, n, int64(1))
+		return nil
+	})
+	assert.NoError(t, err)
+}
+
 func TestInternalServiceListOrganiationUsers(t *testing.T) {
 	st, tearDown := store.NewTest(t)
 	defer tearDown()
@@ -682,6 +1106,29 @@ func TestInternalServiceListOrganiationUsers(t *testing.T) {
 	}
 }
 
+// deleteDefaultUser deletes the default user from the organization. It bypasses the server
+// as the default user is the last owner of the organization.
+func deleteDefaultUser(t *testing.T, st *store.S, orgID string) {
+	err := st.DeleteOrganizationUser(orgID, defaultUserID)
+	assert.NoError(t, err)
+}
+
+// createOrganizationAndProject creates an organization and a project in it.
+func createOrganizationAndProject(ctx context.Context, t *testing.T, srv *S) (*v1.Organization, *v1.Project) {
+	org, err := srv.CreateOrganization(ctx, &v1.CreateOrganizationRequest{
+		Title: "Test organization",
+	})
+	assert.NoError(t, err)
+
+	proj, err := srv.CreateProject(ctx, &v1.CreateProjectRequest{
+		Title:               "Test project",
+		OrganizationId:      org.Id,
+		KubernetesNamespace: "test",
+	})
+	assert.NoError(t, err)
+	return org, proj
+}
+
 func createDefaultOrg(t *testing.T, srv *S, userID string) *store.Organization {
 	c := &config.DefaultOrganizationConfig{
 		Title:   "default",
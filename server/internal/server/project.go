@@ -15,7 +15,6 @@ import (
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/proto"
-	"google.golang.org/protobuf/types/known/emptypb"
 	"gorm.io/gorm"
 	"k8s.io/apimachinery/pkg/api/validation"
 )
@@ -193,12 +192,9 @@ func (s *S) ListProjects(ctx context.Context, req *v1.ListProjectsRequest) (*v1.
 		}
 
 		if req.IncludeSummary {
-			numUsers, err := s.store.CountProjectUsersByProjectID(p.ProjectID)
+			pProto.Summary, err = s.projectSummary(p.ProjectID)
 			if err != nil {
-				return nil, status.Errorf(codes.Internal, "count project users by project ID: %s", err)
-			}
-			pProto.Summary = &v1.Project_Summary{
-				UserCount: int32(numUsers),
+				return nil, err
 			}
 		}
 
@@ -209,6 +205,66 @@ func (s *S) ListProjects(ctx context.Context, req *v1.ListProjectsRequest) (*v1.
 	}, nil
 }
 
+// GetProject gets a project.
+func (s *S) GetProject(ctx context.Context, req *v1.GetProjectRequest) (*v1.Project, error) {
+	userInfo, ok := auth.ExtractUserInfoFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("failed to extract user info from context")
+	}
+
+	if req.OrganizationId == "" {
+		return nil, status.Error(codes.InvalidArgument, "organization id is required")
+	}
+	if req.Id == "" {
+		return nil, status.Error(codes.InvalidArgument, "project id is required")
+	}
+
+	if _, err := validateOrganizationID(s.store, req.OrganizationId, userInfo.TenantID); err != nil {
+		return nil, err
+	}
+
+	p, err := s.store.GetProject(store.GetProjectParams{
+		TenantID:       userInfo.TenantID,
+		OrganizationID: req.OrganizationId,
+		ProjectID:      req.Id,
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.NotFound, "project %q not found", req.Id)
+		}
+		return nil, status.Errorf(codes.Internal, "get project: %s", err)
+	}
+	if err := s.validateProjectMember(p.ProjectID, p.OrganizationID, userInfo.UserID); err != nil {
+		// Do not reveal the existence of the project to the user who is not a member.
+		if status.Code(err) == codes.FailedPrecondition {
+			return nil, status.Errorf(codes.NotFound, "project %q not found", req.Id)
+		}
+		return nil, err
+	}
+
+	pProto, err := p.ToProto()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "convert project to proto: %s", err)
+	}
+	if req.IncludeSummary {
+		pProto.Summary, err = s.projectSummary(p.ProjectID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return pProto, nil
+}
+
+func (s *S) projectSummary(projectID string) (*v1.Project_Summary, error) {
+	numUsers, err := s.store.CountProjectUsersByProjectID(projectID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "count project users by project ID: %s", err)
+	}
+	return &v1.Project_Summary{
+		UserCount: int32(numUsers),
+	}, nil
+}
+
 // DeleteProject deletes an project.
 func (s *S) DeleteProject(ctx context.Context, req *v1.DeleteProjectRequest) (*v1.DeleteProjectResponse, error) {
 	userInfo, ok := auth.ExtractUserInfoFromContext(ctx)
@@ -418,7 +474,7 @@ func (s *S) ListProjectUsers(ctx context.Context, req *v1.ListProjectUsersReques
 }
 
 // DeleteProjectUser deletes an project user.
-func (s *S) DeleteProjectUser(ctx context.Context, req *v1.DeleteProjectUserRequest) (*emptypb.Empty, error) {
+func (s *S) DeleteProjectUser(ctx context.Context, req *v1.DeleteProjectUserRequest) (*v1.DeleteProjectUserResponse, error) {
 	userInfo, ok := auth.ExtractUserInfoFromContext(ctx)
 	if !ok {
 		return nil, fmt.Errorf("failed to extract user info from context")
@@ -438,19 +494,118 @@ func (s *S) DeleteProjectUser(ctx context.Context, req *v1.DeleteProjectUserRequ
 		return nil, err
 	}
 
-	if err := s.validateProjectOwner(req.ProjectId, req.OrganizationId, userInfo.UserID); err != nil {
-		return nil, err
+	override := s.canRemoveLastOwner(req.OrganizationId, userInfo.UserID)
+	if !override {
+		if err := s.validateProjectOwner(req.ProjectId, req.OrganizationId, userInfo.UserID); err != nil {
+			return nil, err
+		}
 	}
 
 	userID := userid.Normalize(req.UserId)
-	if err := s.store.DeleteProjectUser(req.ProjectId, userID); err != nil {
+	role, err := findProjectRole(s.store, req.ProjectId, userID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "find project role: %s", err)
+	}
+	checkOwner := !override && role == v1.ProjectRole_PROJECT_ROLE_OWNER
+
+	// The API keys of the user in the project are revoked together.
+	var revokedIDs, disabledIDs []string
+	if err := s.store.Transaction(func(tx *gorm.DB) error {
+		if err := store.DeleteProjectUserInTransaction(tx, req.ProjectId, userID); err != nil {
+			return err
+		}
+		if checkOwner {
+			if err := validateProjectHasOwnerInTransaction(tx, req.ProjectId); err != nil {
+				return err
+			}
+		}
+		var err error
+		if req.DisableApiKeys {
+			disabledIDs, err = store.DisableAPIKeysByUserIDInTransaction(tx, req.OrganizationId, req.ProjectId, userID)
+			return err
+		}
+		revokedIDs, err = store.DeleteAPIKeysByUserIDInTransaction(tx, req.OrganizationId, req.ProjectId, userID)
+		return err
+	}); err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, status.Errorf(codes.NotFound, "project user not found")
 		}
+		if status.Code(err) == codes.FailedPrecondition {
+			return nil, err
+		}
 		return nil, status.Errorf(codes.Internal, "delete project user: %s", err)
 	}
 
-	return &emptypb.Empty{}, nil
+	return &v1.DeleteProjectUserResponse{
+		Id:                userID,
+		Object:            "project.user",
+		Deleted:           true,
+		RevokedApiKeyIds:  revokedIDs,
+		DisabledApiKeyIds: disabledIDs,
+	}, nil
+}
+
+// UpdateProjectUser changes the role of a user in a project.
+func (s *S) UpdateProjectUser(ctx context.Context, req *v1.UpdateProjectUserRequest) (*v1.ProjectUser, error) {
+	userInfo, ok := auth.ExtractUserInfoFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("failed to extract user info from context")
+	}
+
+	if req.ProjectId == "" {
+		return nil, status.Error(codes.InvalidArgument, "project id is required")
+	}
+	if req.OrganizationId == "" {
+		return nil, status.Error(codes.InvalidArgument, "organization id is required")
+	}
+	if req.UserId == "" {
+		return nil, status.Error(codes.InvalidArgument, "user id is required")
+	}
+	if req.Role == v1.ProjectRole_PROJECT_ROLE_UNSPECIFIED {
+		return nil, status.Error(codes.InvalidArgument, "role is required")
+	}
+
+	if _, err := validateProjectID(s.store, req.ProjectId, req.OrganizationId, userInfo.TenantID); err != nil {
+		return nil, err
+	}
+
+	override := s.canRemoveLastOwner(req.OrganizationId, userInfo.UserID)
+	if !override {
+		if err := s.validateProjectOwner(req.ProjectId, req.OrganizationId, userInfo.UserID); err != nil {
+			return nil, err
+		}
+	}
+
+	userID := userid.Normalize(req.UserId)
+	role, err := findProjectRole(s.store, req.ProjectId, userID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "find project role: %s", err)
+	}
+	checkOwner := !override && role == v1.ProjectRole_PROJECT_ROLE_OWNER
+
+	if err := s.store.Transaction(func(tx *gorm.DB) error {
+		if err := store.UpdateProjectUserRoleInTransaction(tx, req.ProjectId, userID, req.Role); err != nil {
+			return err
+		}
+		if !checkOwner {
+			return nil
+		}
+		return validateProjectHasOwnerInTransaction(tx, req.ProjectId)
+	}); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.NotFound, "project user %q not found", userID)
+		}
+		if status.Code(err) == codes.FailedPrecondition {
+			return nil, err
+		}
+		return nil, status.Errorf(codes.Internal, "update project user: %s", err)
+	}
+
+	pu, err := s.store.GetProjectUser(req.ProjectId, userID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "get project user: %s", err)
+	}
+	return pu.ToProto(), nil
 }
 
 // validateProjectID validates that:
@@ -476,6 +631,24 @@ func validateProjectID(st *store.S, projectID, orgID, tenantID string) (*store.P
 	return p, nil
 }
 
+// validateProjectHasOwnerInTransaction checks if the project still has an owner.
+// This is called after removing or demoting an owner in the same transaction. The project row
+// is locked before the owners are counted so that concurrent transactions removing different owners
+// are serialized, and the later one observes the removal committed by the earlier one.
+func validateProjectHasOwnerInTransaction(tx *gorm.DB, projectID string) error {
+	if err := store.LockProjectInTransaction(tx, projectID); err != nil {
+		return fmt.Errorf("lock project: %s", err)
+	}
+	n, err := store.CountProjectOwnersInTransaction(tx, projectID)
+	if err != nil {
+		return fmt.Errorf("count project owners: %s", err)
+	}
+	if n == 0 {
+		return status.Errorf(codes.FailedPrecondition, "project %q must have at least one owner", projectID)
+	}
+	return nil
+}
+
 // CreateDefaultProject creates the default org.
 // TODO(kenji): This is not the best place for this function as there is nothing related to
 // the server itself.
@@ -514,11 +687,36 @@ func (s *S) CreateDefaultProject(ctx context.Context, c *config.DefaultProjectCo
 	return p, nil
 }
 
-// ListProjects lists all projects.
+// ListProjects lists all projects, or the ones that have changed after the given revision.
 func (s *IS) ListProjects(ctx context.Context, req *v1.ListProjectsRequest) (*v1.ListProjectsResponse, error) {
-	projects, err := s.store.ListAllProjects()
+	rev, err := s.latestRevision(req.SinceRevision)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "list projects: %s", err)
+		return nil, err
+	}
+
+	var (
+		projects   []*store.Project
+		deletedIDs []string
+	)
+	if req.SinceRevision == 0 {
+		projects, err = s.store.ListAllProjects()
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "list projects: %s", err)
+		}
+	} else {
+		ids, err := s.listChangedResourceIDs(store.ResourceKindProject, req.SinceRevision)
+		if err != nil {
+			return nil, err
+		}
+		projects, err = s.store.ListProjectsByIDs(ids)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "list projects: %s", err)
+		}
+		found := map[string]bool{}
+		for _, p := range projects {
+			found[p.ProjectID] = true
+		}
+		deletedIDs = tombstones(ids, found)
 	}
 
 	var pProtos []*v1.Project
@@ -530,22 +728,77 @@ func (s *IS) ListProjects(ctx context.Context, req *v1.ListProjectsRequest) (*v1
 		pProtos = append(pProtos, pp)
 	}
 	return &v1.ListProjectsResponse{
-		Projects: pProtos,
+		Projects:          pProtos,
+		Revision:          rev,
+		DeletedProjectIds: deletedIDs,
 	}, nil
 }
 
-// ListProjectUsers lists project users for all projects.
+// ListProjectUsers lists project users for all projects, or the ones that have changed after the given revision.
 func (s *IS) ListProjectUsers(ctx context.Context, req *v1.ListProjectUsersRequest) (*v1.ListProjectUsersResponse, error) {
-	users, err := s.store.ListAllProjectUsers()
+	rev, err := s.latestRevision(req.SinceRevision)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.SinceRevision == 0 {
+		users, err := s.store.ListAllProjectUsers()
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "list project users: %s", err)
+		}
+
+		var userProtos []*v1.ProjectUser
+		for _, user := range users {
+			userProtos = append(userProtos, user.ToProto())
+		}
+		return &v1.ListProjectUsersResponse{
+			Users:    userProtos,
+			Revision: rev,
+		}, nil
+	}
+
+	ids, err := s.listChangedResourceIDs(store.ResourceKindProjectUser, req.SinceRevision)
+	if err != nil {
+		return nil, err
+	}
+	changed := map[string]bool{}
+	var projectIDs []string
+	for _, id := range ids {
+		projectID, _, err := store.ParseMembershipResourceID(id)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "parse resource ID: %s", err)
+		}
+		changed[id] = true
+		projectIDs = append(projectIDs, projectID)
+	}
+	users, err := s.store.ListProjectUsersByProjectIDs(projectIDs)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "list project users: %s", err)
 	}
 
 	var userProtos []*v1.ProjectUser
+	found := map[string]bool{}
 	for _, user := range users {
+		id := store.MembershipResourceID(user.ProjectID, user.UserID)
+		if !changed[id] {
+			continue
+		}
+		found[id] = true
 		userProtos = append(userProtos, user.ToProto())
 	}
+
+	var deletedProtos []*v1.ProjectUser
+	for _, id := range tombstones(ids, found) {
+		// The ID has already been validated above.
+		projectID, userID, _ := store.ParseMembershipResourceID(id)
+		deletedProtos = append(deletedProtos, &v1.ProjectUser{
+			ProjectId: projectID,
+			UserId:    userID,
+		})
+	}
 	return &v1.ListProjectUsersResponse{
-		Users: userProtos,
+		Users:        userProtos,
+		Revision:     rev,
+		DeletedUsers: deletedProtos,
 	}, nil
 }
@@ -3,6 +3,7 @@ package server
 import (
 	"context"
 	"fmt"
+	"sync"
 	"testing"
 
 	"github.com/go-logr/logr/testr"
@@ -16,6 +17,7 @@ import (
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"gorm.io/gorm"
 )
 
 func TestProject(t *testing.T) {
@@ -121,11 +123,7 @@ func TestProjectUser(t *testing.T) {
 	assert.NoError(t, err)
 
 	// Delete the default user to make the rest of the test simple.
-	_, err = srv.DeleteOrganizationUser(ctx, &v1.DeleteOrganizationUserRequest{
-		OrganizationId: org.Id,
-		UserId:         defaultUserID,
-	})
-	assert.NoError(t, err)
+	deleteDefaultUser(t, st, org.Id)
 
 	proj, err := srv.CreateProject(ctx, &v1.CreateProjectRequest{
 		Title:               "Test project",
@@ -147,7 +145,7 @@ func TestProjectUser(t *testing.T) {
 		ProjectId:      proj.Id,
 		OrganizationId: org.Id,
 		UserId:         "u1",
-		Role:           v1.ProjectRole_PROJECT_ROLE_MEMBER,
+		Role:           v1.ProjectRole_PROJECT_ROLE_OWNER,
 	})
 	assert.NoError(t, err)
 	assert.Equal(t, "u1", pu1.UserId)
@@ -179,6 +177,124 @@ func TestProjectUser(t *testing.T) {
 	assert.Equal(t, pu1.UserId, resp.Users[0].UserId)
 }
 
+func TestDeleteProjectUser_RevokeAPIKeys(t *testing.T) {
+	st, tearDown := store.NewTest(t)
+	defer tearDown()
+
+	srv := New(st, nil, testr.New(t))
+	isrv := NewInternal(st, nil, testr.New(t))
+
+	ctx := fakeAuthInto(context.Background())
+	org, err := srv.CreateOrganization(ctx, &v1.CreateOrganizationRequest{
+		Title: "Test organization",
+	})
+	assert.NoError(t, err)
+
+	var (
+		projs  []*v1.Project
+		keyIDs []string
+	)
+	for _, title := range []string{"p0", "p1"} {
+		p, err := srv.CreateProject(ctx, &v1.CreateProjectRequest{
+			Title:               title,
+			OrganizationId:      org.Id,
+			KubernetesNamespace: "test",
+		})
+		assert.NoError(t, err)
+		projs = append(projs, p)
+		k, err := srv.CreateAPIKey(ctx, &v1.CreateAPIKeyRequest{
+			Name:           title,
+			OrganizationId: org.Id,
+			ProjectId:      p.Id,
+		})
+		assert.NoError(t, err)
+		keyIDs = append(keyIDs, k.Id)
+	}
+
+	addProjectOwner(t, st, org.Id, projs[0].Id)
+
+	resp, err := srv.DeleteProjectUser(ctx, &v1.DeleteProjectUserRequest{
+		OrganizationId: org.Id,
+		ProjectId:      projs[0].Id,
+		UserId:         defaultUserID,
+	})
+	assert.NoError(t, err)
+	assert.True(t, resp.Deleted)
+	assert.Equal(t, []string{keyIDs[0]}, resp.RevokedApiKeyIds)
+
+	lresp, err := isrv.ListInternalAPIKeys(ctx, &v1.ListInternalAPIKeysRequest{})
+	assert.NoError(t, err)
+	assert.Len(t, lresp.ApiKeys, 1)
+	assert.Equal(t, keyIDs[1], lresp.ApiKeys[0].ApiKey.Id)
+
+	// The user has already been removed.
+	_, err = srv.DeleteProjectUser(ctx, &v1.DeleteProjectUserRequest{
+		OrganizationId: org.Id,
+		ProjectId:      projs[0].Id,
+		UserId:         defaultUserID,
+	})
+	assert.Error(t, err)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestDeleteProjectUser_DisableAPIKeys(t *testing.T) {
+	st, tearDown := store.NewTest(t)
+	defer tearDown()
+
+	srv := New(st, nil, testr.New(t))
+	isrv := NewInternal(st, nil, testr.New(t))
+
+	ctx := fakeAuthInto(context.Background())
+	org, err := srv.CreateOrganization(ctx, &v1.CreateOrganizationRequest{
+		Title: "Test organization",
+	})
+	assert.NoError(t, err)
+
+	proj, err := srv.CreateProject(ctx, &v1.CreateProjectRequest{
+		Title:               "Test project",
+		OrganizationId:      org.Id,
+		KubernetesNamespace: "test",
+	})
+	assert.NoError(t, err)
+	key, err := srv.CreateAPIKey(ctx, &v1.CreateAPIKeyRequest{
+		Name:           "k0",
+		OrganizationId: org.Id,
+		ProjectId:      proj.Id,
+	})
+	assert.NoError(t, err)
+
+	addProjectOwner(t, st, org.Id, proj.Id)
+
+	resp, err := srv.DeleteProjectUser(ctx, &v1.DeleteProjectUserRequest{
+		OrganizationId: org.Id,
+		ProjectId:      proj.Id,
+		UserId:         defaultUserID,
+		DisableApiKeys: true,
+	})
+	assert.NoError(t, err)
+	assert.Empty(t, resp.RevokedApiKeyIds)
+	assert.Equal(t, []string{key.Id}, resp.DisabledApiKeyIds)
+
+	lresp, err := isrv.ListInternalAPIKeys(ctx, &v1.ListInternalAPIKeysRequest{})
+	assert.NoError(t, err)
+	assert.Empty(t, lresp.ApiKeys)
+
+	k, err := st.GetAPIKeyByID(key.Id)
+	assert.NoError(t, err)
+	assert.True(t, k.Disabled)
+
+	// The key cannot be enabled while its owner is not a member of the project.
+	key.Enabled = true
+	_, err = srv.UpdateAPIKey(ctx, &v1.UpdateAPIKeyRequest{
+		ApiKey: key,
+		UpdateMask: &fieldmaskpb.FieldMask{
+			Paths: []string{"enabled"},
+		},
+	})
+	assert.Error(t, err)
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
+
 func TestCreateProject_UniqueConstraintViolation(t *testing.T) {
 	st, tearDown := store.NewTest(t)
 	defer tearDown()
@@ -257,11 +373,7 @@ func TestCreateDefaultProject(t *testing.T) {
 	assert.NoError(t, err)
 
 	// Delete the default user to make the rest of the test simple.
-	_, err = srv.DeleteOrganizationUser(ctx, &v1.DeleteOrganizationUserRequest{
-		OrganizationId: org.Id,
-		UserId:         defaultUserID,
-	})
-	assert.NoError(t, err)
+	deleteDefaultUser(t, st, org.Id)
 
 	_, err = srv.CreateOrganizationUser(ctx, &v1.CreateOrganizationUserRequest{
 		OrganizationId: org.Id,
@@ -536,6 +648,76 @@ func TestListProjects_EnableAuth(t *testing.T) {
 	assert.Empty(t, resp.Projects)
 }
 
+func TestGetProject_EnableAuth(t *testing.T) {
+	st, tearDown := store.NewTest(t)
+	defer tearDown()
+
+	srv := New(st, nil, testr.New(t))
+	srv.enableAuth = true
+
+	o := createDefaultOrg(t, srv, "u0")
+
+	u0Ctx := auth.AppendUserInfoToContext(context.Background(), auth.UserInfo{
+		UserID: "u0",
+	})
+	p0, err := srv.CreateProject(u0Ctx, &v1.CreateProjectRequest{
+		Title:               "title",
+		OrganizationId:      o.OrganizationID,
+		KubernetesNamespace: "n0",
+	})
+	assert.NoError(t, err)
+
+	for _, uid := range []string{"u1", "u2"} {
+		_, err = srv.CreateOrganizationUser(u0Ctx, &v1.CreateOrganizationUserRequest{
+			OrganizationId: o.OrganizationID,
+			UserId:         uid,
+			Role:           v1.OrganizationRole_ORGANIZATION_ROLE_READER,
+		})
+		assert.NoError(t, err)
+	}
+	_, err = srv.CreateProjectUser(u0Ctx, &v1.CreateProjectUserRequest{
+		ProjectId:      p0.Id,
+		OrganizationId: o.OrganizationID,
+		UserId:         "u1",
+		Role:           v1.ProjectRole_PROJECT_ROLE_MEMBER,
+	})
+	assert.NoError(t, err)
+
+	// "u0" is an organization owner, and "u1" is a project member.
+	for _, uid := range []string{"u0", "u1"} {
+		ctx := auth.AppendUserInfoToContext(context.Background(), auth.UserInfo{
+			UserID: uid,
+		})
+		got, err := srv.GetProject(ctx, &v1.GetProjectRequest{
+			OrganizationId: o.OrganizationID,
+			Id:             p0.Id,
+			IncludeSummary: true,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "title", got.Title)
+		assert.Equal(t, "n0", got.KubernetesNamespace)
+		assert.Equal(t, int32(2), got.Summary.UserCount)
+	}
+
+	// "u2" is not a member of the project.
+	u2Ctx := auth.AppendUserInfoToContext(context.Background(), auth.UserInfo{
+		UserID: "u2",
+	})
+	_, err = srv.GetProject(u2Ctx, &v1.GetProjectRequest{
+		OrganizationId: o.OrganizationID,
+		Id:             p0.Id,
+	})
+	assert.Error(t, err)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = srv.GetProject(u0Ctx, &v1.GetProjectRequest{
+		OrganizationId: o.OrganizationID,
+		Id:             "missing",
+	})
+	assert.Error(t, err)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
 func TestDeleteProject_EnableAuth(t *testing.T) {
 	st, tearDown := store.NewTest(t)
 	defer tearDown()
@@ -649,6 +831,185 @@ func TestProjectUser_EnableAuth(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestUpdateProjectUser_EnableAuth(t *testing.T) {
+	st, tearDown := store.NewTest(t)
+	defer tearDown()
+
+	srv := New(st, nil, testr.New(t))
+	srv.enableAuth = true
+
+	o := createDefaultOrg(t, srv, "u0")
+
+	u0Ctx := auth.AppendUserInfoToContext(context.Background(), auth.UserInfo{
+		UserID: "u0",
+	})
+	p, err := srv.CreateProject(u0Ctx, &v1.CreateProjectRequest{
+		Title:               "title",
+		OrganizationId:      o.OrganizationID,
+		KubernetesNamespace: "n0",
+	})
+	assert.NoError(t, err)
+
+	_, err = srv.CreateOrganizationUser(u0Ctx, &v1.CreateOrganizationUserRequest{
+		OrganizationId: o.OrganizationID,
+		UserId:         "u1",
+		Role:           v1.OrganizationRole_ORGANIZATION_ROLE_READER,
+	})
+	assert.NoError(t, err)
+	_, err = srv.CreateProjectUser(u0Ctx, &v1.CreateProjectUserRequest{
+		OrganizationId: o.OrganizationID,
+		ProjectId:      p.Id,
+		UserId:         "u1",
+		Role:           v1.ProjectRole_PROJECT_ROLE_MEMBER,
+	})
+	assert.NoError(t, err)
+
+	u1Ctx := auth.AppendUserInfoToContext(context.Background(), auth.UserInfo{
+		UserID: "u1",
+	})
+	req := &v1.UpdateProjectUserRequest{
+		OrganizationId: o.OrganizationID,
+		ProjectId:      p.Id,
+		UserId:         "u1",
+		Role:           v1.ProjectRole_PROJECT_ROLE_OWNER,
+	}
+	_, err = srv.UpdateProjectUser(u1Ctx, req)
+	assert.Error(t, err)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	pu, err := srv.UpdateProjectUser(u0Ctx, req)
+	assert.NoError(t, err)
+	assert.Equal(t, v1.ProjectRole_PROJECT_ROLE_OWNER, pu.Role)
+
+	// "u1" can now manage the project.
+	req.Role = v1.ProjectRole_PROJECT_ROLE_MEMBER
+	pu, err = srv.UpdateProjectUser(u1Ctx, req)
+	assert.NoError(t, err)
+	assert.Equal(t, v1.ProjectRole_PROJECT_ROLE_MEMBER, pu.Role)
+
+	req.UserId = "u2"
+	_, err = srv.UpdateProjectUser(u0Ctx, req)
+	assert.Error(t, err)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestProjectUser_LastOwner(t *testing.T) {
+	st, tearDown := store.NewTest(t)
+	defer tearDown()
+
+	srv := New(st, nil, testr.New(t))
+	srv.enableAuth = true
+
+	o := createDefaultOrg(t, srv, "u0")
+
+	u0Ctx := auth.AppendUserInfoToContext(context.Background(), auth.UserInfo{
+		UserID: "u0",
+	})
+	p, err := srv.CreateProject(u0Ctx, &v1.CreateProjectRequest{
+		Title:               "title",
+		OrganizationId:      o.OrganizationID,
+		KubernetesNamespace: "n0",
+	})
+	assert.NoError(t, err)
+
+	_, err = st.CreateOrganizationUser(o.OrganizationID, "sys", v1.OrganizationRole_ORGANIZATION_ROLE_TENANT_SYSTEM.String())
+	assert.NoError(t, err)
+
+	// "u0" is the only owner of the project as the owner of the organization.
+	_, err = srv.UpdateProjectUser(u0Ctx, &v1.UpdateProjectUserRequest{
+		OrganizationId: o.OrganizationID,
+		ProjectId:      p.Id,
+		UserId:         "u0",
+		Role:           v1.ProjectRole_PROJECT_ROLE_MEMBER,
+	})
+	assert.Error(t, err)
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	_, err = srv.DeleteProjectUser(u0Ctx, &v1.DeleteProjectUserRequest{
+		OrganizationId: o.OrganizationID,
+		ProjectId:      p.Id,
+		UserId:         "u0",
+	})
+	assert.Error(t, err)
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	pu, err := st.GetProjectUser(p.Id, "u0")
+	assert.NoError(t, err)
+	assert.Equal(t, v1.ProjectRole_PROJECT_ROLE_OWNER.String(), pu.Role)
+
+	// A tenant-system user can remove the last owner.
+	sysCtx := auth.AppendUserInfoToContext(context.Background(), auth.UserInfo{
+		UserID: "sys",
+	})
+	_, err = srv.DeleteProjectUser(sysCtx, &v1.DeleteProjectUserRequest{
+		OrganizationId: o.OrganizationID,
+		ProjectId:      p.Id,
+		UserId:         "u0",
+	})
+	assert.NoError(t, err)
+}
+
+func TestProjectUser_ConcurrentOwnerDemotion(t *testing.T) {
+	st, tearDown := store.NewTest(t)
+	defer tearDown()
+
+	srv := New(st, nil, testr.New(t))
+	srv.enableAuth = true
+
+	o := createDefaultOrg(t, srv, "u0")
+
+	u0Ctx := auth.AppendUserInfoToContext(context.Background(), auth.UserInfo{
+		UserID: "u0",
+	})
+	p, err := srv.CreateProject(u0Ctx, &v1.CreateProjectRequest{
+		Title:               "title",
+		OrganizationId:      o.OrganizationID,
+		KubernetesNamespace: "n0",
+	})
+	assert.NoError(t, err)
+	_, err = srv.CreateOrganizationUser(u0Ctx, &v1.CreateOrganizationUserRequest{
+		OrganizationId: o.OrganizationID,
+		UserId:         "u1",
+		Role:           v1.OrganizationRole_ORGANIZATION_ROLE_READER,
+	})
+	assert.NoError(t, err)
+	_, err = srv.CreateProjectUser(u0Ctx, &v1.CreateProjectUserRequest{
+		OrganizationId: o.OrganizationID,
+		ProjectId:      p.Id,
+		UserId:         "u1",
+		Role:           v1.ProjectRole_PROJECT_ROLE_OWNER,
+	})
+	assert.NoError(t, err)
+
+	// Both owners are demoted at the same time.
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i, uid := range []string{"u0", "u1"} {
+		wg.Add(1)
+		go func(i int, uid string) {
+			defer wg.Done()
+			_, errs[i] = srv.UpdateProjectUser(u0Ctx, &v1.UpdateProjectUserRequest{
+				OrganizationId: o.OrganizationID,
+				ProjectId:      p.Id,
+				UserId:         uid,
+				Role:           v1.ProjectRole_PROJECT_ROLE_MEMBER,
+			})
+		}(i, uid)
+	}
+	wg.Wait()
+
+	assert.False(t, errs[0] == nil && errs[1] == nil, "both owners were demoted")
+	err = st.Transaction(func(tx *gorm.DB) error {
+		n, err := store.CountProjectOwnersInTransaction(tx, p.Id)
+		if err != nil {
+			return err
+		}
+		assert.GreaterOrEqual(t, n, int64(1))
+		return nil
+	})
+	assert.NoError(t, err)
+}
+
 func TestListProjectUsers_HiddenUser(t *testing.T) {
 	st, tearDown := store.NewTest(t)
 	defer tearDown()
@@ -662,11 +1023,7 @@ func TestListProjectUsers_HiddenUser(t *testing.T) {
 	assert.NoError(t, err)
 
 	// Delete the default user to make the rest of the test simple.
-	_, err = srv.DeleteOrganizationUser(ctx, &v1.DeleteOrganizationUserRequest{
-		OrganizationId: org.Id,
-		UserId:         defaultUserID,
-	})
-	assert.NoError(t, err)
+	deleteDefaultUser(t, st, org.Id)
 
 	proj, err := srv.CreateProject(ctx, &v1.CreateProjectRequest{
 		Title:               "Test project",
@@ -711,3 +1068,95 @@ func TestListProjectUsers_HiddenUser(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Len(t, resp.Users, 1)
 }
+
+func TestListProjectsAndProjectUsers_SinceRevision(t *testing.T) {
+	st, tearDown := store.NewTest(t)
+	defer tearDown()
+
+	srv := New(st, nil, testr.New(t))
+	isrv := NewInternal(st, nil, testr.New(t))
+
+	ctx := metadata.NewIncomingContext(fakeAuthInto(context.Background()), metadata.Pairs("Authorization", "dummy"))
+	org, err := srv.CreateOrganization(ctx, &v1.CreateOrganizationRequest{
+		Title: "Test organization",
+	})
+	assert.NoError(t, err)
+
+	proj0, err := srv.CreateProject(ctx, &v1.CreateProjectRequest{
+		Title:               "p0",
+		OrganizationId:      org.Id,
+		KubernetesNamespace: "test",
+	})
+	assert.NoError(t, err)
+
+	presp, err := isrv.ListProjects(ctx, &v1.ListProjectsRequest{})
+	assert.NoError(t, err)
+	assert.Len(t, presp.Projects, 1)
+	assert.Greater(t, presp.Revision, int64(0))
+	projRev := presp.Revision
+
+	uresp, err := isrv.ListProjectUsers(ctx, &v1.ListProjectUsersRequest{})
+	assert.NoError(t, err)
+	assert.Len(t, uresp.Users, 1)
+	userRev := uresp.Revision
+
+	// No change.
+	presp, err = isrv.ListProjects(ctx, &v1.ListProjectsRequest{SinceRevision: projRev})
+	assert.NoError(t, err)
+	assert.Empty(t, presp.Projects)
+	assert.Empty(t, presp.DeletedProjectIds)
+	assert.Equal(t, projRev, presp.Revision)
+
+	proj1, err := srv.CreateProject(ctx, &v1.CreateProjectRequest{
+		Title:               "p1",
+		OrganizationId:      org.Id,
+		KubernetesNamespace: "test",
+	})
+	assert.NoError(t, err)
+	_, err = srv.CreateProjectUser(ctx, &v1.CreateProjectUserRequest{
+		ProjectId:      proj1.Id,
+		OrganizationId: org.Id,
+		UserId:         "u0",
+		Role:           v1.ProjectRole_PROJECT_ROLE_MEMBER,
+	})
+	assert.NoError(t, err)
+	_, err = srv.DeleteProject(ctx, &v1.DeleteProjectRequest{
+		OrganizationId: org.Id,
+		Id:             proj0.Id,
+	})
+	assert.NoError(t, err)
+
+	presp, err = isrv.ListProjects(ctx, &v1.ListProjectsRequest{SinceRevision: projRev})
+	assert.NoError(t, err)
+	assert.Len(t, presp.Projects, 1)
+	assert.Equal(t, proj1.Id, presp.Projects[0].Id)
+	assert.Equal(t, []string{proj0.Id}, presp.DeletedProjectIds)
+	assert.Greater(t, presp.Revision, projRev)
+
+	uresp, err = isrv.ListProjectUsers(ctx, &v1.ListProjectUsersRequest{SinceRevision: userRev})
+	assert.NoError(t, err)
+	// The owner of proj1 and u0 have been added.
+	assert.Len(t, uresp.Users, 2)
+	for _, u := range uresp.Users {
+		assert.Equal(t, proj1.Id, u.ProjectId)
+	}
+	// The owner of proj0 has been removed with the project.
+	assert.Len(t, uresp.DeletedUsers, 1)
+	assert.Equal(t, proj0.Id, uresp.DeletedUsers[0].ProjectId)
+	assert.Equal(t, defaultUserID, uresp.DeletedUsers[0].UserId)
+
+	_, err = isrv.ListProjects(ctx, &v1.ListProjectsRequest{SinceRevision: presp.Revision + 1})
+	assert.Error(t, err)
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
+
+// addProjectOwner adds another owner to the project so that the default user can be removed from it.
+func addProjectOwner(t *testing.T, st *store.S, orgID, projectID string) {
+	_, err := st.CreateProjectUser(store.CreateProjectUserParams{
+		ProjectID:      projectID,
+		OrganizationID: orgID,
+		UserID:         "owner",
+		Role:           v1.ProjectRole_PROJECT_ROLE_OWNER,
+	})
+	assert.NoError(t, err)
+}
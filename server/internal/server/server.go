@@ -10,7 +10,9 @@ import (
 	"github.com/llmariner/api-usage/pkg/sender"
 	"github.com/llmariner/rbac-manager/pkg/auth"
 	v1 "github.com/llmariner/user-manager/api/v1"
+	"github.com/llmariner/user-manager/pkg/scope"
 	"github.com/llmariner/user-manager/server/internal/config"
+	"github.com/llmariner/user-manager/server/internal/invitation"
 	"github.com/llmariner/user-manager/server/internal/store"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
@@ -34,12 +36,18 @@ func New(
 	log logr.Logger,
 ) *S {
 	return &S{
-		store:   store,
-		dataKey: dataKey,
-		log:     log.WithName("grpc"),
+		store:            store,
+		dataKey:          dataKey,
+		invitationSender: invitation.NewLogSender(log),
+		log:              log.WithName("grpc"),
 	}
 }
 
+// SetInvitationSender sets the sender that delivers invitations.
+func (s *S) SetInvitationSender(sender invitation.Sender) {
+	s.invitationSender = sender
+}
+
 // S is a server.
 type S struct {
 	v1.UnimplementedUsersServiceServer
@@ -50,7 +58,11 @@ type S struct {
 	store   *store.S
 	log     logr.Logger
 
+	invitationSender invitation.Sender
+
 	enableAuth bool
+	// hashAPIKeySecrets indicates whether only the hashes of API key secrets are stored.
+	hashAPIKeySecrets bool
 }
 
 // Run starts the gRPC server.
@@ -68,20 +80,23 @@ func (s *S) Run(ctx context.Context, port int, authConfig config.AuthConfig, usa
 				ms := strings.Split(fullMethod, "/")
 				method := ms[len(ms)-1]
 				switch method {
-				case "CreateAPIKey", "DeleteAPIKey", "ListAPIKeys", "CreateProjectAPIKey", "DeleteProjectAPIKey", "ListProjectAPIKeys", "UpdateAPIKey":
-					return "api.organizations.projects.api_keys"
-				case "CreateOrganization", "DeleteOrganization", "ListOrganizations":
-					return "api.organizations"
-				case "CreateOrganizationUser", "DeleteOrganizationUser", "ListOrganizationUsers":
-					return "api.organizations.users"
-				case "CreateProject", "DeleteProject", "ListProjects", "UpdateProject":
-					return "api.organizations.projects"
-				case "CreateProjectUser", "DeleteProjectUser", "ListProjectUsers":
-					return "api.organizations.projects.users"
-				case "GetUserSelf":
-					return "api.selfuser"
+				case "CreateAPIKey", "DeleteAPIKey", "ListAPIKeys", "CreateProjectAPIKey", "DeleteProjectAPIKey", "ListProjectAPIKeys", "UpdateAPIKey", "RotateAPIKey", "TransferAPIKey", "ImportAPIKey":
+					return scope.ResourceAPIKeys
+				case "CreateOrganization", "DeleteOrganization", "GetOrganization", "ListOrganizations", "UpdateOrganization":
+					return scope.ResourceOrganizations
+				case "CreateOrganizationUser", "DeleteOrganizationUser", "ListOrganizationUsers", "UpdateOrganizationUser":
+					return scope.ResourceOrganizationUsers
+				case "CreateInvitation", "ListOrganizationInvitations", "RevokeInvitation":
+					return scope.ResourceOrganizationUsers
+				case "CreateProject", "DeleteProject", "GetProject", "ListProjects", "UpdateProject":
+					return scope.ResourceProjects
+				case "CreateProjectUser", "DeleteProjectUser", "ListProjectUsers", "UpdateProjectUser",
+					"CreateServiceAccount", "ListServiceAccounts", "GetServiceAccount", "UpdateServiceAccount", "DeleteServiceAccount":
+					return scope.ResourceProjectUsers
+				case "GetUserSelf", "ListInvitations", "AcceptInvitation", "DeclineInvitation":
+					return scope.ResourceSelfUser
 				case "ListUsers":
-					return "api.organizations.users"
+					return scope.ResourceOrganizationUsers
 				default:
 					return "unknown"
 				}
@@ -174,6 +189,28 @@ func (s *S) isOrganizationOwner(orgID, userID string) bool {
 	return s.organizationRole(orgID, userID) == v1.OrganizationRole_ORGANIZATION_ROLE_OWNER
 }
 
+// canRemoveLastOwner returns true if the user can remove or demote the last owner of the organization
+// and its projects.
+//
+// Only users with the tenant-system role can do so, and they can manage the members without being an owner.
+// This allows them to recover organizations whose owners have left.
+//
+// The invariant is kept even if the authorization is disabled.
+func (s *S) canRemoveLastOwner(orgID, userID string) bool {
+	return s.organizationRole(orgID, userID) == v1.OrganizationRole_ORGANIZATION_ROLE_TENANT_SYSTEM
+}
+
+// validateAssignableOrganizationRole checks if the role can be assigned through the user-facing APIs.
+//
+// The tenant-system role can be granted only by the system (e.g., to the default API keys) as it allows
+// its holders to remove the last owner of an organization.
+func validateAssignableOrganizationRole(role v1.OrganizationRole) error {
+	if role == v1.OrganizationRole_ORGANIZATION_ROLE_TENANT_SYSTEM {
+		return status.Errorf(codes.InvalidArgument, "role %q cannot be assigned", role.String())
+	}
+	return nil
+}
+
 // validateOrganizationOwner checks if the user has the permission to manage the organization.
 //
 // If the authorization is enabled, this passes only when the user is an owner of the organization.
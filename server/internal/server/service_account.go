@@ -0,0 +1,457 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	gerrors "github.com/llmariner/common/pkg/gormlib/errors"
+	"github.com/llmariner/common/pkg/id"
+	"github.com/llmariner/rbac-manager/pkg/auth"
+	v1 "github.com/llmariner/user-manager/api/v1"
+	"github.com/llmariner/user-manager/pkg/serviceaccount"
+	"github.com/llmariner/user-manager/server/internal/store"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+const maxServiceAccountNameLength = 63
+
+// serviceAccountNameRE restricts the names of service accounts as they are embedded in user IDs.
+var serviceAccountNameRE = regexp.MustCompile(`^[a-zA-Z0-9][-a-zA-Z0-9_.]*$`)
+
+// CreateServiceAccount creates a service account in a project.
+func (s *S) CreateServiceAccount(ctx context.Context, req *v1.CreateServiceAccountRequest) (*v1.ServiceAccount, error) {
+	userInfo, ok := auth.ExtractUserInfoFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("failed to extract user info from context")
+	}
+
+	if req.OrganizationId == "" {
+		return nil, status.Error(codes.InvalidArgument, "organization id is required")
+	}
+	if req.ProjectId == "" {
+		return nil, status.Error(codes.InvalidArgument, "project id is required")
+	}
+	if len(req.Name) > maxServiceAccountNameLength || !serviceAccountNameRE.MatchString(req.Name) {
+		return nil, status.Errorf(codes.InvalidArgument, "name must consist of at most %d alphanumeric characters, '-', '_', or '.', and start with an alphanumeric character", maxServiceAccountNameLength)
+	}
+	if req.OrganizationRole == v1.OrganizationRole_ORGANIZATION_ROLE_UNSPECIFIED {
+		return nil, status.Error(codes.InvalidArgument, "organization role is required")
+	}
+	if err := validateAssignableOrganizationRole(req.OrganizationRole); err != nil {
+		return nil, err
+	}
+	projectRole := req.ProjectRole
+	if projectRole == v1.ProjectRole_PROJECT_ROLE_UNSPECIFIED {
+		projectRole = v1.ProjectRole_PROJECT_ROLE_MEMBER
+	}
+
+	if _, err := validateProjectID(s.store, req.ProjectId, req.OrganizationId, userInfo.TenantID); err != nil {
+		return nil, err
+	}
+	if err := s.validateOrganizationOwner(req.OrganizationId, userInfo.UserID); err != nil {
+		return nil, err
+	}
+
+	saID, err := id.GenerateID("sa_", 24)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "generate service account id: %s", err)
+	}
+	userID := serviceaccount.UserID(userInfo.TenantID, req.ProjectId, req.Name)
+	// Do not take over the user of a service account that has been created together with an API key.
+	if _, err := s.store.GetUserByUserID(userID); err == nil {
+		return nil, status.Errorf(codes.AlreadyExists, "service account %q already exists", req.Name)
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, status.Errorf(codes.Internal, "get user: %s", err)
+	}
+
+	var sa *store.ServiceAccount
+	if err := s.store.Transaction(func(tx *gorm.DB) error {
+		if _, err := findOrCreateUserInTransaction(tx, userID); err != nil {
+			return err
+		}
+		if _, err := store.CreateOrganizationUserInTransaction(tx, req.OrganizationId, userID, req.OrganizationRole.String()); err != nil {
+			return err
+		}
+		if _, err := store.CreateProjectUserInTransaction(tx, store.CreateProjectUserParams{
+			ProjectID:      req.ProjectId,
+			OrganizationID: req.OrganizationId,
+			UserID:         userID,
+			Role:           projectRole,
+		}); err != nil {
+			return err
+		}
+		var err error
+		sa, err = store.CreateServiceAccountInTransaction(tx, store.CreateServiceAccountParams{
+			ServiceAccountID: saID,
+			TenantID:         userInfo.TenantID,
+			OrganizationID:   req.OrganizationId,
+			ProjectID:        req.ProjectId,
+			Name:             req.Name,
+			Description:      req.Description,
+			UserID:           userID,
+		})
+		return err
+	}); err != nil {
+		if gerrors.IsUniqueConstraintViolation(err) {
+			return nil, status.Errorf(codes.AlreadyExists, "service account %q already exists", req.Name)
+		}
+		return nil, status.Errorf(codes.Internal, "create service account: %s", err)
+	}
+
+	return sa.ToProto(req.OrganizationRole, projectRole, 0), nil
+}
+
+// ListServiceAccounts lists service accounts in a project.
+func (s *S) ListServiceAccounts(ctx context.Context, req *v1.ListServiceAccountsRequest) (*v1.ListServiceAccountsResponse, error) {
+	userInfo, ok := auth.ExtractUserInfoFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("failed to extract user info from context")
+	}
+
+	if req.OrganizationId == "" {
+		return nil, status.Error(codes.InvalidArgument, "organization id is required")
+	}
+	if req.ProjectId == "" {
+		return nil, status.Error(codes.InvalidArgument, "project id is required")
+	}
+
+	if _, err := validateProjectID(s.store, req.ProjectId, req.OrganizationId, userInfo.TenantID); err != nil {
+		return nil, err
+	}
+	if err := s.validateProjectMember(req.ProjectId, req.OrganizationId, userInfo.UserID); err != nil {
+		return nil, err
+	}
+
+	sas, err := s.store.ListServiceAccountsByProjectID(req.ProjectId)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "list service accounts: %s", err)
+	}
+	var userIDs []string
+	for _, sa := range sas {
+		userIDs = append(userIDs, sa.UserID)
+	}
+	counts, err := s.store.CountAPIKeysByUserIDs(userIDs)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "count api keys: %s", err)
+	}
+
+	var saProtos []*v1.ServiceAccount
+	for _, sa := range sas {
+		saProto, err := s.toServiceAccountProto(sa, counts[sa.UserID])
+		if err != nil {
+			return nil, err
+		}
+		saProtos = append(saProtos, saProto)
+	}
+	return &v1.ListServiceAccountsResponse{
+		Object: "list",
+		Data:   saProtos,
+	}, nil
+}
+
+// GetServiceAccount gets a service account.
+func (s *S) GetServiceAccount(ctx context.Context, req *v1.GetServiceAccountRequest) (*v1.ServiceAccount, error) {
+	userInfo, ok := auth.ExtractUserInfoFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("failed to extract user info from context")
+	}
+
+	sa, err := s.getServiceAccount(req.Id, req.ProjectId, req.OrganizationId, userInfo.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validateProjectMember(req.ProjectId, req.OrganizationId, userInfo.UserID); err != nil {
+		return nil, err
+	}
+
+	counts, err := s.store.CountAPIKeysByUserIDs([]string{sa.UserID})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "count api keys: %s", err)
+	}
+	return s.toServiceAccountProto(sa, counts[sa.UserID])
+}
+
+// UpdateServiceAccount updates the description or the roles of a service account. The API keys of
+// the service account are kept.
+func (s *S) UpdateServiceAccount(ctx context.Context, req *v1.UpdateServiceAccountRequest) (*v1.ServiceAccount, error) {
+	userInfo, ok := auth.ExtractUserInfoFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("failed to extract user info from context")
+	}
+
+	if req.ServiceAccount == nil {
+		return nil, status.Error(codes.InvalidArgument, "service account is required")
+	}
+	if req.UpdateMask == nil {
+		return nil, status.Error(codes.InvalidArgument, "update mask is required")
+	}
+	in := req.ServiceAccount
+
+	sa, err := s.getServiceAccount(in.Id, in.ProjectId, in.OrganizationId, userInfo.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	override := s.canRemoveLastOwner(in.OrganizationId, userInfo.UserID)
+	if !override {
+		if err := s.validateOrganizationOwner(in.OrganizationId, userInfo.UserID); err != nil {
+			return nil, err
+		}
+	}
+
+	checkOrgOwner, checkProjectOwner, err := s.serviceAccountOwnerChecks(sa, override)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := map[string]interface{}{}
+	var (
+		orgRole     v1.OrganizationRole
+		projectRole v1.ProjectRole
+	)
+	for _, path := range req.UpdateMask.Paths {
+		switch path {
+		case "description":
+			updates["description"] = in.Description
+		case "organization_role":
+			if in.OrganizationRole == v1.OrganizationRole_ORGANIZATION_ROLE_UNSPECIFIED {
+				return nil, status.Error(codes.InvalidArgument, "organization role is required")
+			}
+			if err := validateAssignableOrganizationRole(in.OrganizationRole); err != nil {
+				return nil, err
+			}
+			orgRole = in.OrganizationRole
+		case "project_role":
+			if in.ProjectRole == v1.ProjectRole_PROJECT_ROLE_UNSPECIFIED {
+				return nil, status.Error(codes.InvalidArgument, "project role is required")
+			}
+			projectRole = in.ProjectRole
+		default:
+			return nil, status.Errorf(codes.InvalidArgument, "unsupported update path: %s", path)
+		}
+	}
+
+	if err := s.store.Transaction(func(tx *gorm.DB) error {
+		if len(updates) > 0 {
+			if err := store.UpdateServiceAccountInTransaction(tx, sa.ServiceAccountID, updates); err != nil {
+				return fmt.Errorf("update service account: %s", err)
+			}
+		}
+		if orgRole != v1.OrganizationRole_ORGANIZATION_ROLE_UNSPECIFIED {
+			if err := store.UpdateOrganizationUserRoleInTransaction(tx, sa.OrganizationID, sa.UserID, orgRole.String()); err != nil {
+				return fmt.Errorf("update organization role: %s", err)
+			}
+		}
+		if projectRole != v1.ProjectRole_PROJECT_ROLE_UNSPECIFIED {
+			if err := store.UpdateProjectUserRoleInTransaction(tx, sa.ProjectID, sa.UserID, projectRole); err != nil {
+				return fmt.Errorf("update project role: %s", err)
+			}
+		}
+		return validateServiceAccountOwnersInTransaction(tx, sa, checkOrgOwner, checkProjectOwner)
+	}); err != nil {
+		if status.Code(err) == codes.FailedPrecondition {
+			return nil, err
+		}
+		return nil, status.Errorf(codes.Internal, "transaction: %s", err)
+	}
+
+	sa, err = s.store.GetServiceAccount(sa.ServiceAccountID, sa.ProjectID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "get service account: %s", err)
+	}
+	counts, err := s.store.CountAPIKeysByUserIDs([]string{sa.UserID})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "count api keys: %s", err)
+	}
+	return s.toServiceAccountProto(sa, counts[sa.UserID])
+}
+
+// DeleteServiceAccount deletes a service account together with its API keys.
+func (s *S) DeleteServiceAccount(ctx context.Context, req *v1.DeleteServiceAccountRequest) (*v1.DeleteServiceAccountResponse, error) {
+	userInfo, ok := auth.ExtractUserInfoFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("failed to extract user info from context")
+	}
+
+	sa, err := s.getServiceAccount(req.Id, req.ProjectId, req.OrganizationId, userInfo.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	override := s.canRemoveLastOwner(req.OrganizationId, userInfo.UserID)
+	if !override {
+		if err := s.validateOrganizationOwner(req.OrganizationId, userInfo.UserID); err != nil {
+			return nil, err
+		}
+	}
+
+	checkOrgOwner, checkProjectOwner, err := s.serviceAccountOwnerChecks(sa, override)
+	if err != nil {
+		return nil, err
+	}
+
+	var deletedKeyIDs []string
+	if err := s.store.Transaction(func(tx *gorm.DB) error {
+		var err error
+		deletedKeyIDs, err = store.DeleteAPIKeysByUserIDInTransaction(tx, sa.OrganizationID, "", sa.UserID)
+		if err != nil {
+			return fmt.Errorf("delete api keys: %s", err)
+		}
+		if err := store.DeleteProjectUserInTransaction(tx, sa.ProjectID, sa.UserID); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("delete project user: %s", err)
+		}
+		if err := store.DeleteOrganizationUserInTransaction(tx, sa.OrganizationID, sa.UserID); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("delete organization user: %s", err)
+		}
+		if err := store.DeleteUserInTransaction(tx, sa.UserID); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("delete user: %s", err)
+		}
+		if err := store.DeleteServiceAccountInTransaction(tx, sa.ServiceAccountID); err != nil {
+			return err
+		}
+		return validateServiceAccountOwnersInTransaction(tx, sa, checkOrgOwner, checkProjectOwner)
+	}); err != nil {
+		if status.Code(err) == codes.FailedPrecondition {
+			return nil, err
+		}
+		return nil, status.Errorf(codes.Internal, "transaction: %s", err)
+	}
+
+	return &v1.DeleteServiceAccountResponse{
+		Id:               sa.ServiceAccountID,
+		Object:           "organization.project.service_account",
+		Deleted:          true,
+		DeletedApiKeyIds: deletedKeyIDs,
+	}, nil
+}
+
+// MigrateServiceAccountUserIDs renames the users of service accounts that have user IDs in the legacy format
+// ("system:serviceaccount:<name>") so that the user IDs include the tenant and the project. Users that do not
+// belong to exactly one project or whose new user IDs are already taken are left unchanged as they cannot be
+// migrated automatically. They are reported in an error log so that operators can migrate them manually.
+func (s *S) MigrateServiceAccountUserIDs() error {
+	users, err := s.store.ListUsersByUserIDPrefix(serviceaccount.Prefix)
+	if err != nil {
+		return fmt.Errorf("list service account users: %s", err)
+	}
+	var skipped []string
+	skip := func(userID, reason string, kvs ...interface{}) {
+		s.log.Info("Skipped migrating the service account user", append([]interface{}{"userID", userID, "reason", reason}, kvs...)...)
+		skipped = append(skipped, userID)
+	}
+	for _, u := range users {
+		if _, err := serviceaccount.Parse(u.UserID); err == nil {
+			// Already migrated.
+			continue
+		}
+		pus, err := s.store.ListProjectUsersByUserID(u.UserID)
+		if err != nil {
+			return fmt.Errorf("list project users: %s", err)
+		}
+		if len(pus) != 1 {
+			skip(u.UserID, "the user does not belong to exactly one project", "projects", len(pus))
+			continue
+		}
+		ps, err := s.store.ListProjectsByIDs([]string{pus[0].ProjectID})
+		if err != nil {
+			return fmt.Errorf("list projects: %s", err)
+		}
+		if len(ps) != 1 {
+			skip(u.UserID, "the project of the user is not found", "projectID", pus[0].ProjectID)
+			continue
+		}
+		p := ps[0]
+
+		newUserID := serviceaccount.UserID(p.TenantID, p.ProjectID, strings.TrimPrefix(u.UserID, serviceaccount.Prefix))
+		if err := s.store.RenameUser(u.UserID, newUserID); err != nil {
+			if gerrors.IsUniqueConstraintViolation(err) {
+				skip(u.UserID, "the new user ID is already taken", "newUserID", newUserID)
+				continue
+			}
+			return fmt.Errorf("rename user %q: %s", u.UserID, err)
+		}
+		s.log.Info("Migrated the service account user", "oldUserID", u.UserID, "newUserID", newUserID)
+	}
+
+	if len(skipped) > 0 {
+		s.log.Error(
+			fmt.Errorf("%d service account users were not migrated", len(skipped)),
+			"Some service account users keep their legacy user IDs and need to be migrated manually",
+			"count", len(skipped),
+			"userIDs", skipped,
+		)
+	}
+	return nil
+}
+
+// getServiceAccount validates the request parameters and returns the service account.
+func (s *S) getServiceAccount(id, projectID, orgID, tenantID string) (*store.ServiceAccount, error) {
+	if orgID == "" {
+		return nil, status.Error(codes.InvalidArgument, "organization id is required")
+	}
+	if projectID == "" {
+		return nil, status.Error(codes.InvalidArgument, "project id is required")
+	}
+	if id == "" {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+
+	if _, err := validateProjectID(s.store, projectID, orgID, tenantID); err != nil {
+		return nil, err
+	}
+
+	sa, err := s.store.GetServiceAccount(id, projectID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.NotFound, "service account %q not found", id)
+		}
+		return nil, status.Errorf(codes.Internal, "get service account: %s", err)
+	}
+	return sa, nil
+}
+
+// serviceAccountOwnerChecks returns whether the organization and the project need to be checked for
+// remaining owners after the roles of the service account are changed or the service account is deleted.
+func (s *S) serviceAccountOwnerChecks(sa *store.ServiceAccount, override bool) (bool, bool, error) {
+	if override {
+		return false, false, nil
+	}
+	orgRole, err := findOrgRole(s.store, sa.OrganizationID, sa.UserID)
+	if err != nil {
+		return false, false, status.Errorf(codes.Internal, "find organization role: %s", err)
+	}
+	projectRole, err := findProjectRole(s.store, sa.ProjectID, sa.UserID)
+	if err != nil {
+		return false, false, status.Errorf(codes.Internal, "find project role: %s", err)
+	}
+	return orgRole == v1.OrganizationRole_ORGANIZATION_ROLE_OWNER, projectRole == v1.ProjectRole_PROJECT_ROLE_OWNER, nil
+}
+
+func validateServiceAccountOwnersInTransaction(tx *gorm.DB, sa *store.ServiceAccount, checkOrgOwner, checkProjectOwner bool) error {
+	if checkOrgOwner {
+		if err := validateOrganizationHasOwnerInTransaction(tx, sa.OrganizationID); err != nil {
+			return err
+		}
+	}
+	if checkProjectOwner {
+		if err := validateProjectHasOwnerInTransaction(tx, sa.ProjectID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *S) toServiceAccountProto(sa *store.ServiceAccount, apiKeyCount int64) (*v1.ServiceAccount, error) {
+	orgRole, err := findOrgRole(s.store, sa.OrganizationID, sa.UserID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "find organization role: %s", err)
+	}
+	projectRole, err := findProjectRole(s.store, sa.ProjectID, sa.UserID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "find project role: %s", err)
+	}
+	return sa.ToProto(orgRole, projectRole, apiKeyCount), nil
+}
@@ -0,0 +1,264 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/llmariner/rbac-manager/pkg/auth"
+	v1 "github.com/llmariner/user-manager/api/v1"
+	"github.com/llmariner/user-manager/pkg/serviceaccount"
+	"github.com/llmariner/user-manager/server/internal/store"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+func TestServiceAccount(t *testing.T) {
+	st, tearDown := store.NewTest(t)
+	defer tearDown()
+
+	srv := New(st, nil, testr.New(t))
+	srv.enableAuth = true
+	isrv := NewInternal(st, nil, testr.New(t))
+	org := createDefaultOrg(t, srv, "u0")
+
+	// "u0" is an owner of the organization. "u1" is a member of the project.
+	u0Ctx := auth.AppendUserInfoToContext(context.Background(), auth.UserInfo{
+		UserID: "u0",
+	})
+	proj, err := srv.CreateProject(u0Ctx, &v1.CreateProjectRequest{
+		Title:               "title",
+		OrganizationId:      org.OrganizationID,
+		KubernetesNamespace: "n0",
+	})
+	assert.NoError(t, err)
+
+	u1Ctx := auth.AppendUserInfoToContext(context.Background(), auth.UserInfo{
+		UserID: "u1",
+	})
+	_, err = srv.CreateOrganizationUser(u0Ctx, &v1.CreateOrganizationUserRequest{
+		OrganizationId: org.OrganizationID,
+		UserId:         "u1",
+		Role:           v1.OrganizationRole_ORGANIZATION_ROLE_READER,
+	})
+	assert.NoError(t, err)
+	_, err = srv.CreateProjectUser(u0Ctx, &v1.CreateProjectUserRequest{
+		OrganizationId: org.OrganizationID,
+		ProjectId:      proj.Id,
+		UserId:         "u1",
+		Role:           v1.ProjectRole_PROJECT_ROLE_MEMBER,
+	})
+	assert.NoError(t, err)
+
+	createReq := &v1.CreateServiceAccountRequest{
+		OrganizationId:   org.OrganizationID,
+		ProjectId:        proj.Id,
+		Name:             "ci",
+		Description:      "CI pipelines",
+		OrganizationRole: v1.OrganizationRole_ORGANIZATION_ROLE_READER,
+	}
+	_, err = srv.CreateServiceAccount(u1Ctx, createReq)
+	assert.Error(t, err)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	sa, err := srv.CreateServiceAccount(u0Ctx, createReq)
+	assert.NoError(t, err)
+	assert.Equal(t, "ci", sa.Name)
+	assert.Equal(t, serviceaccount.UserID("", proj.Id, "ci"), sa.UserId)
+	assert.Equal(t, v1.OrganizationRole_ORGANIZATION_ROLE_READER, sa.OrganizationRole)
+	assert.Equal(t, v1.ProjectRole_PROJECT_ROLE_MEMBER, sa.ProjectRole)
+
+	_, err = srv.CreateServiceAccount(u0Ctx, createReq)
+	assert.Error(t, err)
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	// Create two API keys for the service account.
+	var keys []*v1.APIKey
+	for _, name := range []string{"k0", "k1"} {
+		k, err := srv.CreateAPIKey(u0Ctx, &v1.CreateAPIKeyRequest{
+			Name:             name,
+			OrganizationId:   org.OrganizationID,
+			ProjectId:        proj.Id,
+			ServiceAccountId: sa.Id,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, sa.UserId, k.User.Id)
+		assert.True(t, k.User.IsServiceAccount)
+		keys = append(keys, k)
+	}
+
+	// A project member cannot create API keys for service accounts.
+	_, err = srv.CreateAPIKey(u1Ctx, &v1.CreateAPIKeyRequest{
+		Name:             "k2",
+		OrganizationId:   org.OrganizationID,
+		ProjectId:        proj.Id,
+		ServiceAccountId: sa.Id,
+	})
+	assert.Error(t, err)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	lresp, err := srv.ListServiceAccounts(u1Ctx, &v1.ListServiceAccountsRequest{
+		OrganizationId: org.OrganizationID,
+		ProjectId:      proj.Id,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, lresp.Data, 1)
+	assert.Equal(t, int32(2), lresp.Data[0].ApiKeyCount)
+
+	// Deleting an API key keeps the service account.
+	_, err = srv.DeleteProjectAPIKey(u0Ctx, &v1.DeleteProjectAPIKeyRequest{
+		Id:             keys[0].Id,
+		OrganizationId: org.OrganizationID,
+		ProjectId:      proj.Id,
+	})
+	assert.NoError(t, err)
+	got, err := srv.GetServiceAccount(u0Ctx, &v1.GetServiceAccountRequest{
+		OrganizationId: org.OrganizationID,
+		ProjectId:      proj.Id,
+		Id:             sa.Id,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), got.ApiKeyCount)
+
+	// Change the roles without recreating the API key.
+	got.OrganizationRole = v1.OrganizationRole_ORGANIZATION_ROLE_OWNER
+	got.ProjectRole = v1.ProjectRole_PROJECT_ROLE_OWNER
+	got.Description = "updated"
+	got, err = srv.UpdateServiceAccount(u0Ctx, &v1.UpdateServiceAccountRequest{
+		ServiceAccount: got,
+		UpdateMask: &fieldmaskpb.FieldMask{
+			Paths: []string{"organization_role", "project_role", "description"},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, v1.OrganizationRole_ORGANIZATION_ROLE_OWNER, got.OrganizationRole)
+	assert.Equal(t, v1.ProjectRole_PROJECT_ROLE_OWNER, got.ProjectRole)
+	assert.Equal(t, "updated", got.Description)
+
+	// The tenant-system role cannot be granted.
+	_, err = srv.UpdateServiceAccount(u0Ctx, &v1.UpdateServiceAccountRequest{
+		ServiceAccount: &v1.ServiceAccount{
+			Id:               sa.Id,
+			OrganizationId:   org.OrganizationID,
+			ProjectId:        proj.Id,
+			OrganizationRole: v1.OrganizationRole_ORGANIZATION_ROLE_TENANT_SYSTEM,
+		},
+		UpdateMask: &fieldmaskpb.FieldMask{
+			Paths: []string{"organization_role"},
+		},
+	})
+	assert.Error(t, err)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	ik, err := isrv.AuthenticateAPIKey(context.Background(), &v1.AuthenticateAPIKeyRequest{Secret: keys[1].Secret})
+	assert.NoError(t, err)
+	assert.Equal(t, v1.OrganizationRole_ORGANIZATION_ROLE_OWNER, ik.ApiKey.OrganizationRole)
+	assert.Equal(t, v1.ProjectRole_PROJECT_ROLE_OWNER, ik.ApiKey.ProjectRole)
+
+	dresp, err := srv.DeleteServiceAccount(u0Ctx, &v1.DeleteServiceAccountRequest{
+		OrganizationId: org.OrganizationID,
+		ProjectId:      proj.Id,
+		Id:             sa.Id,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{keys[1].Id}, dresp.DeletedApiKeyIds)
+
+	_, err = srv.GetServiceAccount(u0Ctx, &v1.GetServiceAccountRequest{
+		OrganizationId: org.OrganizationID,
+		ProjectId:      proj.Id,
+		Id:             sa.Id,
+	})
+	assert.Error(t, err)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = isrv.AuthenticateAPIKey(context.Background(), &v1.AuthenticateAPIKeyRequest{Secret: keys[1].Secret})
+	assert.Error(t, err)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = st.GetUserByUserID(sa.UserId)
+	assert.Error(t, err)
+}
+
+func TestMigrateServiceAccountUserIDs(t *testing.T) {
+	st, tearDown := store.NewTest(t)
+	defer tearDown()
+
+	srv := New(st, nil, testr.New(t))
+	srv.enableAuth = true
+	org := createDefaultOrg(t, srv, "u0")
+	ctx := auth.AppendUserInfoToContext(context.Background(), auth.UserInfo{
+		UserID: "u0",
+	})
+	proj, err := srv.CreateProject(ctx, &v1.CreateProjectRequest{
+		Title:               "title",
+		OrganizationId:      org.OrganizationID,
+		KubernetesNamespace: "n0",
+	})
+	assert.NoError(t, err)
+
+	// Create a service account user in the legacy format.
+	const legacyUserID = "system:serviceaccount:ci"
+	_, err = st.FindOrCreateUser(legacyUserID, "iu0")
+	assert.NoError(t, err)
+	_, err = st.CreateOrganizationUser(org.OrganizationID, legacyUserID, v1.OrganizationRole_ORGANIZATION_ROLE_READER.String())
+	assert.NoError(t, err)
+	_, err = st.CreateProjectUser(store.CreateProjectUserParams{
+		ProjectID:      proj.Id,
+		OrganizationID: org.OrganizationID,
+		UserID:         legacyUserID,
+		Role:           v1.ProjectRole_PROJECT_ROLE_OWNER,
+	})
+	assert.NoError(t, err)
+	_, err = st.CreateAPIKey(store.APIKeySpec{
+		APIKeyID:         "k0",
+		OrganizationID:   org.OrganizationID,
+		ProjectID:        proj.Id,
+		UserID:           legacyUserID,
+		IsServiceAccount: true,
+		Name:             "ci",
+	})
+	assert.NoError(t, err)
+
+	// A service account user that is not in any project is left unchanged.
+	const orphanUserID = "system:serviceaccount:orphan"
+	_, err = st.FindOrCreateUser(orphanUserID, "iu1")
+	assert.NoError(t, err)
+
+	// A service account user whose new user ID is already taken is left unchanged.
+	const conflictingUserID = "system:serviceaccount:dup"
+	_, err = st.FindOrCreateUser(conflictingUserID, "iu2")
+	assert.NoError(t, err)
+	_, err = st.CreateProjectUser(store.CreateProjectUserParams{
+		ProjectID:      proj.Id,
+		OrganizationID: org.OrganizationID,
+		UserID:         conflictingUserID,
+		Role:           v1.ProjectRole_PROJECT_ROLE_MEMBER,
+	})
+	assert.NoError(t, err)
+	_, err = st.FindOrCreateUser(serviceaccount.UserID(org.TenantID, proj.Id, "dup"), "iu3")
+	assert.NoError(t, err)
+
+	err = srv.MigrateServiceAccountUserIDs()
+	assert.NoError(t, err)
+
+	newUserID := serviceaccount.UserID(org.TenantID, proj.Id, "ci")
+	_, err = st.GetUserByUserID(newUserID)
+	assert.NoError(t, err)
+	_, err = st.GetUserByUserID(legacyUserID)
+	assert.Error(t, err)
+	_, err = st.GetProjectUser(proj.Id, newUserID)
+	assert.NoError(t, err)
+	k, err := st.GetAPIKeyByID("k0")
+	assert.NoError(t, err)
+	assert.Equal(t, newUserID, k.UserID)
+	_, err = st.GetUserByUserID(orphanUserID)
+	assert.NoError(t, err)
+	_, err = st.GetProjectUser(proj.Id, conflictingUserID)
+	assert.NoError(t, err)
+
+	// The migration is idempotent.
+	err = srv.MigrateServiceAccountUserIDs()
+	assert.NoError(t, err)
+	_, err = st.GetUserByUserID(newUserID)
+	assert.NoError(t, err)
+}
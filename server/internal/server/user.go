@@ -24,6 +24,9 @@ func (s *S) GetUserSelf(ctx context.Context, req *v1.GetUserSelfRequest) (*v1.Us
 		return nil, fmt.Errorf("failed to extract user info from context")
 	}
 
+	// Materialize the pending invitations so that the returned role bindings include them.
+	s.acceptPendingInvitations(userInfo)
+
 	orgUsers, err := s.store.ListOrganizationUsersByUserID(userInfo.UserID)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "list organization users: %s", err)
@@ -176,14 +179,38 @@ func (s *S) ListUsers(ctx context.Context, req *v1.ListUsersRequest) (*v1.ListUs
 	}, nil
 }
 
-// ListUsers lists all users.
+// ListUsers lists all users, or the ones that have changed after the given revision.
 func (s *IS) ListUsers(ctx context.Context, req *v1.ListUsersRequest) (*v1.ListUsersResponse, error) {
-	users, err := s.store.ListAllUsers()
+	rev, err := s.latestRevision(req.SinceRevision)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "list users: %s", err)
+		return nil, err
+	}
+
+	res := v1.ListUsersResponse{
+		Revision: rev,
+	}
+	var users []store.User
+	if req.SinceRevision == 0 {
+		users, err = s.store.ListAllUsers()
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "list users: %s", err)
+		}
+	} else {
+		ids, err := s.listChangedResourceIDs(store.ResourceKindUser, req.SinceRevision)
+		if err != nil {
+			return nil, err
+		}
+		users, err = s.store.ListUsersByUserIDs(ids)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "list users: %s", err)
+		}
+		found := map[string]bool{}
+		for _, u := range users {
+			found[u.UserID] = true
+		}
+		res.DeletedUserIds = tombstones(ids, found)
 	}
 
-	var res v1.ListUsersResponse
 	for _, u := range users {
 		res.Users = append(res.Users, &v1.User{
 			Id:               u.UserID,
@@ -1,6 +1,12 @@
 package store
 
 import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
 	"gorm.io/gorm"
 )
 
@@ -11,6 +17,10 @@ type APIKey struct {
 	APIKeyID string `gorm:"uniqueIndex"`
 
 	Name string `gorm:"uniqueIndex:idx_api_key_name_user_id"`
+	// Description is a free-form description of the API key.
+	Description string
+	// Labels is a JSON-encoded object of free-form key-value pairs. Its keys are sorted.
+	Labels string
 
 	TenantID string
 
@@ -21,12 +31,57 @@ type APIKey struct {
 	IsServiceAccount bool
 	// ExcludedFromRateLimiting indicates whether this API key is excluded from rate limiting
 	ExcludedFromRateLimiting bool
+	// Disabled is set to true if the API key is suspended. A disabled key cannot be used until it is enabled again.
+	Disabled bool `gorm:"default:false"`
 
 	// Secret is set when kms encryption is disabled.
 	Secret string
 	// EncryptedSecret is encrypted by data key, and it is set when kms encryption is enabled.
 	EncryptedSecret []byte
 
+	// SecretPrefix is the first few characters of the secret. It is used to look up candidate keys
+	// when verifying a secret and to display an obfuscated secret.
+	SecretPrefix string `gorm:"index"`
+	// SecretHash is the salted SHA-256 hash of the secret. It is set when secret hashing is enabled.
+	// Secret and EncryptedSecret are empty in that case.
+	SecretHash []byte
+	// SecretSalt is the salt used to compute SecretHash.
+	SecretSalt []byte
+
+	// ImportedFrom is the system that the secret was imported from. It is empty if the secret was generated.
+	ImportedFrom string
+	// ImportedBy is the ID of the user who imported the secret.
+	ImportedBy string
+
+	// The following fields hold the secret before the last rotation. It remains valid until PreviousSecretExpiresAt.
+	PreviousSecret          string
+	PreviousEncryptedSecret []byte
+	PreviousSecretPrefix    string `gorm:"index"`
+	PreviousSecretHash      []byte
+	PreviousSecretSalt      []byte
+	// PreviousSecretExpiresAt is the Unix time (in seconds) when the previous secret expires.
+	PreviousSecretExpiresAt int64
+
+	// LastUsedAt is the Unix time (in seconds) when the API key was last used.
+	LastUsedAt int64
+	// LastUsedSourceIP is the IP address of the client that last used the API key.
+	LastUsedSourceIP string
+
+	// ExpiresAt is the Unix time (in seconds) when the API key expires. Zero means no expiration.
+	ExpiresAt int64 `gorm:"index;default:0"`
+
+	// Scopes is a comma-separated list of scopes that the API key is restricted to.
+	Scopes string
+	// AllowedModels is a comma-separated list of model IDs that the API key can use.
+	AllowedModels string
+	// AllowedCIDRs is a comma-separated list of CIDR ranges from which the API key can be used.
+	AllowedCIDRs string
+
+	// The following fields are the limits applied to the API key. Zero means no limit.
+	RequestsPerMinute  int64
+	TokensPerMinute    int64
+	MonthlyTokenBudget int64
+
 	// TODO(kenji): Associate roles.
 }
 
@@ -42,19 +97,143 @@ type APIKeySpec struct {
 	// ExcludedFromRateLimiting indicates whether this API key is excluded from rate limiting
 	ExcludedFromRateLimiting bool
 
-	Name   string
+	Name        string
+	Description string
+	Labels      map[string]string
+
 	Secret string
 	// EncryptedSecret is encrypted by data key.
 	EncryptedSecret []byte
+
+	SecretPrefix string
+	SecretHash   []byte
+	SecretSalt   []byte
+
+	ImportedFrom string
+	ImportedBy   string
+
+	// ExpiresAt is the Unix time (in seconds) when the API key expires. Zero means no expiration.
+	ExpiresAt int64
+
+	Scopes        []string
+	AllowedModels []string
+	AllowedCIDRs  []string
+
+	RequestsPerMinute  int64
+	TokensPerMinute    int64
+	MonthlyTokenBudget int64
+}
+
+// IsActive returns true if the API key is enabled and has not expired at the given time.
+func (k *APIKey) IsActive(now time.Time) bool {
+	if k.Disabled {
+		return false
+	}
+	return k.ExpiresAt == 0 || k.ExpiresAt > now.Unix()
+}
+
+// GetScopes returns the scopes of the API key.
+func (k *APIKey) GetScopes() []string {
+	if k.Scopes == "" {
+		return nil
+	}
+	return strings.Split(k.Scopes, ",")
+}
+
+// GetAllowedModels returns the model IDs that the API key can use.
+func (k *APIKey) GetAllowedModels() []string {
+	if k.AllowedModels == "" {
+		return nil
+	}
+	return strings.Split(k.AllowedModels, ",")
+}
+
+// SetAllowedModels sets the model IDs that the API key can use.
+func (k *APIKey) SetAllowedModels(models []string) {
+	k.AllowedModels = strings.Join(models, ",")
+}
+
+// GetAllowedCIDRs returns the CIDR ranges from which the API key can be used.
+func (k *APIKey) GetAllowedCIDRs() []string {
+	if k.AllowedCIDRs == "" {
+		return nil
+	}
+	return strings.Split(k.AllowedCIDRs, ",")
+}
+
+// SetAllowedCIDRs sets the CIDR ranges from which the API key can be used.
+func (k *APIKey) SetAllowedCIDRs(cidrs []string) {
+	k.AllowedCIDRs = strings.Join(cidrs, ",")
+}
+
+// GetLabels returns the labels of the API key.
+func (k *APIKey) GetLabels() (map[string]string, error) {
+	if k.Labels == "" {
+		return nil, nil
+	}
+	var labels map[string]string
+	if err := json.Unmarshal([]byte(k.Labels), &labels); err != nil {
+		return nil, err
+	}
+	return labels, nil
+}
+
+// SetLabels sets the labels of the API key.
+func (k *APIKey) SetLabels(labels map[string]string) error {
+	s, err := encodeLabels(labels)
+	if err != nil {
+		return err
+	}
+	k.Labels = s
+	return nil
+}
+
+func encodeLabels(labels map[string]string) (string, error) {
+	if len(labels) == 0 {
+		return "", nil
+	}
+	// json.Marshal sorts the map keys, which makes the encoding deterministic.
+	b, err := json.Marshal(labels)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// labelPattern returns a LIKE pattern that matches the encoded labels containing the given key-value pair.
+func labelPattern(key, value string) (string, error) {
+	k, err := json.Marshal(key)
+	if err != nil {
+		return "", err
+	}
+	v, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	// Double quotes in keys and values are escaped in the encoding, so the pair matches only
+	// an actual key-value pair of the object.
+	return "%" + escapeLike(string(k)+":"+string(v)) + "%", nil
 }
 
 // CreateAPIKey creates a new API key.
 func (s *S) CreateAPIKey(spec APIKeySpec) (*APIKey, error) {
-	return CreateAPIKeyInTransaction(s.db, spec)
+	var k *APIKey
+	if err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		k, err = CreateAPIKeyInTransaction(tx, spec)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return k, nil
 }
 
 // CreateAPIKeyInTransaction creates a new API key in a transaction.
 func CreateAPIKeyInTransaction(db *gorm.DB, spec APIKeySpec) (*APIKey, error) {
+	labels, err := encodeLabels(spec.Labels)
+	if err != nil {
+		return nil, err
+	}
 	k := &APIKey{
 		APIKeyID:                 spec.APIKeyID,
 		TenantID:                 spec.TenantID,
@@ -65,12 +244,30 @@ func CreateAPIKeyInTransaction(db *gorm.DB, spec APIKeySpec) (*APIKey, error) {
 		ExcludedFromRateLimiting: spec.ExcludedFromRateLimiting,
 
 		Name:            spec.Name,
+		Description:     spec.Description,
+		Labels:          labels,
 		Secret:          spec.Secret,
 		EncryptedSecret: spec.EncryptedSecret,
+		SecretPrefix:    spec.SecretPrefix,
+		SecretHash:      spec.SecretHash,
+		SecretSalt:      spec.SecretSalt,
+		ImportedFrom:    spec.ImportedFrom,
+		ImportedBy:      spec.ImportedBy,
+		ExpiresAt:       spec.ExpiresAt,
+		Scopes:          strings.Join(spec.Scopes, ","),
+		AllowedModels:   strings.Join(spec.AllowedModels, ","),
+		AllowedCIDRs:    strings.Join(spec.AllowedCIDRs, ","),
+
+		RequestsPerMinute:  spec.RequestsPerMinute,
+		TokensPerMinute:    spec.TokensPerMinute,
+		MonthlyTokenBudget: spec.MonthlyTokenBudget,
 	}
 	if err := db.Create(k).Error; err != nil {
 		return nil, err
 	}
+	if err := recordChangeInTransaction(db, ResourceKindAPIKey, k.APIKeyID, ChangeTypeCreated); err != nil {
+		return nil, err
+	}
 	return k, nil
 }
 
@@ -83,13 +280,114 @@ func (s *S) ListAPIKeysByProjectID(projectID string) ([]*APIKey, error) {
 	return ks, nil
 }
 
-// ListAPIKeysByTenantID lists API keys by a tenant ID.
-func (s *S) ListAPIKeysByTenantID(tenantID string) ([]*APIKey, error) {
+// APIKeyOrderBy is the field by which API keys are sorted.
+type APIKeyOrderBy string
+
+const (
+	// APIKeyOrderByName sorts API keys by their names.
+	APIKeyOrderByName APIKeyOrderBy = "name"
+	// APIKeyOrderByCreatedAt sorts API keys by their creation time.
+	APIKeyOrderByCreatedAt APIKeyOrderBy = "created_at"
+)
+
+// ListAPIKeysParams is the parameters for ListAPIKeys.
+type ListAPIKeysParams struct {
+	TenantID string
+
+	// The following fields are filters. Empty values match all API keys.
+	OrganizationID string
+	ProjectID      string
+	UserID         string
+	// IsServiceAccount filters API keys by whether they belong to service accounts if it is non-nil.
+	IsServiceAccount *bool
+	NamePrefix       string
+	// Labels filters API keys that have all the given labels.
+	Labels map[string]string
+
+	// RestrictVisibility limits the API keys to the ones that are in VisibleProjectIDs or owned by VisibleUserID.
+	RestrictVisibility bool
+	VisibleProjectIDs  []string
+	VisibleUserID      string
+
+	OrderBy    APIKeyOrderBy
+	Descending bool
+
+	// After is the last API key in the previous page.
+	After *APIKey
+	Limit int
+}
+
+// ListAPIKeys lists API keys that match the parameters. The second return value is true if there are more API keys.
+func (s *S) ListAPIKeys(p ListAPIKeysParams) ([]*APIKey, bool, error) {
+	q := s.db.Where("tenant_id = ?", p.TenantID)
+	if p.OrganizationID != "" {
+		q = q.Where("organization_id = ?", p.OrganizationID)
+	}
+	if p.ProjectID != "" {
+		q = q.Where("project_id = ?", p.ProjectID)
+	}
+	if p.UserID != "" {
+		q = q.Where("user_id = ?", p.UserID)
+	}
+	if p.IsServiceAccount != nil {
+		q = q.Where("is_service_account = ?", *p.IsServiceAccount)
+	}
+	if p.NamePrefix != "" {
+		q = q.Where("name LIKE ? ESCAPE '!'", escapeLike(p.NamePrefix)+"%")
+	}
+	// Sort the label keys so that the generated query is deterministic.
+	var lkeys []string
+	for k := range p.Labels {
+		lkeys = append(lkeys, k)
+	}
+	sort.Strings(lkeys)
+	for _, k := range lkeys {
+		pat, err := labelPattern(k, p.Labels[k])
+		if err != nil {
+			return nil, false, err
+		}
+		q = q.Where("labels LIKE ? ESCAPE '!'", pat)
+	}
+	if p.RestrictVisibility {
+		q = q.Where(s.db.Where("project_id IN ?", p.VisibleProjectIDs).Or("user_id = ?", p.VisibleUserID))
+	}
+
+	cmp, dir := ">", "ASC"
+	if p.Descending {
+		cmp, dir = "<", "DESC"
+	}
+	// The ID increases monotonically and breaks ties so that the order is stable across pages.
+	switch p.OrderBy {
+	case APIKeyOrderByName:
+		if p.After != nil {
+			q = q.Where(
+				s.db.Where("name "+cmp+" ?", p.After.Name).
+					Or("name = ? AND id "+cmp+" ?", p.After.Name, p.After.ID),
+			)
+		}
+		q = q.Order("name " + dir).Order("id " + dir)
+	case APIKeyOrderByCreatedAt:
+		if p.After != nil {
+			q = q.Where("id "+cmp+" ?", p.After.ID)
+		}
+		q = q.Order("id " + dir)
+	default:
+		return nil, false, fmt.Errorf("unknown order by %q", p.OrderBy)
+	}
+
 	var ks []*APIKey
-	if err := s.db.Where("tenant_id = ?", tenantID).Order("name").Find(&ks).Error; err != nil {
-		return nil, err
+	if err := q.Limit(p.Limit + 1).Find(&ks).Error; err != nil {
+		return nil, false, err
 	}
-	return ks, nil
+	if len(ks) > p.Limit {
+		return ks[:p.Limit], true, nil
+	}
+	return ks, false, nil
+}
+
+// escapeLike escapes the special characters of a LIKE pattern.
+func escapeLike(s string) string {
+	return strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(s)
 }
 
 // GetAPIKeyByNameAndUserID gets an API key by its name and user ID.
@@ -119,6 +417,35 @@ func (s *S) GetAPIKeyByID(id string) (*APIKey, error) {
 	return &k, nil
 }
 
+// CountAPIKeysByUserIDInTransaction counts the API keys owned by the user in a transaction.
+func CountAPIKeysByUserIDInTransaction(tx *gorm.DB, userID string) (int64, error) {
+	var n int64
+	if err := tx.Model(&APIKey{}).Where("user_id = ?", userID).Count(&n).Error; err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// CountAPIKeysByUserIDs counts the API keys owned by each of the given users.
+func (s *S) CountAPIKeysByUserIDs(userIDs []string) (map[string]int64, error) {
+	var rows []struct {
+		UserID string
+		Count  int64
+	}
+	if err := s.db.Model(&APIKey{}).
+		Select("user_id, COUNT(*) AS count").
+		Where("user_id IN ?", userIDs).
+		Group("user_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	counts := map[string]int64{}
+	for _, r := range rows {
+		counts[r.UserID] = r.Count
+	}
+	return counts, nil
+}
+
 // ListAllAPIKeys lists all API keys.
 func (s *S) ListAllAPIKeys() ([]*APIKey, error) {
 	var ks []*APIKey
@@ -128,9 +455,208 @@ func (s *S) ListAllAPIKeys() ([]*APIKey, error) {
 	return ks, nil
 }
 
+// ListAllActiveAPIKeys lists all API keys that are enabled and have not expired at the given time.
+func (s *S) ListAllActiveAPIKeys(now time.Time) ([]*APIKey, error) {
+	var ks []*APIKey
+	if err := s.db.Where("disabled = ?", false).Where("expires_at = 0 OR expires_at > ?", now.Unix()).Find(&ks).Error; err != nil {
+		return nil, err
+	}
+	return ks, nil
+}
+
+// ListActiveAPIKeysByIDs lists API keys that have the given IDs, are enabled, and have not expired at the given time.
+func (s *S) ListActiveAPIKeysByIDs(apiKeyIDs []string, now time.Time) ([]*APIKey, error) {
+	var ks []*APIKey
+	if err := s.db.Where("api_key_id IN ?", apiKeyIDs).Where("disabled = ?", false).Where("expires_at = 0 OR expires_at > ?", now.Unix()).Find(&ks).Error; err != nil {
+		return nil, err
+	}
+	return ks, nil
+}
+
+// ListAPIKeysExpiringAfter lists API keys whose secrets or previous secrets expire after the given time.
+func (s *S) ListAPIKeysExpiringAfter(t time.Time) ([]*APIKey, error) {
+	var ks []*APIKey
+	if err := s.db.Where("expires_at > ? OR previous_secret_expires_at > ?", t.Unix(), t.Unix()).Find(&ks).Error; err != nil {
+		return nil, err
+	}
+	return ks, nil
+}
+
+// ListAPIKeysExpiredBetween lists API keys whose secrets or previous secrets expired after from and not after to.
+func (s *S) ListAPIKeysExpiredBetween(from, to time.Time) ([]*APIKey, error) {
+	var ks []*APIKey
+	if err := s.db.Where(
+		"(expires_at > ? AND expires_at <= ?) OR (previous_secret_expires_at > ? AND previous_secret_expires_at <= ?)",
+		from.Unix(), to.Unix(), from.Unix(), to.Unix(),
+	).Find(&ks).Error; err != nil {
+		return nil, err
+	}
+	return ks, nil
+}
+
+// ListAPIKeysBySecretPrefix lists API keys whose secrets start with the given prefix.
+func (s *S) ListAPIKeysBySecretPrefix(prefix string) ([]*APIKey, error) {
+	var ks []*APIKey
+	if err := s.db.Where("secret_prefix = ?", prefix).Find(&ks).Error; err != nil {
+		return nil, err
+	}
+	return ks, nil
+}
+
+// ListAPIKeysByPreviousSecretPrefix lists API keys whose previous secrets start with the given prefix
+// and have not expired at the given time.
+func (s *S) ListAPIKeysByPreviousSecretPrefix(prefix string, now time.Time) ([]*APIKey, error) {
+	var ks []*APIKey
+	if err := s.db.Where("previous_secret_prefix = ? AND previous_secret_expires_at > ?", prefix, now.Unix()).Find(&ks).Error; err != nil {
+		return nil, err
+	}
+	return ks, nil
+}
+
+// ListAPIKeysWithoutSecretHash lists API keys whose secrets have not been hashed.
+func (s *S) ListAPIKeysWithoutSecretHash() ([]*APIKey, error) {
+	var ks []*APIKey
+	if err := s.db.Where("secret_hash IS NULL OR length(secret_hash) = 0").Find(&ks).Error; err != nil {
+		return nil, err
+	}
+	return ks, nil
+}
+
+// ListAPIKeysWithoutSecretPrefix lists API keys whose secret prefixes or unhashed previous secret prefixes
+// have not been set.
+func (s *S) ListAPIKeysWithoutSecretPrefix() ([]*APIKey, error) {
+	var ks []*APIKey
+	if err := s.db.Where(
+		"secret_prefix IS NULL OR secret_prefix = '' OR " +
+			"((previous_secret_prefix IS NULL OR previous_secret_prefix = '') AND (previous_secret <> '' OR length(previous_encrypted_secret) > 0))",
+	).Find(&ks).Error; err != nil {
+		return nil, err
+	}
+	return ks, nil
+}
+
+// UpdateAPIKeySecretPrefixes sets the prefixes of the current and previous secrets of an API key.
+func (s *S) UpdateAPIKeySecretPrefixes(apiKeyID, prefix, previousPrefix string) error {
+	return s.updateAPIKeyColumns(apiKeyID, map[string]interface{}{
+		"secret_prefix":          prefix,
+		"previous_secret_prefix": previousPrefix,
+	})
+}
+
+// UpdateAPIKeySecretHash sets the hash of the secret of an API key and clears its plaintext and encrypted secret.
+func (s *S) UpdateAPIKeySecretHash(apiKeyID, prefix string, hash, salt []byte) error {
+	return s.updateAPIKeyColumns(apiKeyID, map[string]interface{}{
+		"secret":           "",
+		"encrypted_secret": nil,
+		"secret_prefix":    prefix,
+		"secret_hash":      hash,
+		"secret_salt":      salt,
+	})
+}
+
+// UpdateAPIKeyPreviousSecretHash sets the hash of the previous secret of an API key and clears its plaintext and encrypted previous secret.
+func (s *S) UpdateAPIKeyPreviousSecretHash(apiKeyID string, hash, salt []byte) error {
+	return s.updateAPIKeyColumns(apiKeyID, map[string]interface{}{
+		"previous_secret":           "",
+		"previous_encrypted_secret": nil,
+		"previous_secret_hash":      hash,
+		"previous_secret_salt":      salt,
+	})
+}
+
+// RotateAPIKeySecretParams is the parameters for RotateAPIKeySecret.
+type RotateAPIKeySecretParams struct {
+	APIKeyID string
+
+	Secret          string
+	EncryptedSecret []byte
+	SecretPrefix    string
+	SecretHash      []byte
+	SecretSalt      []byte
+
+	// PreviousSecretExpiresAt is the Unix time (in seconds) until which the current secret remains valid.
+	// The current secret is discarded if it is zero.
+	PreviousSecretExpiresAt int64
+}
+
+// RotateAPIKeySecret replaces the secret of an API key. The current secret is kept as the previous secret
+// if PreviousSecretExpiresAt is set.
+func (s *S) RotateAPIKeySecret(p RotateAPIKeySecretParams) error {
+	updates := map[string]interface{}{
+		"secret":                     p.Secret,
+		"encrypted_secret":           p.EncryptedSecret,
+		"secret_prefix":              p.SecretPrefix,
+		"secret_hash":                p.SecretHash,
+		"secret_salt":                p.SecretSalt,
+		"previous_secret_expires_at": p.PreviousSecretExpiresAt,
+	}
+	if p.PreviousSecretExpiresAt > 0 {
+		// The right-hand sides of the assignments refer to the values before the update.
+		updates["previous_secret"] = gorm.Expr("secret")
+		updates["previous_encrypted_secret"] = gorm.Expr("encrypted_secret")
+		updates["previous_secret_prefix"] = gorm.Expr("secret_prefix")
+		updates["previous_secret_hash"] = gorm.Expr("secret_hash")
+		updates["previous_secret_salt"] = gorm.Expr("secret_salt")
+	} else {
+		updates["previous_secret"] = ""
+		updates["previous_encrypted_secret"] = nil
+		updates["previous_secret_prefix"] = ""
+		updates["previous_secret_hash"] = nil
+		updates["previous_secret_salt"] = nil
+	}
+	return s.updateAPIKeyColumns(p.APIKeyID, updates)
+}
+
+// TransferAPIKey changes the owner of an API key.
+func (s *S) TransferAPIKey(apiKeyID, userID string, isServiceAccount bool) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		return TransferAPIKeyInTransaction(tx, apiKeyID, userID, isServiceAccount)
+	})
+}
+
+// TransferAPIKeyInTransaction changes the owner of an API key in a transaction.
+func TransferAPIKeyInTransaction(tx *gorm.DB, apiKeyID, userID string, isServiceAccount bool) error {
+	return updateAPIKeyColumnsInTransaction(tx, apiKeyID, map[string]interface{}{
+		"user_id":            userID,
+		"is_service_account": isServiceAccount,
+	})
+}
+
+// updateAPIKeyColumns updates the given columns of an API key and records the change.
+func (s *S) updateAPIKeyColumns(apiKeyID string, updates map[string]interface{}) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		return updateAPIKeyColumnsInTransaction(tx, apiKeyID, updates)
+	})
+}
+
+func updateAPIKeyColumnsInTransaction(tx *gorm.DB, apiKeyID string, updates map[string]interface{}) error {
+	res := tx.Model(&APIKey{}).Where("api_key_id = ?", apiKeyID).Updates(updates)
+	if err := res.Error; err != nil {
+		return err
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return recordChangeInTransaction(tx, ResourceKindAPIKey, apiKeyID, ChangeTypeUpdated)
+}
+
+// UpdateAPIKeyLastUsed updates the last-used time and source of an API key.
+// The update is skipped if the API key has been used more recently.
+// The update is not recorded in the change log as it happens frequently.
+func (s *S) UpdateAPIKeyLastUsed(apiKeyID string, usedAt int64, sourceIP string) error {
+	return s.db.Model(&APIKey{}).
+		Where("api_key_id = ? AND COALESCE(last_used_at, 0) < ?", apiKeyID, usedAt).
+		Updates(map[string]interface{}{
+			"last_used_at":        usedAt,
+			"last_used_source_ip": sourceIP,
+		}).Error
+}
+
 // DeleteAPIKey deletes an APIKey by APIKey ID and tenant ID.
 func (s *S) DeleteAPIKey(apiKeyID, projectID string) error {
-	return DeleteAPIKeyInTransaction(s.db, apiKeyID, projectID)
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		return DeleteAPIKeyInTransaction(tx, apiKeyID, projectID)
+	})
 }
 
 // DeleteAPIKeyInTransaction deletes an APIKey in a transaction.
@@ -142,13 +668,86 @@ func DeleteAPIKeyInTransaction(tx *gorm.DB, apiKeyID, projectID string) error {
 	if res.RowsAffected == 0 {
 		return gorm.ErrRecordNotFound
 	}
-	return nil
+	return recordChangeInTransaction(tx, ResourceKindAPIKey, apiKeyID, ChangeTypeDeleted)
+}
+
+// DeleteAPIKeysByUserIDInTransaction deletes the API keys owned by the user in the organization in a transaction.
+// If projectID is not empty, only the API keys in the project are deleted. It returns the IDs of the deleted API keys.
+func DeleteAPIKeysByUserIDInTransaction(tx *gorm.DB, orgID, projectID, userID string) ([]string, error) {
+	ids, err := listAPIKeyIDsByUserIDInTransaction(tx, orgID, projectID, userID, false)
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	if err := tx.Unscoped().Where("api_key_id IN ?", ids).Delete(&APIKey{}).Error; err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		if err := recordChangeInTransaction(tx, ResourceKindAPIKey, id, ChangeTypeDeleted); err != nil {
+			return nil, err
+		}
+	}
+	return ids, nil
 }
 
-// UpdateAPIKey updates an API key.
-func (s *S) UpdateAPIKey(key *APIKey) error {
-	if err := s.db.Save(key).Error; err != nil {
+// DisableAPIKeysByUserIDInTransaction disables the enabled API keys owned by the user in the organization in a transaction.
+// If projectID is not empty, only the API keys in the project are disabled. It returns the IDs of the disabled API keys.
+func DisableAPIKeysByUserIDInTransaction(tx *gorm.DB, orgID, projectID, userID string) ([]string, error) {
+	ids, err := listAPIKeyIDsByUserIDInTransaction(tx, orgID, projectID, userID, true)
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	if err := tx.Model(&APIKey{}).Where("api_key_id IN ?", ids).Update("disabled", true).Error; err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		if err := recordChangeInTransaction(tx, ResourceKindAPIKey, id, ChangeTypeUpdated); err != nil {
+			return nil, err
+		}
+	}
+	return ids, nil
+}
+
+func listAPIKeyIDsByUserIDInTransaction(tx *gorm.DB, orgID, projectID, userID string, enabledOnly bool) ([]string, error) {
+	q := tx.Where("user_id = ?", userID)
+	if orgID != "" {
+		q = q.Where("organization_id = ?", orgID)
+	}
+	if projectID != "" {
+		q = q.Where("project_id = ?", projectID)
+	}
+	if enabledOnly {
+		q = q.Where("disabled = ?", false)
+	}
+	var ids []string
+	if err := q.Model(&APIKey{}).Order("api_key_id").Pluck("api_key_id", &ids).Error; err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// recordAPIKeyUpdatesByUserIDInTransaction records updates of the API keys owned by the user in the organization
+// (or in the project if projectID is not empty) in a transaction. This is called when the user's role changes so that
+// the watchers of the API keys observe the new role.
+func recordAPIKeyUpdatesByUserIDInTransaction(tx *gorm.DB, orgID, projectID, userID string) error {
+	ids, err := listAPIKeyIDsByUserIDInTransaction(tx, orgID, projectID, userID, false)
+	if err != nil {
 		return err
 	}
+	for _, id := range ids {
+		if err := recordChangeInTransaction(tx, ResourceKindAPIKey, id, ChangeTypeUpdated); err != nil {
+			return err
+		}
+	}
 	return nil
 }
+
+// UpdateAPIKey updates the given columns of an API key.
+func (s *S) UpdateAPIKey(apiKeyID string, updates map[string]interface{}) error {
+	return s.updateAPIKeyColumns(apiKeyID, updates)
+}
@@ -3,6 +3,7 @@ package store
 import (
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"gorm.io/gorm"
@@ -132,3 +133,300 @@ func TestAPIKeySameName(t *testing.T) {
 	})
 	assert.NoError(t, err)
 }
+
+func TestListAllActiveAPIKeys(t *testing.T) {
+	st, tearDown := NewTest(t)
+	defer tearDown()
+
+	now := time.Now()
+	specs := []APIKeySpec{
+		{
+			APIKeyID: "k0",
+			UserID:   "u0",
+			Name:     "no-expiration",
+		},
+		{
+			APIKeyID:  "k1",
+			UserID:    "u0",
+			Name:      "expired",
+			ExpiresAt: now.Add(-time.Minute).Unix(),
+		},
+		{
+			APIKeyID:  "k2",
+			UserID:    "u0",
+			Name:      "unexpired",
+			ExpiresAt: now.Add(time.Minute).Unix(),
+		},
+		{
+			APIKeyID: "k3",
+			UserID:   "u1",
+			Name:     "disabled",
+		},
+	}
+	for _, spec := range specs {
+		_, err := st.CreateAPIKey(spec)
+		assert.NoError(t, err)
+	}
+	ids, err := DisableAPIKeysByUserIDInTransaction(st.db, "", "", "u1")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"k3"}, ids)
+
+	got, err := st.ListAllActiveAPIKeys(now)
+	assert.NoError(t, err)
+	ids = nil
+	for _, k := range got {
+		ids = append(ids, k.APIKeyID)
+	}
+	assert.ElementsMatch(t, []string{"k0", "k2"}, ids)
+}
+
+func TestUpdateAPIKeySecretHash(t *testing.T) {
+	st, tearDown := NewTest(t)
+	defer tearDown()
+
+	_, err := st.CreateAPIKey(APIKeySpec{
+		APIKeyID:     "k0",
+		UserID:       "u0",
+		Name:         "plaintext",
+		Secret:       "sk-secret0",
+		SecretPrefix: "sk-sec",
+	})
+	assert.NoError(t, err)
+	_, err = st.CreateAPIKey(APIKeySpec{
+		APIKeyID:     "k1",
+		UserID:       "u0",
+		Name:         "hashed",
+		SecretPrefix: "sk-sec",
+		SecretHash:   []byte("hash1"),
+		SecretSalt:   []byte("salt1"),
+	})
+	assert.NoError(t, err)
+
+	ks, err := st.ListAPIKeysWithoutSecretHash()
+	assert.NoError(t, err)
+	assert.Len(t, ks, 1)
+	assert.Equal(t, "k0", ks[0].APIKeyID)
+
+	err = st.UpdateAPIKeySecretHash("k0", "sk-sec", []byte("hash0"), []byte("salt0"))
+	assert.NoError(t, err)
+
+	k, err := st.GetAPIKeyByID("k0")
+	assert.NoError(t, err)
+	assert.Empty(t, k.Secret)
+	assert.Equal(t, []byte("hash0"), k.SecretHash)
+	assert.Equal(t, []byte("salt0"), k.SecretSalt)
+
+	ks, err = st.ListAPIKeysWithoutSecretHash()
+	assert.NoError(t, err)
+	assert.Empty(t, ks)
+
+	ks, err = st.ListAPIKeysBySecretPrefix("sk-sec")
+	assert.NoError(t, err)
+	assert.Len(t, ks, 2)
+
+	err = st.UpdateAPIKeySecretHash("k2", "sk-sec", []byte("hash2"), []byte("salt2"))
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestRotateAPIKeySecret(t *testing.T) {
+	st, tearDown := NewTest(t)
+	defer tearDown()
+
+	_, err := st.CreateAPIKey(APIKeySpec{
+		APIKeyID:     "k0",
+		UserID:       "u0",
+		Name:         "key",
+		Secret:       "sk-secret0",
+		SecretPrefix: "sk-secret0",
+	})
+	assert.NoError(t, err)
+
+	now := time.Now()
+	err = st.RotateAPIKeySecret(RotateAPIKeySecretParams{
+		APIKeyID:                "k0",
+		Secret:                  "sk-secret1",
+		SecretPrefix:            "sk-secret1",
+		PreviousSecretExpiresAt: now.Add(time.Hour).Unix(),
+	})
+	assert.NoError(t, err)
+
+	k, err := st.GetAPIKeyByID("k0")
+	assert.NoError(t, err)
+	assert.Equal(t, "sk-secret1", k.Secret)
+	assert.Equal(t, "sk-secret0", k.PreviousSecret)
+	assert.Equal(t, "sk-secret0", k.PreviousSecretPrefix)
+
+	ks, err := st.ListAPIKeysByPreviousSecretPrefix("sk-secret0", now)
+	assert.NoError(t, err)
+	assert.Len(t, ks, 1)
+	ks, err = st.ListAPIKeysByPreviousSecretPrefix("sk-secret0", now.Add(2*time.Hour))
+	assert.NoError(t, err)
+	assert.Empty(t, ks)
+
+	err = st.RotateAPIKeySecret(RotateAPIKeySecretParams{
+		APIKeyID:     "k0",
+		Secret:       "sk-secret2",
+		SecretPrefix: "sk-secret2",
+	})
+	assert.NoError(t, err)
+
+	k, err = st.GetAPIKeyByID("k0")
+	assert.NoError(t, err)
+	assert.Equal(t, "sk-secret2", k.Secret)
+	assert.Empty(t, k.PreviousSecret)
+	assert.Zero(t, k.PreviousSecretExpiresAt)
+
+	err = st.RotateAPIKeySecret(RotateAPIKeySecretParams{APIKeyID: "k1"})
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestUpdateAPIKey(t *testing.T) {
+	st, tearDown := NewTest(t)
+	defer tearDown()
+
+	_, err := st.CreateAPIKey(APIKeySpec{
+		APIKeyID:     "k0",
+		UserID:       "u0",
+		Name:         "key",
+		Secret:       "sk-secret0",
+		SecretPrefix: "sk-secret0",
+	})
+	assert.NoError(t, err)
+
+	// Read the key before a concurrent rotation.
+	stale, err := st.GetAPIKeyByID("k0")
+	assert.NoError(t, err)
+
+	err = st.RotateAPIKeySecret(RotateAPIKeySecretParams{
+		APIKeyID:     "k0",
+		Secret:       "sk-secret1",
+		SecretPrefix: "sk-secret1",
+	})
+	assert.NoError(t, err)
+
+	// Updating the name of the stale key does not revert the rotation.
+	err = st.UpdateAPIKey(stale.APIKeyID, map[string]interface{}{"name": "renamed", "disabled": true})
+	assert.NoError(t, err)
+
+	k, err := st.GetAPIKeyByID("k0")
+	assert.NoError(t, err)
+	assert.Equal(t, "renamed", k.Name)
+	assert.True(t, k.Disabled)
+	assert.Equal(t, "sk-secret1", k.Secret)
+	assert.Equal(t, "sk-secret1", k.SecretPrefix)
+
+	err = st.UpdateAPIKey("k1", map[string]interface{}{"name": "renamed"})
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestListAPIKeys(t *testing.T) {
+	st, tearDown := NewTest(t)
+	defer tearDown()
+
+	for _, spec := range []APIKeySpec{
+		{APIKeyID: "k0", TenantID: "t0", ProjectID: "p0", UserID: "u0", Name: "a_b", Labels: map[string]string{"env": "prod", "team": "ml"}},
+		{APIKeyID: "k1", TenantID: "t0", ProjectID: "p0", UserID: "u1", Name: "axb", Labels: map[string]string{"env": `prod","team":"ml`}},
+		{APIKeyID: "k2", TenantID: "t0", ProjectID: "p1", UserID: "u1", Name: "a%b", Labels: map[string]string{"env": "dev", "team": "ml"}},
+		{APIKeyID: "k3", TenantID: "t1", ProjectID: "p2", UserID: "u2", Name: "a_b"},
+	} {
+		_, err := st.CreateAPIKey(spec)
+		assert.NoError(t, err)
+	}
+
+	ids := func(ks []*APIKey) []string {
+		var ids []string
+		for _, k := range ks {
+			ids = append(ids, k.APIKeyID)
+		}
+		return ids
+	}
+
+	ks, hasMore, err := st.ListAPIKeys(ListAPIKeysParams{TenantID: "t0", OrderBy: APIKeyOrderByName, Limit: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"k2", "k0", "k1"}, ids(ks))
+	assert.False(t, hasMore)
+
+	// Special characters in the name prefix are matched literally.
+	ks, _, err = st.ListAPIKeys(ListAPIKeysParams{TenantID: "t0", NamePrefix: "a_", OrderBy: APIKeyOrderByName, Limit: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"k0"}, ids(ks))
+	ks, _, err = st.ListAPIKeys(ListAPIKeysParams{TenantID: "t0", NamePrefix: "a%", OrderBy: APIKeyOrderByName, Limit: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"k2"}, ids(ks))
+
+	ks, _, err = st.ListAPIKeys(ListAPIKeysParams{TenantID: "t0", Labels: map[string]string{"team": "ml"}, OrderBy: APIKeyOrderByCreatedAt, Limit: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"k0", "k2"}, ids(ks))
+	// A value that contains an encoded pair does not match the pair.
+	ks, _, err = st.ListAPIKeys(ListAPIKeysParams{TenantID: "t0", Labels: map[string]string{"env": "prod", "team": "ml"}, OrderBy: APIKeyOrderByCreatedAt, Limit: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"k0"}, ids(ks))
+
+	k, err := st.GetAPIKeyByID("k1")
+	assert.NoError(t, err)
+	labels, err := k.GetLabels()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"env": `prod","team":"ml`}, labels)
+
+	ks, _, err = st.ListAPIKeys(ListAPIKeysParams{
+		TenantID:           "t0",
+		RestrictVisibility: true,
+		VisibleProjectIDs:  []string{"p1"},
+		VisibleUserID:      "u0",
+		OrderBy:            APIKeyOrderByCreatedAt,
+		Limit:              10,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"k0", "k2"}, ids(ks))
+
+	ks, hasMore, err = st.ListAPIKeys(ListAPIKeysParams{TenantID: "t0", OrderBy: APIKeyOrderByCreatedAt, Descending: true, Limit: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"k2", "k1"}, ids(ks))
+	assert.True(t, hasMore)
+	ks, hasMore, err = st.ListAPIKeys(ListAPIKeysParams{TenantID: "t0", OrderBy: APIKeyOrderByCreatedAt, Descending: true, After: ks[1], Limit: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"k0"}, ids(ks))
+	assert.False(t, hasMore)
+}
+
+func TestListAPIKeysByExpiration(t *testing.T) {
+	st, tearDown := NewTest(t)
+	defer tearDown()
+
+	now := time.Now()
+	for _, spec := range []APIKeySpec{
+		{APIKeyID: "k0", Name: "k0"},
+		{APIKeyID: "k1", Name: "k1", ExpiresAt: now.Add(-time.Minute).Unix()},
+		{APIKeyID: "k2", Name: "k2", ExpiresAt: now.Add(time.Minute).Unix()},
+		{APIKeyID: "k3", Name: "k3"},
+	} {
+		_, err := st.CreateAPIKey(spec)
+		assert.NoError(t, err)
+	}
+	err := st.RotateAPIKeySecret(RotateAPIKeySecretParams{
+		APIKeyID:                "k3",
+		Secret:                  "s",
+		PreviousSecretExpiresAt: now.Add(time.Minute).Unix(),
+	})
+	assert.NoError(t, err)
+
+	ids := func(ks []*APIKey) []string {
+		var ids []string
+		for _, k := range ks {
+			ids = append(ids, k.APIKeyID)
+		}
+		return ids
+	}
+
+	ks, err := st.ListAPIKeysExpiringAfter(now)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"k2", "k3"}, ids(ks))
+
+	ks, err = st.ListAPIKeysExpiredBetween(now.Add(-time.Hour), now)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"k1"}, ids(ks))
+
+	ks, err = st.ListAPIKeysExpiredBetween(now, now.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"k2", "k3"}, ids(ks))
+}
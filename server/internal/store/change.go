@@ -0,0 +1,185 @@
+package store
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ChangeType is the type of a change.
+type ChangeType string
+
+const (
+	// ChangeTypeCreated is the type of a change that creates a resource.
+	ChangeTypeCreated ChangeType = "created"
+	// ChangeTypeUpdated is the type of a change that updates a resource.
+	ChangeTypeUpdated ChangeType = "updated"
+	// ChangeTypeDeleted is the type of a change that deletes a resource.
+	ChangeTypeDeleted ChangeType = "deleted"
+)
+
+const (
+	// ResourceKindAPIKey is the kind of API keys.
+	ResourceKindAPIKey = "api_key"
+	// ResourceKindInvitation is the kind of invitations.
+	ResourceKindInvitation = "invitation"
+	// ResourceKindOrganization is the kind of organizations.
+	ResourceKindOrganization = "organization"
+	// ResourceKindOrganizationUser is the kind of organization users.
+	ResourceKindOrganizationUser = "organization_user"
+	// ResourceKindProject is the kind of projects.
+	ResourceKindProject = "project"
+	// ResourceKindProjectUser is the kind of project users.
+	ResourceKindProjectUser = "project_user"
+	// ResourceKindServiceAccount is the kind of service accounts.
+	ResourceKindServiceAccount = "service_account"
+	// ResourceKindUser is the kind of users.
+	ResourceKindUser = "user"
+)
+
+// MembershipResourceID returns the resource ID of an organization user or a project user.
+func MembershipResourceID(parentID, userID string) string {
+	return parentID + "/" + userID
+}
+
+// ParseMembershipResourceID splits the resource ID of an organization user or a project user
+// into the organization or project ID and the user ID.
+func ParseMembershipResourceID(resourceID string) (string, string, error) {
+	parentID, userID, ok := strings.Cut(resourceID, "/")
+	if !ok {
+		return "", "", fmt.Errorf("invalid membership resource ID %q", resourceID)
+	}
+	return parentID, userID, nil
+}
+
+// Change is an entry of the change log. Its ID is used as a revision, which increases monotonically.
+type Change struct {
+	gorm.Model
+
+	Kind       string `gorm:"index"`
+	ResourceID string
+	Type       ChangeType
+}
+
+// Revision returns the revision of the change.
+func (c *Change) Revision() int64 {
+	return int64(c.ID)
+}
+
+// recordChangeInTransaction records a change in a transaction.
+func recordChangeInTransaction(tx *gorm.DB, kind, resourceID string, typ ChangeType) error {
+	return tx.Create(&Change{
+		Kind:       kind,
+		ResourceID: resourceID,
+		Type:       typ,
+	}).Error
+}
+
+// ListChanges lists changes of the given kind whose revisions are greater than sinceRevision and
+// not greater than untilRevision, ordered by revision. At most limit changes are returned.
+func (s *S) ListChanges(kind string, sinceRevision, untilRevision int64, limit int) ([]*Change, error) {
+	var cs []*Change
+	if err := s.db.Where("kind = ? AND id > ? AND id <= ?", kind, sinceRevision, untilRevision).Order("id").Limit(limit).Find(&cs).Error; err != nil {
+		return nil, err
+	}
+	return cs, nil
+}
+
+// GetChange gets the change that has the given revision.
+func (s *S) GetChange(revision int64) (*Change, error) {
+	var c Change
+	if err := s.db.Where("id = ?", revision).Take(&c).Error; err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// GetLatestChangeAtOrBefore gets the change that has the largest revision not greater than the given revision.
+func (s *S) GetLatestChangeAtOrBefore(revision int64) (*Change, error) {
+	var c Change
+	if err := s.db.Where("id <= ?", revision).Order("id DESC").Take(&c).Error; err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// ListChangedResourceIDs returns the IDs of the resources of the given kind that have changed
+// after sinceRevision, ordered by the revision of their latest changes.
+func (s *S) ListChangedResourceIDs(kind string, sinceRevision int64) ([]string, error) {
+	var ids []string
+	if err := s.db.Model(&Change{}).
+		Select("resource_id").
+		Where("kind = ? AND id > ?", kind, sinceRevision).
+		Group("resource_id").
+		Order("MAX(id)").
+		Pluck("resource_id", &ids).Error; err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// GetStableRevision returns the largest revision up to which all changes are visible.
+//
+// Revisions are assigned when changes are inserted, not when their transactions are committed,
+// so a change can become visible after another change with a larger revision. A missing revision
+// is treated as a change in an open transaction until the change after it is older than
+// settleWindow. Rolled-back transactions also leave such missing revisions.
+func (s *S) GetStableRevision(now time.Time, settleWindow time.Duration) (int64, error) {
+	cutoff := now.Add(-settleWindow)
+	var ids []int64
+	if err := s.db.Model(&Change{}).Where("created_at > ?", cutoff).Order("id").Pluck("id", &ids).Error; err != nil {
+		return 0, err
+	}
+
+	var settled Change
+	if err := s.db.Where("created_at <= ?", cutoff).Order("id DESC").Limit(1).Find(&settled).Error; err != nil {
+		return 0, err
+	}
+	rev := settled.Revision()
+	if rev == 0 && len(ids) > 0 {
+		// All the changes are recent.
+		rev = ids[0] - 1
+	}
+	for _, id := range ids {
+		if id != rev+1 {
+			break
+		}
+		rev = id
+	}
+	return rev, nil
+}
+
+// DeleteChangesBefore deletes the changes created before the given time. The latest change is kept
+// so that revisions keep increasing. It returns the number of deleted changes.
+func (s *S) DeleteChangesBefore(t time.Time) (int64, error) {
+	latest, err := s.GetLatestRevision()
+	if err != nil {
+		return 0, err
+	}
+	res := s.db.Unscoped().Where("created_at < ? AND id < ?", t, latest).Delete(&Change{})
+	if err := res.Error; err != nil {
+		return 0, err
+	}
+	return res.RowsAffected, nil
+}
+
+// GetLatestRevision returns the revision of the latest change. It returns zero if there is no change.
+func (s *S) GetLatestRevision() (int64, error) {
+	var c Change
+	if err := s.db.Order("id DESC").Limit(1).Find(&c).Error; err != nil {
+		return 0, err
+	}
+	return c.Revision(), nil
+}
+
+// GetOldestRevision returns the revision of the oldest change that has not been pruned. It returns zero
+// if there is no change.
+func (s *S) GetOldestRevision() (int64, error) {
+	var c Change
+	if err := s.db.Order("id").Limit(1).Find(&c).Error; err != nil {
+		return 0, err
+	}
+	return c.Revision(), nil
+}
@@ -0,0 +1,240 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	v1 "github.com/llmariner/user-manager/api/v1"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestListChanges(t *testing.T) {
+	st, tearDown := NewTest(t)
+	defer tearDown()
+
+	rev, err := st.GetLatestRevision()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), rev)
+
+	_, err = st.CreateAPIKey(APIKeySpec{
+		APIKeyID:  "k0",
+		ProjectID: "p0",
+		UserID:    "u0",
+		Name:      "n0",
+		Secret:    "s0",
+	})
+	assert.NoError(t, err)
+	err = st.UpdateAPIKeySecretHash("k0", "s0", []byte("hash"), []byte("salt"))
+	assert.NoError(t, err)
+	err = st.DeleteAPIKey("k0", "p0")
+	assert.NoError(t, err)
+
+	cs, err := st.ListChanges(ResourceKindAPIKey, 0, rev+100, 10)
+	assert.NoError(t, err)
+	assert.Len(t, cs, 3)
+	var types []ChangeType
+	for _, c := range cs {
+		assert.Equal(t, "k0", c.ResourceID)
+		types = append(types, c.Type)
+	}
+	assert.Equal(t, []ChangeType{ChangeTypeCreated, ChangeTypeUpdated, ChangeTypeDeleted}, types)
+
+	rev, err = st.GetLatestRevision()
+	assert.NoError(t, err)
+	assert.Equal(t, cs[2].Revision(), rev)
+
+	cs, err = st.ListChanges(ResourceKindAPIKey, cs[0].Revision(), rev, 1)
+	assert.NoError(t, err)
+	assert.Len(t, cs, 1)
+	assert.Equal(t, ChangeTypeUpdated, cs[0].Type)
+
+	cs, err = st.ListChanges("other", 0, rev, 10)
+	assert.NoError(t, err)
+	assert.Empty(t, cs)
+}
+
+func TestListChangedResourceIDs(t *testing.T) {
+	st, tearDown := NewTest(t)
+	defer tearDown()
+
+	_, err := st.CreateProject(CreateProjectParams{ProjectID: "p0", Title: "t0"})
+	assert.NoError(t, err)
+	_, err = st.CreateProject(CreateProjectParams{ProjectID: "p1", Title: "t1"})
+	assert.NoError(t, err)
+	_, err = st.CreateProjectUser(CreateProjectUserParams{ProjectID: "p0", UserID: "u0"})
+	assert.NoError(t, err)
+	rev, err := st.GetLatestRevision()
+	assert.NoError(t, err)
+
+	err = st.UpdateProject("p0", map[string]interface{}{"title": "new"})
+	assert.NoError(t, err)
+	err = st.DeleteProject("p1")
+	assert.NoError(t, err)
+	err = st.UpdateProject("p0", map[string]interface{}{"title": "newer"})
+	assert.NoError(t, err)
+
+	ids, err := st.ListChangedResourceIDs(ResourceKindProject, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"p1", "p0"}, ids)
+
+	ids, err = st.ListChangedResourceIDs(ResourceKindProject, rev)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"p1", "p0"}, ids)
+
+	ids, err = st.ListChangedResourceIDs(ResourceKindProjectUser, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{MembershipResourceID("p0", "u0")}, ids)
+	projectID, userID, err := ParseMembershipResourceID(ids[0])
+	assert.NoError(t, err)
+	assert.Equal(t, "p0", projectID)
+	assert.Equal(t, "u0", userID)
+
+	ids, err = st.ListChangedResourceIDs(ResourceKindProjectUser, rev)
+	assert.NoError(t, err)
+	assert.Empty(t, ids)
+}
+
+func TestGetStableRevision(t *testing.T) {
+	st, tearDown := NewTest(t)
+	defer tearDown()
+
+	now := time.Now()
+	rev, err := st.GetStableRevision(now, time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), rev)
+
+	for _, id := range []string{"p0", "p1", "p2"} {
+		_, err := st.CreateProject(CreateProjectParams{ProjectID: id, Title: id})
+		assert.NoError(t, err)
+	}
+	latest, err := st.GetLatestRevision()
+	assert.NoError(t, err)
+
+	rev, err = st.GetStableRevision(now, time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, latest, rev)
+
+	// Simulate a change that has not been committed yet.
+	err = st.db.Unscoped().Where("id = ?", latest-1).Delete(&Change{}).Error
+	assert.NoError(t, err)
+	rev, err = st.GetStableRevision(now, time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, latest-2, rev)
+
+	// The missing revision is skipped once the change after it settles.
+	rev, err = st.GetStableRevision(now.Add(time.Hour), time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, latest, rev)
+}
+
+func TestDeleteChangesBefore(t *testing.T) {
+	st, tearDown := NewTest(t)
+	defer tearDown()
+
+	for _, id := range []string{"p0", "p1"} {
+		_, err := st.CreateProject(CreateProjectParams{ProjectID: id, Title: id})
+		assert.NoError(t, err)
+	}
+	latest, err := st.GetLatestRevision()
+	assert.NoError(t, err)
+
+	n, err := st.DeleteChangesBefore(time.Now().Add(-time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), n)
+
+	// The latest change is kept.
+	n, err = st.DeleteChangesBefore(time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	_, err = st.GetChange(latest - 1)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	c, err := st.GetChange(latest)
+	assert.NoError(t, err)
+	assert.Equal(t, "p1", c.ResourceID)
+
+	rev, err := st.GetLatestRevision()
+	assert.NoError(t, err)
+	assert.Equal(t, latest, rev)
+}
+
+func TestGetLatestChangeAtOrBefore(t *testing.T) {
+	st, tearDown := NewTest(t)
+	defer tearDown()
+
+	for _, id := range []string{"p0", "p1", "p2"} {
+		_, err := st.CreateProject(CreateProjectParams{ProjectID: id, Title: id})
+		assert.NoError(t, err)
+	}
+	latest, err := st.GetLatestRevision()
+	assert.NoError(t, err)
+
+	// Make a missing revision as a rolled-back transaction does.
+	err = st.db.Unscoped().Delete(&Change{}, latest-1).Error
+	assert.NoError(t, err)
+
+	c, err := st.GetLatestChangeAtOrBefore(latest - 1)
+	assert.NoError(t, err)
+	assert.Equal(t, latest-2, c.Revision())
+	c, err = st.GetLatestChangeAtOrBefore(latest)
+	assert.NoError(t, err)
+	assert.Equal(t, latest, c.Revision())
+	_, err = st.GetLatestChangeAtOrBefore(latest - 3)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	oldest, err := st.GetOldestRevision()
+	assert.NoError(t, err)
+	assert.Equal(t, latest-2, oldest)
+}
+
+func TestUpdateRoleRecordsAPIKeyChanges(t *testing.T) {
+	st, tearDown := NewTest(t)
+	defer tearDown()
+
+	for _, spec := range []APIKeySpec{
+		{APIKeyID: "k0", OrganizationID: "o0", ProjectID: "p0", UserID: "u0", Name: "n0", Secret: "s0"},
+		{APIKeyID: "k1", OrganizationID: "o0", ProjectID: "p1", UserID: "u0", Name: "n1", Secret: "s1"},
+		{APIKeyID: "k2", OrganizationID: "o1", ProjectID: "p2", UserID: "u0", Name: "n2", Secret: "s2"},
+		{APIKeyID: "k3", OrganizationID: "o0", ProjectID: "p0", UserID: "u1", Name: "n3", Secret: "s3"},
+	} {
+		_, err := st.CreateAPIKey(spec)
+		assert.NoError(t, err)
+	}
+	_, err := st.CreateOrganizationUser("o0", "u0", "r0")
+	assert.NoError(t, err)
+	_, err = st.CreateProjectUser(CreateProjectUserParams{
+		ProjectID:      "p0",
+		OrganizationID: "o0",
+		UserID:         "u0",
+		Role:           v1.ProjectRole_PROJECT_ROLE_MEMBER,
+	})
+	assert.NoError(t, err)
+
+	updatedKeyIDs := func(since int64) []string {
+		cs, err := st.ListChanges(ResourceKindAPIKey, since, since+100, 10)
+		assert.NoError(t, err)
+		var ids []string
+		for _, c := range cs {
+			assert.Equal(t, ChangeTypeUpdated, c.Type)
+			ids = append(ids, c.ResourceID)
+		}
+		return ids
+	}
+
+	rev, err := st.GetLatestRevision()
+	assert.NoError(t, err)
+	err = st.Transaction(func(tx *gorm.DB) error {
+		return UpdateOrganizationUserRoleInTransaction(tx, "o0", "u0", "r1")
+	})
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"k0", "k1"}, updatedKeyIDs(rev))
+
+	rev, err = st.GetLatestRevision()
+	assert.NoError(t, err)
+	err = st.Transaction(func(tx *gorm.DB) error {
+		return UpdateProjectUserRoleInTransaction(tx, "p0", "u0", v1.ProjectRole_PROJECT_ROLE_OWNER)
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"k0"}, updatedKeyIDs(rev))
+}
@@ -0,0 +1,176 @@
+package store
+
+import (
+	"time"
+
+	v1 "github.com/llmariner/user-manager/api/v1"
+	"google.golang.org/protobuf/proto"
+	"gorm.io/gorm"
+)
+
+// Invitation is a model for invitation.
+type Invitation struct {
+	gorm.Model
+
+	InvitationID string `gorm:"uniqueIndex"`
+
+	TenantID       string
+	OrganizationID string `gorm:"index"`
+
+	// UserID is the ID of the invited user.
+	UserID string `gorm:"index"`
+	Role   string
+
+	// ProjectBindings is the projects that the user joins. It is a marshaled v1.InvitationProjectBindings.
+	ProjectBindings []byte
+
+	InviterUserID string
+
+	// State is the state of the invitation. Expired invitations remain in the pending state.
+	State string
+	// ExpiresAt is the Unix time (in seconds) when the invitation expires.
+	ExpiresAt int64
+}
+
+// IsPending returns true if the invitation can still be accepted.
+func (i *Invitation) IsPending(now time.Time) bool {
+	return i.State == v1.InvitationState_INVITATION_STATE_PENDING.String() && i.ExpiresAt > now.Unix()
+}
+
+// GetProjectBindings returns the unmarshaled project bindings.
+func (i *Invitation) GetProjectBindings() ([]*v1.Invitation_ProjectBinding, error) {
+	var pb v1.InvitationProjectBindings
+	if err := proto.Unmarshal(i.ProjectBindings, &pb); err != nil {
+		return nil, err
+	}
+	return pb.ProjectBindings, nil
+}
+
+// ToProto converts the model to Porto.
+func (i *Invitation) ToProto(now time.Time) (*v1.Invitation, error) {
+	pbs, err := i.GetProjectBindings()
+	if err != nil {
+		return nil, err
+	}
+	state := v1.InvitationState(v1.InvitationState_value[i.State])
+	if state == v1.InvitationState_INVITATION_STATE_PENDING && !i.IsPending(now) {
+		state = v1.InvitationState_INVITATION_STATE_EXPIRED
+	}
+	return &v1.Invitation{
+		Id:              i.InvitationID,
+		OrganizationId:  i.OrganizationID,
+		UserId:          i.UserID,
+		Role:            v1.OrganizationRole(v1.OrganizationRole_value[i.Role]),
+		ProjectBindings: pbs,
+		InviterUserId:   i.InviterUserID,
+		State:           state,
+		CreatedAt:       i.CreatedAt.UTC().Unix(),
+		ExpiresAt:       i.ExpiresAt,
+	}, nil
+}
+
+// CreateInvitationParams is the parameters for CreateInvitation.
+type CreateInvitationParams struct {
+	InvitationID    string
+	TenantID        string
+	OrganizationID  string
+	UserID          string
+	Role            v1.OrganizationRole
+	ProjectBindings []*v1.Invitation_ProjectBinding
+	InviterUserID   string
+	ExpiresAt       int64
+}
+
+// CreateInvitation creates a new pending invitation.
+func (s *S) CreateInvitation(p CreateInvitationParams) (*Invitation, error) {
+	pbb, err := proto.Marshal(&v1.InvitationProjectBindings{ProjectBindings: p.ProjectBindings})
+	if err != nil {
+		return nil, err
+	}
+
+	inv := &Invitation{
+		InvitationID:    p.InvitationID,
+		TenantID:        p.TenantID,
+		OrganizationID:  p.OrganizationID,
+		UserID:          p.UserID,
+		Role:            p.Role.String(),
+		ProjectBindings: pbb,
+		InviterUserID:   p.InviterUserID,
+		State:           v1.InvitationState_INVITATION_STATE_PENDING.String(),
+		ExpiresAt:       p.ExpiresAt,
+	}
+	if err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(inv).Error; err != nil {
+			return err
+		}
+		return recordChangeInTransaction(tx, ResourceKindInvitation, p.InvitationID, ChangeTypeCreated)
+	}); err != nil {
+		return nil, err
+	}
+	return inv, nil
+}
+
+// GetInvitation gets an invitation by its ID.
+func (s *S) GetInvitation(id string) (*Invitation, error) {
+	var inv Invitation
+	if err := s.db.Where("invitation_id = ?", id).Take(&inv).Error; err != nil {
+		return nil, err
+	}
+	return &inv, nil
+}
+
+// ListInvitationsByOrganizationID lists invitations in the specified organization.
+func (s *S) ListInvitationsByOrganizationID(orgID string) ([]*Invitation, error) {
+	var invs []*Invitation
+	if err := s.db.Where("organization_id = ?", orgID).Order("id").Find(&invs).Error; err != nil {
+		return nil, err
+	}
+	return invs, nil
+}
+
+// ListPendingInvitationsByUserID lists invitations for the user that have neither been
+// responded to nor expired.
+func (s *S) ListPendingInvitationsByUserID(tenantID, userID string, now time.Time) ([]*Invitation, error) {
+	var invs []*Invitation
+	if err := s.db.Where("tenant_id = ? AND user_id = ? AND state = ? AND expires_at > ?",
+		tenantID,
+		userID,
+		v1.InvitationState_INVITATION_STATE_PENDING.String(),
+		now.Unix(),
+	).Order("id").Find(&invs).Error; err != nil {
+		return nil, err
+	}
+	return invs, nil
+}
+
+// UpdateInvitationStateInTransaction changes the state of a pending invitation in a transaction.
+// gorm.ErrRecordNotFound is returned if the invitation is not found or no longer pending.
+func UpdateInvitationStateInTransaction(tx *gorm.DB, id string, state v1.InvitationState) error {
+	res := tx.Model(&Invitation{}).
+		Where("invitation_id = ? AND state = ?", id, v1.InvitationState_INVITATION_STATE_PENDING.String()).
+		Update("state", state.String())
+	if err := res.Error; err != nil {
+		return err
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return recordChangeInTransaction(tx, ResourceKindInvitation, id, ChangeTypeUpdated)
+}
+
+// DeleteAllInvitationsInTransaction deletes all invitations in the specified organization.
+func DeleteAllInvitationsInTransaction(tx *gorm.DB, orgID string) error {
+	var ids []string
+	if err := tx.Model(&Invitation{}).Where("organization_id = ?", orgID).Pluck("invitation_id", &ids).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("organization_id = ?", orgID).Delete(&Invitation{}).Error; err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err := recordChangeInTransaction(tx, ResourceKindInvitation, id, ChangeTypeDeleted); err != nil {
+			return err
+		}
+	}
+	return nil
+}
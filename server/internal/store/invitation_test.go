@@ -0,0 +1,87 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	v1 "github.com/llmariner/user-manager/api/v1"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestInvitation(t *testing.T) {
+	s, tearDown := NewTest(t)
+	defer tearDown()
+
+	now := time.Now()
+	for _, p := range []CreateInvitationParams{
+		{
+			InvitationID:   "i0",
+			OrganizationID: "o0",
+			UserID:         "u0",
+			Role:           v1.OrganizationRole_ORGANIZATION_ROLE_READER,
+			ProjectBindings: []*v1.Invitation_ProjectBinding{
+				{ProjectId: "p0", Role: v1.ProjectRole_PROJECT_ROLE_MEMBER},
+			},
+			ExpiresAt: now.Add(time.Hour).Unix(),
+		},
+		{InvitationID: "i1", OrganizationID: "o1", UserID: "u0", Role: v1.OrganizationRole_ORGANIZATION_ROLE_OWNER, ExpiresAt: now.Add(-time.Hour).Unix()},
+		{InvitationID: "i2", OrganizationID: "o0", UserID: "u1", Role: v1.OrganizationRole_ORGANIZATION_ROLE_READER, ExpiresAt: now.Add(time.Hour).Unix()},
+		{InvitationID: "i3", TenantID: "t1", OrganizationID: "o2", UserID: "u0", Role: v1.OrganizationRole_ORGANIZATION_ROLE_READER, ExpiresAt: now.Add(time.Hour).Unix()},
+	} {
+		_, err := s.CreateInvitation(p)
+		assert.NoError(t, err)
+	}
+
+	inv, err := s.GetInvitation("i0")
+	assert.NoError(t, err)
+	assert.True(t, inv.IsPending(now))
+	pbs, err := inv.GetProjectBindings()
+	assert.NoError(t, err)
+	assert.Len(t, pbs, 1)
+	assert.Equal(t, "p0", pbs[0].ProjectId)
+
+	invs, err := s.ListInvitationsByOrganizationID("o0")
+	assert.NoError(t, err)
+	assert.Len(t, invs, 2)
+
+	// Expired invitations and invitations in other tenants are excluded.
+	invs, err = s.ListPendingInvitationsByUserID("", "u0", now)
+	assert.NoError(t, err)
+	assert.Len(t, invs, 1)
+	assert.Equal(t, "i0", invs[0].InvitationID)
+
+	inv, err = s.GetInvitation("i1")
+	assert.NoError(t, err)
+	p, err := inv.ToProto(now)
+	assert.NoError(t, err)
+	assert.Equal(t, v1.InvitationState_INVITATION_STATE_EXPIRED, p.State)
+
+	err = UpdateInvitationStateInTransaction(s.db, "i0", v1.InvitationState_INVITATION_STATE_ACCEPTED)
+	assert.NoError(t, err)
+	// The invitation is no longer pending.
+	err = UpdateInvitationStateInTransaction(s.db, "i0", v1.InvitationState_INVITATION_STATE_REVOKED)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	inv, err = s.GetInvitation("i0")
+	assert.NoError(t, err)
+	p, err = inv.ToProto(now)
+	assert.NoError(t, err)
+	assert.Equal(t, v1.InvitationState_INVITATION_STATE_ACCEPTED, p.State)
+
+	invs, err = s.ListPendingInvitationsByUserID("", "u0", now)
+	assert.NoError(t, err)
+	assert.Empty(t, invs)
+
+	_, err = s.GetInvitation("i9")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	err = DeleteAllInvitationsInTransaction(s.db, "o0")
+	assert.NoError(t, err)
+	invs, err = s.ListInvitationsByOrganizationID("o0")
+	assert.NoError(t, err)
+	assert.Empty(t, invs)
+	invs, err = s.ListInvitationsByOrganizationID("o1")
+	assert.NoError(t, err)
+	assert.Len(t, invs, 1)
+}
@@ -3,6 +3,7 @@ package store
 import (
 	v1 "github.com/llmariner/user-manager/api/v1"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // Organization is a model for organization
@@ -29,7 +30,15 @@ func (o *Organization) ToProto() *v1.Organization {
 
 // CreateOrganization creates a new organization.
 func (s *S) CreateOrganization(tenantID, orgID, title string, isDefault bool) (*Organization, error) {
-	return CreateOrganizationInTransaction(s.db, tenantID, orgID, title, isDefault)
+	var org *Organization
+	if err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		org, err = CreateOrganizationInTransaction(tx, tenantID, orgID, title, isDefault)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return org, nil
 }
 
 // CreateOrganizationInTransaction creates a new organization in a transaction.
@@ -43,6 +52,9 @@ func CreateOrganizationInTransaction(tx *gorm.DB, tenantID, orgID, title string,
 	if err := tx.Create(org).Error; err != nil {
 		return nil, err
 	}
+	if err := recordChangeInTransaction(tx, ResourceKindOrganization, orgID, ChangeTypeCreated); err != nil {
+		return nil, err
+	}
 	return org, nil
 }
 
@@ -55,6 +67,13 @@ func (s *S) GetOrganizationByTenantIDAndOrgID(tenantID, orgID string) (*Organiza
 	return &org, nil
 }
 
+// LockOrganizationInTransaction locks the organization row until the end of the transaction
+// so that transactions that lock the same organization are serialized.
+func LockOrganizationInTransaction(tx *gorm.DB, orgID string) error {
+	var org Organization
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("organization_id = ?", orgID).First(&org).Error
+}
+
 // GetDefaultOrganization gets a default organization.
 func (s *S) GetDefaultOrganization(tenantID string) (*Organization, error) {
 	var org Organization
@@ -82,9 +101,34 @@ func (s *S) ListAllOrganizations() ([]*Organization, error) {
 	return orgs, nil
 }
 
+// ListOrganizationsByIDs lists organizations that have the given IDs.
+func (s *S) ListOrganizationsByIDs(orgIDs []string) ([]*Organization, error) {
+	var orgs []*Organization
+	if err := s.db.Where("organization_id IN ?", orgIDs).Find(&orgs).Error; err != nil {
+		return nil, err
+	}
+	return orgs, nil
+}
+
+// UpdateOrganization updates an existing organization.
+func (s *S) UpdateOrganization(orgID string, updates map[string]interface{}) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&Organization{}).Where("organization_id = ?", orgID).Updates(updates)
+		if err := res.Error; err != nil {
+			return err
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return recordChangeInTransaction(tx, ResourceKindOrganization, orgID, ChangeTypeUpdated)
+	})
+}
+
 // DeleteOrganization deletes an organization.
 func (s *S) DeleteOrganization(orgID string) error {
-	return DeleteOrganizationInTransaction(s.db, orgID)
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		return DeleteOrganizationInTransaction(tx, orgID)
+	})
 }
 
 // DeleteOrganizationInTransaction deletes an organization in a transaction.
@@ -96,6 +140,5 @@ func DeleteOrganizationInTransaction(tx *gorm.DB, orgID string) error {
 	if res.RowsAffected == 0 {
 		return gorm.ErrRecordNotFound
 	}
-
-	return nil
+	return recordChangeInTransaction(tx, ResourceKindOrganization, orgID, ChangeTypeDeleted)
 }
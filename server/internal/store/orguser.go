@@ -30,7 +30,15 @@ func (o *OrganizationUser) ToProto(internalUserID string) *v1.OrganizationUser {
 
 // CreateOrganizationUser creates a organization user.
 func (s *S) CreateOrganizationUser(orgID, userID, role string) (*OrganizationUser, error) {
-	return CreateOrganizationUserInTransaction(s.db, orgID, userID, role)
+	var orgusr *OrganizationUser
+	if err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		orgusr, err = CreateOrganizationUserInTransaction(tx, orgID, userID, role)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return orgusr, nil
 }
 
 // CreateOrganizationUserInTransaction creates a organization user in a transaction.
@@ -44,6 +52,9 @@ func CreateOrganizationUserInTransaction(tx *gorm.DB, orgID, userID, role string
 	if err := tx.Create(orgusr).Error; err != nil {
 		return nil, err
 	}
+	if err := recordChangeInTransaction(tx, ResourceKindOrganizationUser, MembershipResourceID(orgID, userID), ChangeTypeCreated); err != nil {
+		return nil, err
+	}
 	return orgusr, nil
 }
 
@@ -83,6 +94,16 @@ func (s *S) ListOrganizationUsersByUserID(userID string) ([]OrganizationUser, er
 	return users, nil
 }
 
+// ListOrganizationUsersByOrganizationIDsAndUserIDs lists organization users whose organization and user are
+// in the specified lists.
+func (s *S) ListOrganizationUsersByOrganizationIDsAndUserIDs(orgIDs, userIDs []string) ([]OrganizationUser, error) {
+	var users []OrganizationUser
+	if err := s.db.Where("organization_id IN ? AND user_id IN ?", orgIDs, userIDs).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
 // ListAllOrganizationUsers lists all organization users.
 func (s *S) ListAllOrganizationUsers() ([]OrganizationUser, error) {
 	var users []OrganizationUser
@@ -94,25 +115,58 @@ func (s *S) ListAllOrganizationUsers() ([]OrganizationUser, error) {
 
 // HideOrganizationUser sets the hide field of the organization user to true.
 func (s *S) HideOrganizationUser(orgID, userID string) error {
-	result := s.db.Model(&OrganizationUser{}).
-		Where("organization_id = ?", orgID).
-		Where("user_id = ?", userID).
-		Updates(map[string]interface{}{
-			"hidden": true,
-		})
-	if err := result.Error; err != nil {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&OrganizationUser{}).
+			Where("organization_id = ?", orgID).
+			Where("user_id = ?", userID).
+			Updates(map[string]interface{}{
+				"hidden": true,
+			})
+		if err := result.Error; err != nil {
+			return err
+		}
+
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return recordChangeInTransaction(tx, ResourceKindOrganizationUser, MembershipResourceID(orgID, userID), ChangeTypeUpdated)
+	})
+}
+
+// UpdateOrganizationUserRoleInTransaction updates the role of a organization user in a transaction.
+func UpdateOrganizationUserRoleInTransaction(tx *gorm.DB, orgID, userID, role string) error {
+	res := tx.Model(&OrganizationUser{}).
+		Where("organization_id = ? AND user_id = ?", orgID, userID).
+		Update("role", role)
+	if err := res.Error; err != nil {
 		return err
 	}
-
-	if result.RowsAffected == 0 {
+	if res.RowsAffected == 0 {
 		return gorm.ErrRecordNotFound
 	}
-	return nil
+	if err := recordChangeInTransaction(tx, ResourceKindOrganizationUser, MembershipResourceID(orgID, userID), ChangeTypeUpdated); err != nil {
+		return err
+	}
+	// API keys carry the organization role of their owners.
+	return recordAPIKeyUpdatesByUserIDInTransaction(tx, orgID, "", userID)
+}
+
+// CountOrganizationOwnersInTransaction counts the owners of the organization in a transaction.
+func CountOrganizationOwnersInTransaction(tx *gorm.DB, orgID string) (int64, error) {
+	var count int64
+	if err := tx.Model(&OrganizationUser{}).
+		Where("organization_id = ? AND role = ?", orgID, v1.OrganizationRole_ORGANIZATION_ROLE_OWNER.String()).
+		Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
 }
 
 // DeleteOrganizationUser deletes a organization user.
 func (s *S) DeleteOrganizationUser(orgID, userID string) error {
-	return DeleteOrganizationUserInTransaction(s.db, orgID, userID)
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		return DeleteOrganizationUserInTransaction(tx, orgID, userID)
+	})
 }
 
 // DeleteOrganizationUserInTransaction deletes a organization user in a transaction.
@@ -124,13 +178,22 @@ func DeleteOrganizationUserInTransaction(tx *gorm.DB, orgID, userID string) erro
 	if res.RowsAffected == 0 {
 		return gorm.ErrRecordNotFound
 	}
-	return nil
+	return recordChangeInTransaction(tx, ResourceKindOrganizationUser, MembershipResourceID(orgID, userID), ChangeTypeDeleted)
 }
 
 // DeleteAllOrganizationUsersInTransaction deletes all organization users in the specified organization.
 func DeleteAllOrganizationUsersInTransaction(tx *gorm.DB, orgID string) error {
+	var userIDs []string
+	if err := tx.Model(&OrganizationUser{}).Where("organization_id = ?", orgID).Pluck("user_id", &userIDs).Error; err != nil {
+		return err
+	}
 	if err := tx.Unscoped().Where("organization_id = ?", orgID).Delete(&OrganizationUser{}).Error; err != nil {
 		return err
 	}
+	for _, userID := range userIDs {
+		if err := recordChangeInTransaction(tx, ResourceKindOrganizationUser, MembershipResourceID(orgID, userID), ChangeTypeDeleted); err != nil {
+			return err
+		}
+	}
 	return nil
 }
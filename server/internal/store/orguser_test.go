@@ -4,6 +4,7 @@ import (
 	"errors"
 	"testing"
 
+	v1 "github.com/llmariner/user-manager/api/v1"
 	"github.com/stretchr/testify/assert"
 	"gorm.io/gorm"
 )
@@ -47,6 +48,12 @@ func TestOrganizationUser(t *testing.T) {
 	assert.Equal(t, "user1", users[0].UserID)
 	assert.Equal(t, "o1", users[0].OrganizationID)
 
+	users, err = s.ListOrganizationUsersByOrganizationIDsAndUserIDs([]string{"o1", "o2"}, []string{"user2", "user3"})
+	assert.NoError(t, err)
+	assert.Len(t, users, 1)
+	assert.Equal(t, "user2", users[0].UserID)
+	assert.Equal(t, "o2", users[0].OrganizationID)
+
 	err = s.DeleteOrganizationUser("o1", "user1")
 	assert.NoError(t, err)
 
@@ -74,3 +81,29 @@ func TestHideOrganizationUser(t *testing.T) {
 	assert.NoError(t, err)
 	assert.True(t, ou.Hidden)
 }
+
+func TestCountOrganizationOwnersInTransaction(t *testing.T) {
+	s, tearDown := NewTest(t)
+	defer tearDown()
+
+	for _, u := range []struct {
+		orgID, userID string
+		role          v1.OrganizationRole
+	}{
+		{"o1", "user1", v1.OrganizationRole_ORGANIZATION_ROLE_OWNER},
+		{"o1", "user2", v1.OrganizationRole_ORGANIZATION_ROLE_OWNER},
+		{"o1", "user3", v1.OrganizationRole_ORGANIZATION_ROLE_READER},
+		{"o2", "user1", v1.OrganizationRole_ORGANIZATION_ROLE_READER},
+	} {
+		_, err := s.CreateOrganizationUser(u.orgID, u.userID, u.role.String())
+		assert.NoError(t, err)
+	}
+
+	n, err := CountOrganizationOwnersInTransaction(s.db, "o1")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+
+	n, err = CountOrganizationOwnersInTransaction(s.db, "o2")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), n)
+}
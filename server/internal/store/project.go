@@ -4,6 +4,7 @@ import (
 	v1 "github.com/llmariner/user-manager/api/v1"
 	"google.golang.org/protobuf/proto"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // Project is a model for project.
@@ -83,7 +84,15 @@ type CreateProjectParams struct {
 
 // CreateProject creates a new project.
 func (s *S) CreateProject(p CreateProjectParams) (*Project, error) {
-	return CreateProjectInTransaction(s.db, p)
+	var project *Project
+	if err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		project, err = CreateProjectInTransaction(tx, p)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return project, nil
 }
 
 // CreateProjectInTransaction creates a new project in a transaction.
@@ -104,6 +113,9 @@ func CreateProjectInTransaction(tx *gorm.DB, p CreateProjectParams) (*Project, e
 	if err := tx.Create(project).Error; err != nil {
 		return nil, err
 	}
+	if err := recordChangeInTransaction(tx, ResourceKindProject, p.ProjectID, ChangeTypeCreated); err != nil {
+		return nil, err
+	}
 	return project, nil
 }
 
@@ -123,6 +135,13 @@ func (s *S) GetProject(p GetProjectParams) (*Project, error) {
 	return &project, nil
 }
 
+// LockProjectInTransaction locks the project row until the end of the transaction
+// so that transactions that lock the same project are serialized.
+func LockProjectInTransaction(tx *gorm.DB, projectID string) error {
+	var project Project
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("project_id = ?", projectID).First(&project).Error
+}
+
 // GetDefaultProject gets a default project.
 func (s *S) GetDefaultProject(tenantID string) (*Project, error) {
 	var prj Project
@@ -159,9 +178,20 @@ func (s *S) ListAllProjects() ([]*Project, error) {
 	return projects, nil
 }
 
+// ListProjectsByIDs lists projects that have the given IDs.
+func (s *S) ListProjectsByIDs(projectIDs []string) ([]*Project, error) {
+	var projects []*Project
+	if err := s.db.Where("project_id IN ?", projectIDs).Find(&projects).Error; err != nil {
+		return nil, err
+	}
+	return projects, nil
+}
+
 // DeleteProject deletes an project.
 func (s *S) DeleteProject(projectID string) error {
-	return DeleteProjectInTransaction(s.db, projectID)
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		return DeleteProjectInTransaction(tx, projectID)
+	})
 }
 
 // DeleteProjectInTransaction deletes an project in a transaction.
@@ -173,7 +203,7 @@ func DeleteProjectInTransaction(tx *gorm.DB, projectID string) error {
 	if res.RowsAffected == 0 {
 		return gorm.ErrRecordNotFound
 	}
-	return nil
+	return recordChangeInTransaction(tx, ResourceKindProject, projectID, ChangeTypeDeleted)
 }
 
 // UpdateProject updates an existing project.
@@ -181,13 +211,15 @@ func (s *S) UpdateProject(
 	projectID string,
 	updates map[string]interface{},
 ) error {
-	res := s.db.Model(&Project{}).Where("project_id = ?", projectID).
-		Updates(updates)
-	if err := res.Error; err != nil {
-		return err
-	}
-	if res.RowsAffected == 0 {
-		return gorm.ErrRecordNotFound
-	}
-	return nil
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&Project{}).Where("project_id = ?", projectID).
+			Updates(updates)
+		if err := res.Error; err != nil {
+			return err
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return recordChangeInTransaction(tx, ResourceKindProject, projectID, ChangeTypeUpdated)
+	})
 }
@@ -39,7 +39,15 @@ type CreateProjectUserParams struct {
 
 // CreateProjectUser creates a project user.
 func (s *S) CreateProjectUser(p CreateProjectUserParams) (*ProjectUser, error) {
-	return CreateProjectUserInTransaction(s.db, p)
+	var pusr *ProjectUser
+	if err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		pusr, err = CreateProjectUserInTransaction(tx, p)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return pusr, nil
 }
 
 // CreateProjectUserInTransaction creates a project user in a transaction.
@@ -54,6 +62,9 @@ func CreateProjectUserInTransaction(tx *gorm.DB, p CreateProjectUserParams) (*Pr
 	if err := tx.Create(pusr).Error; err != nil {
 		return nil, err
 	}
+	if err := recordChangeInTransaction(tx, ResourceKindProjectUser, MembershipResourceID(p.ProjectID, p.UserID), ChangeTypeCreated); err != nil {
+		return nil, err
+	}
 	return pusr, nil
 }
 
@@ -84,6 +95,24 @@ func (s *S) ListProjectUsersByUserID(userID string) ([]ProjectUser, error) {
 	return users, nil
 }
 
+// ListProjectUsersByProjectIDs lists project users in the specified projects.
+func (s *S) ListProjectUsersByProjectIDs(projectIDs []string) ([]ProjectUser, error) {
+	var users []ProjectUser
+	if err := s.db.Where("project_id IN ?", projectIDs).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// ListProjectUsersByProjectIDsAndUserIDs lists project users whose project and user are in the specified lists.
+func (s *S) ListProjectUsersByProjectIDsAndUserIDs(projectIDs, userIDs []string) ([]ProjectUser, error) {
+	var users []ProjectUser
+	if err := s.db.Where("project_id IN ? AND user_id IN ?", projectIDs, userIDs).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
 // CountProjectUsersByProjectID counts the number of project users in the specified project.
 func (s *S) CountProjectUsersByProjectID(projectID string) (int64, error) {
 	var numUsers int64
@@ -104,25 +133,29 @@ func (s *S) ListAllProjectUsers() ([]ProjectUser, error) {
 
 // HideProjectUser sets the hide field of the project user to true.
 func (s *S) HideProjectUser(projectID, userID string) error {
-	result := s.db.Model(&ProjectUser{}).
-		Where("project_id = ?", projectID).
-		Where("user_id = ?", userID).
-		Updates(map[string]interface{}{
-			"hidden": true,
-		})
-	if err := result.Error; err != nil {
-		return err
-	}
-
-	if result.RowsAffected == 0 {
-		return gorm.ErrRecordNotFound
-	}
-	return nil
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&ProjectUser{}).
+			Where("project_id = ?", projectID).
+			Where("user_id = ?", userID).
+			Updates(map[string]interface{}{
+				"hidden": true,
+			})
+		if err := result.Error; err != nil {
+			return err
+		}
+
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return recordChangeInTransaction(tx, ResourceKindProjectUser, MembershipResourceID(projectID, userID), ChangeTypeUpdated)
+	})
 }
 
 // DeleteProjectUser deletes a project user.
 func (s *S) DeleteProjectUser(projectID, userID string) error {
-	return DeleteProjectUserInTransaction(s.db, projectID, userID)
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		return DeleteProjectUserInTransaction(tx, projectID, userID)
+	})
 }
 
 // DeleteProjectUserInTransaction deletes a project user in a transaction.
@@ -134,14 +167,51 @@ func DeleteProjectUserInTransaction(tx *gorm.DB, projectID, userID string) error
 	if res.RowsAffected == 0 {
 		return gorm.ErrRecordNotFound
 	}
-	return nil
+	return recordChangeInTransaction(tx, ResourceKindProjectUser, MembershipResourceID(projectID, userID), ChangeTypeDeleted)
+}
+
+// UpdateProjectUserRoleInTransaction updates the role of a project user in a transaction.
+func UpdateProjectUserRoleInTransaction(tx *gorm.DB, projectID, userID string, role v1.ProjectRole) error {
+	res := tx.Model(&ProjectUser{}).
+		Where("project_id = ? AND user_id = ?", projectID, userID).
+		Update("role", role.String())
+	if err := res.Error; err != nil {
+		return err
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	if err := recordChangeInTransaction(tx, ResourceKindProjectUser, MembershipResourceID(projectID, userID), ChangeTypeUpdated); err != nil {
+		return err
+	}
+	// API keys carry the project role of their owners.
+	return recordAPIKeyUpdatesByUserIDInTransaction(tx, "", projectID, userID)
+}
+
+// CountProjectOwnersInTransaction counts the owners of the project in a transaction.
+func CountProjectOwnersInTransaction(tx *gorm.DB, projectID string) (int64, error) {
+	var count int64
+	if err := tx.Model(&ProjectUser{}).
+		Where("project_id = ? AND role = ?", projectID, v1.ProjectRole_PROJECT_ROLE_OWNER.String()).
+		Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
 }
 
 // DeleteAllProjectUsersInTransaction deletes all project users in a transaction.
 func DeleteAllProjectUsersInTransaction(tx *gorm.DB, projectID string) error {
+	var userIDs []string
+	if err := tx.Model(&ProjectUser{}).Where("project_id = ?", projectID).Pluck("user_id", &userIDs).Error; err != nil {
+		return err
+	}
 	if err := tx.Unscoped().Where("project_id = ?", projectID).Delete(&ProjectUser{}).Error; err != nil {
 		return err
 	}
-
+	for _, userID := range userIDs {
+		if err := recordChangeInTransaction(tx, ResourceKindProjectUser, MembershipResourceID(projectID, userID), ChangeTypeDeleted); err != nil {
+			return err
+		}
+	}
 	return nil
 }
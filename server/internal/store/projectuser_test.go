@@ -58,6 +58,12 @@ func TestProjectUser(t *testing.T) {
 	assert.Equal(t, "user1", users[0].UserID)
 	assert.Equal(t, "p1", users[0].ProjectID)
 
+	users, err = s.ListProjectUsersByProjectIDsAndUserIDs([]string{"p1", "p2"}, []string{"user2", "user3"})
+	assert.NoError(t, err)
+	assert.Len(t, users, 1)
+	assert.Equal(t, "user2", users[0].UserID)
+	assert.Equal(t, "p2", users[0].ProjectID)
+
 	err = s.DeleteProjectUser("p1", "user1")
 	assert.NoError(t, err)
 
@@ -90,3 +96,25 @@ func TestHideProjectUser(t *testing.T) {
 	assert.NoError(t, err)
 	assert.True(t, pu.Hidden)
 }
+
+func TestCountProjectOwnersInTransaction(t *testing.T) {
+	s, tearDown := NewTest(t)
+	defer tearDown()
+
+	for _, p := range []CreateProjectUserParams{
+		{ProjectID: "p1", OrganizationID: "o1", UserID: "user1", Role: v1.ProjectRole_PROJECT_ROLE_OWNER},
+		{ProjectID: "p1", OrganizationID: "o1", UserID: "user2", Role: v1.ProjectRole_PROJECT_ROLE_MEMBER},
+		{ProjectID: "p2", OrganizationID: "o1", UserID: "user1", Role: v1.ProjectRole_PROJECT_ROLE_MEMBER},
+	} {
+		_, err := s.CreateProjectUser(p)
+		assert.NoError(t, err)
+	}
+
+	n, err := CountProjectOwnersInTransaction(s.db, "p1")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	n, err = CountProjectOwnersInTransaction(s.db, "p2")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), n)
+}
@@ -0,0 +1,122 @@
+package store
+
+import (
+	v1 "github.com/llmariner/user-manager/api/v1"
+	"gorm.io/gorm"
+)
+
+// ServiceAccount is a model for service account.
+type ServiceAccount struct {
+	gorm.Model
+
+	ServiceAccountID string `gorm:"uniqueIndex"`
+
+	TenantID       string
+	OrganizationID string
+	ProjectID      string `gorm:"uniqueIndex:idx_service_account_project_id_name"`
+
+	Name        string `gorm:"uniqueIndex:idx_service_account_project_id_name"`
+	Description string
+
+	// UserID is the ID of the user that represents the service account. The roles of the service account
+	// are the roles of this user in the organization and the project.
+	UserID string `gorm:"uniqueIndex"`
+}
+
+// ToProto converts the model to Porto.
+func (a *ServiceAccount) ToProto(orgRole v1.OrganizationRole, projectRole v1.ProjectRole, apiKeyCount int64) *v1.ServiceAccount {
+	return &v1.ServiceAccount{
+		Id:               a.ServiceAccountID,
+		Name:             a.Name,
+		Description:      a.Description,
+		OrganizationId:   a.OrganizationID,
+		ProjectId:        a.ProjectID,
+		UserId:           a.UserID,
+		OrganizationRole: orgRole,
+		ProjectRole:      projectRole,
+		CreatedAt:        a.CreatedAt.UTC().Unix(),
+		ApiKeyCount:      int32(apiKeyCount),
+	}
+}
+
+// CreateServiceAccountParams is the parameters for CreateServiceAccountInTransaction.
+type CreateServiceAccountParams struct {
+	ServiceAccountID string
+	TenantID         string
+	OrganizationID   string
+	ProjectID        string
+	Name             string
+	Description      string
+	UserID           string
+}
+
+// CreateServiceAccountInTransaction creates a new service account in a transaction.
+func CreateServiceAccountInTransaction(tx *gorm.DB, p CreateServiceAccountParams) (*ServiceAccount, error) {
+	sa := &ServiceAccount{
+		ServiceAccountID: p.ServiceAccountID,
+		TenantID:         p.TenantID,
+		OrganizationID:   p.OrganizationID,
+		ProjectID:        p.ProjectID,
+		Name:             p.Name,
+		Description:      p.Description,
+		UserID:           p.UserID,
+	}
+	if err := tx.Create(sa).Error; err != nil {
+		return nil, err
+	}
+	if err := recordChangeInTransaction(tx, ResourceKindServiceAccount, p.ServiceAccountID, ChangeTypeCreated); err != nil {
+		return nil, err
+	}
+	return sa, nil
+}
+
+// GetServiceAccount gets a service account by its ID and project ID.
+func (s *S) GetServiceAccount(id, projectID string) (*ServiceAccount, error) {
+	var sa ServiceAccount
+	if err := s.db.Where("service_account_id = ? AND project_id = ?", id, projectID).Take(&sa).Error; err != nil {
+		return nil, err
+	}
+	return &sa, nil
+}
+
+// GetServiceAccountByUserIDInTransaction gets a service account by the ID of its user in a transaction.
+func GetServiceAccountByUserIDInTransaction(tx *gorm.DB, userID string) (*ServiceAccount, error) {
+	var sa ServiceAccount
+	if err := tx.Where("user_id = ?", userID).Take(&sa).Error; err != nil {
+		return nil, err
+	}
+	return &sa, nil
+}
+
+// ListServiceAccountsByProjectID lists service accounts in the specified project.
+func (s *S) ListServiceAccountsByProjectID(projectID string) ([]*ServiceAccount, error) {
+	var sas []*ServiceAccount
+	if err := s.db.Where("project_id = ?", projectID).Order("name").Find(&sas).Error; err != nil {
+		return nil, err
+	}
+	return sas, nil
+}
+
+// UpdateServiceAccountInTransaction updates an existing service account in a transaction.
+func UpdateServiceAccountInTransaction(tx *gorm.DB, id string, updates map[string]interface{}) error {
+	res := tx.Model(&ServiceAccount{}).Where("service_account_id = ?", id).Updates(updates)
+	if err := res.Error; err != nil {
+		return err
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return recordChangeInTransaction(tx, ResourceKindServiceAccount, id, ChangeTypeUpdated)
+}
+
+// DeleteServiceAccountInTransaction deletes a service account in a transaction.
+func DeleteServiceAccountInTransaction(tx *gorm.DB, id string) error {
+	res := tx.Unscoped().Where("service_account_id = ?", id).Delete(&ServiceAccount{})
+	if err := res.Error; err != nil {
+		return err
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return recordChangeInTransaction(tx, ResourceKindServiceAccount, id, ChangeTypeDeleted)
+}
@@ -0,0 +1,53 @@
+package store
+
+import (
+	"testing"
+
+	gerrors "github.com/llmariner/common/pkg/gormlib/errors"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestServiceAccount(t *testing.T) {
+	s, tearDown := NewTest(t)
+	defer tearDown()
+
+	for _, p := range []CreateServiceAccountParams{
+		{ServiceAccountID: "sa0", ProjectID: "p0", Name: "ci", UserID: "u0"},
+		{ServiceAccountID: "sa1", ProjectID: "p0", Name: "bot", UserID: "u1"},
+		{ServiceAccountID: "sa2", ProjectID: "p1", Name: "ci", UserID: "u2"},
+	} {
+		_, err := CreateServiceAccountInTransaction(s.db, p)
+		assert.NoError(t, err)
+	}
+
+	// The name must be unique in the project.
+	_, err := CreateServiceAccountInTransaction(s.db, CreateServiceAccountParams{ServiceAccountID: "sa3", ProjectID: "p0", Name: "ci", UserID: "u3"})
+	assert.True(t, gerrors.IsUniqueConstraintViolation(err))
+
+	sas, err := s.ListServiceAccountsByProjectID("p0")
+	assert.NoError(t, err)
+	assert.Len(t, sas, 2)
+	assert.Equal(t, "bot", sas[0].Name)
+	assert.Equal(t, "ci", sas[1].Name)
+
+	err = UpdateServiceAccountInTransaction(s.db, "sa0", map[string]interface{}{"description": "desc"})
+	assert.NoError(t, err)
+	sa, err := s.GetServiceAccount("sa0", "p0")
+	assert.NoError(t, err)
+	assert.Equal(t, "desc", sa.Description)
+
+	_, err = s.GetServiceAccount("sa0", "p1")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	sa, err = GetServiceAccountByUserIDInTransaction(s.db, "u2")
+	assert.NoError(t, err)
+	assert.Equal(t, "sa2", sa.ServiceAccountID)
+
+	err = DeleteServiceAccountInTransaction(s.db, "sa0")
+	assert.NoError(t, err)
+	err = DeleteServiceAccountInTransaction(s.db, "sa0")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	_, err = s.GetServiceAccount("sa0", "p0")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
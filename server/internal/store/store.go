@@ -1,6 +1,8 @@
 package store
 
 import (
+	"fmt"
+
 	"gorm.io/gorm"
 )
 
@@ -27,13 +29,43 @@ func (s *S) Transaction(f func(*gorm.DB) error) error {
 }
 
 func autoMigrate(db *gorm.DB) error {
-	return db.AutoMigrate(
+	if err := db.AutoMigrate(
 		&APIKey{},
+		&Change{},
+		&Invitation{},
 		&Organization{},
 		&OrganizationUser{},
 		&Project{},
 		&ProjectUser{},
+		&ServiceAccount{},
 		&User{},
 		&DataKey{},
-	)
+	); err != nil {
+		return err
+	}
+	return backfillNullColumns(db)
+}
+
+// nullColumnDefaults are the columns added to existing tables and the values of the columns in the rows
+// that existed before the columns were added. Queries compare these columns with values, and such comparisons
+// never match NULL.
+var nullColumnDefaults = []struct {
+	model  interface{}
+	column string
+	value  interface{}
+}{
+	{&APIKey{}, "disabled", false},
+	{&APIKey{}, "expires_at", 0},
+}
+
+// backfillNullColumns sets the columns in nullColumnDefaults to their default values if they are NULL.
+// The columns have default values in the schema, but the columns added by an earlier version without
+// the default values are NULL in the rows that existed at that time.
+func backfillNullColumns(db *gorm.DB) error {
+	for _, c := range nullColumnDefaults {
+		if err := db.Unscoped().Model(c.model).Where(c.column+" IS NULL").UpdateColumn(c.column, c.value).Error; err != nil {
+			return fmt.Errorf("backfill %s: %s", c.column, err)
+		}
+	}
+	return nil
 }
@@ -0,0 +1,196 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/llmariner/common/pkg/gormlib/testdb"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// baselineAPIKey is the schema of API keys before any column was added.
+type baselineAPIKey struct {
+	gorm.Model
+
+	APIKeyID string `gorm:"uniqueIndex"`
+
+	Name string `gorm:"uniqueIndex:idx_api_key_name_user_id"`
+
+	TenantID string
+
+	OrganizationID string
+	ProjectID      string
+	UserID         string `gorm:"uniqueIndex:idx_api_key_name_user_id"`
+
+	IsServiceAccount         bool
+	ExcludedFromRateLimiting bool
+
+	Secret          string
+	EncryptedSecret []byte
+}
+
+func (baselineAPIKey) TableName() string {
+	return "api_keys"
+}
+
+// newBaselineTest returns a test store migrated from the baseline schema. The store has an API key "k0"
+// created before the migration. addedColumns are added to the baseline schema without default values
+// before the migration as an earlier version did.
+func newBaselineTest(t *testing.T, addedColumns ...string) (*S, func()) {
+	db, tearDown := testdb.New(t)
+	err := db.AutoMigrate(&baselineAPIKey{})
+	assert.NoError(t, err)
+	err = db.Create(&baselineAPIKey{
+		APIKeyID:       "k0",
+		TenantID:       "t0",
+		OrganizationID: "o0",
+		ProjectID:      "p0",
+		UserID:         "u0",
+		Name:           "n0",
+		Secret:         "sk-legacysecret0",
+	}).Error
+	assert.NoError(t, err)
+	for _, c := range addedColumns {
+		err := db.Exec("ALTER TABLE api_keys ADD COLUMN " + c).Error
+		assert.NoError(t, err)
+	}
+	err = autoMigrate(db)
+	assert.NoError(t, err)
+	return New(db), tearDown
+}
+
+func TestMigrateFromBaseline_ExpiresAt(t *testing.T) {
+	tcs := []struct {
+		name         string
+		addedColumns []string
+	}{
+		{
+			name: "baseline",
+		},
+		{
+			name:         "column without default",
+			addedColumns: []string{"expires_at integer"},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			st, tearDown := newBaselineTest(t, tc.addedColumns...)
+			defer tearDown()
+
+			var n int64
+			err := st.db.Model(&APIKey{}).Where("expires_at = 0").Count(&n).Error
+			assert.NoError(t, err)
+			assert.Equal(t, int64(1), n)
+		})
+	}
+}
+
+func TestMigrateFromBaseline_Disabled(t *testing.T) {
+	tcs := []struct {
+		name         string
+		addedColumns []string
+	}{
+		{
+			name: "baseline",
+		},
+		{
+			name:         "column without default",
+			addedColumns: []string{"disabled numeric", "expires_at integer"},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			st, tearDown := newBaselineTest(t, tc.addedColumns...)
+			defer tearDown()
+
+			now := time.Now()
+			ks, err := st.ListAllActiveAPIKeys(now)
+			assert.NoError(t, err)
+			assert.Len(t, ks, 1)
+			ks, err = st.ListActiveAPIKeysByIDs([]string{"k0"}, now)
+			assert.NoError(t, err)
+			assert.Len(t, ks, 1)
+
+			// The API key is disabled when its owner is removed from the organization.
+			var ids []string
+			err = st.Transaction(func(tx *gorm.DB) error {
+				var err error
+				ids, err = DisableAPIKeysByUserIDInTransaction(tx, "o0", "", "u0")
+				return err
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"k0"}, ids)
+			k, err := st.GetAPIKeyByID("k0")
+			assert.NoError(t, err)
+			assert.True(t, k.Disabled)
+			ks, err = st.ListAllActiveAPIKeys(now)
+			assert.NoError(t, err)
+			assert.Empty(t, ks)
+		})
+	}
+}
+
+func TestMigrateFromBaseline_SecretPrefix(t *testing.T) {
+	tcs := []struct {
+		name         string
+		addedColumns []string
+	}{
+		{
+			name: "baseline",
+		},
+		{
+			name:         "column without default",
+			addedColumns: []string{"secret_prefix text", "previous_secret_prefix text"},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			st, tearDown := newBaselineTest(t, tc.addedColumns...)
+			defer tearDown()
+
+			ks, err := st.ListAPIKeysWithoutSecretPrefix()
+			assert.NoError(t, err)
+			if assert.Len(t, ks, 1) {
+				assert.Equal(t, "k0", ks[0].APIKeyID)
+			}
+
+			err = st.UpdateAPIKeySecretPrefixes("k0", "sk-legac", "")
+			assert.NoError(t, err)
+			ks, err = st.ListAPIKeysWithoutSecretPrefix()
+			assert.NoError(t, err)
+			assert.Empty(t, ks)
+			ks, err = st.ListAPIKeysBySecretPrefix("sk-legac")
+			assert.NoError(t, err)
+			assert.Len(t, ks, 1)
+		})
+	}
+}
+
+func TestMigrateFromBaseline_LastUsed(t *testing.T) {
+	tcs := []struct {
+		name         string
+		addedColumns []string
+	}{
+		{
+			name: "baseline",
+		},
+		{
+			name:         "column without default",
+			addedColumns: []string{"last_used_at integer"},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			st, tearDown := newBaselineTest(t, tc.addedColumns...)
+			defer tearDown()
+
+			err := st.UpdateAPIKeyLastUsed("k0", 100, "10.0.0.1")
+			assert.NoError(t, err)
+			k, err := st.GetAPIKeyByID("k0")
+			assert.NoError(t, err)
+			assert.Equal(t, int64(100), k.LastUsedAt)
+			assert.Equal(t, "10.0.0.1", k.LastUsedSourceIP)
+		})
+	}
+}
@@ -22,7 +22,15 @@ type User struct {
 
 // FindOrCreateUser finds or creates a user.
 func (s *S) FindOrCreateUser(userID, internalUserID string) (*User, error) {
-	return FindOrCreateUserInTransaction(s.db, userID, internalUserID)
+	var u *User
+	if err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		u, err = FindOrCreateUserInTransaction(tx, userID, internalUserID)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return u, nil
 }
 
 // FindOrCreateUserInTransaction creates a new user.
@@ -45,6 +53,9 @@ func FindOrCreateUserInTransaction(tx *gorm.DB, userID, internalUserID string) (
 	if err := tx.Create(u).Error; err != nil {
 		return nil, err
 	}
+	if err := recordChangeInTransaction(tx, ResourceKindUser, userID, ChangeTypeCreated); err != nil {
+		return nil, err
+	}
 	return u, nil
 }
 
@@ -57,7 +68,7 @@ func DeleteUserInTransaction(tx *gorm.DB, userID string) error {
 	if res.RowsAffected == 0 {
 		return gorm.ErrRecordNotFound
 	}
-	return nil
+	return recordChangeInTransaction(tx, ResourceKindUser, userID, ChangeTypeDeleted)
 }
 
 // GetUserByUserID returns the user with the given ID.
@@ -69,6 +80,15 @@ func (s *S) GetUserByUserID(userID string) (*User, error) {
 	return &u, nil
 }
 
+// ListUsersByUserIDs lists users that have the given IDs.
+func (s *S) ListUsersByUserIDs(userIDs []string) ([]User, error) {
+	var users []User
+	if err := s.db.Where("user_id IN ?", userIDs).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
 // ListAllUsers lists all users.
 func (s *S) ListAllUsers() ([]User, error) {
 	var users []User
@@ -77,3 +97,91 @@ func (s *S) ListAllUsers() ([]User, error) {
 	}
 	return users, nil
 }
+
+// ListUsersByUserIDPrefix lists users whose IDs start with the given prefix.
+func (s *S) ListUsersByUserIDPrefix(prefix string) ([]User, error) {
+	var users []User
+	if err := s.db.Where("user_id LIKE ? ESCAPE '!'", escapeLike(prefix)+"%").Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// RenameUser changes the ID of a user. The organization and project memberships, API keys, and
+// service account of the user are moved to the new ID in the same transaction.
+func (s *S) RenameUser(oldUserID, newUserID string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&User{}).Where("user_id = ?", oldUserID).Update("user_id", newUserID)
+		if err := res.Error; err != nil {
+			return err
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		if err := recordChangeInTransaction(tx, ResourceKindUser, oldUserID, ChangeTypeDeleted); err != nil {
+			return err
+		}
+		if err := recordChangeInTransaction(tx, ResourceKindUser, newUserID, ChangeTypeCreated); err != nil {
+			return err
+		}
+
+		var orgIDs []string
+		if err := tx.Model(&OrganizationUser{}).Where("user_id = ?", oldUserID).Pluck("organization_id", &orgIDs).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&OrganizationUser{}).Where("user_id = ?", oldUserID).Update("user_id", newUserID).Error; err != nil {
+			return err
+		}
+		for _, orgID := range orgIDs {
+			if err := recordChangeInTransaction(tx, ResourceKindOrganizationUser, MembershipResourceID(orgID, oldUserID), ChangeTypeDeleted); err != nil {
+				return err
+			}
+			if err := recordChangeInTransaction(tx, ResourceKindOrganizationUser, MembershipResourceID(orgID, newUserID), ChangeTypeCreated); err != nil {
+				return err
+			}
+		}
+
+		var projectIDs []string
+		if err := tx.Model(&ProjectUser{}).Where("user_id = ?", oldUserID).Pluck("project_id", &projectIDs).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&ProjectUser{}).Where("user_id = ?", oldUserID).Update("user_id", newUserID).Error; err != nil {
+			return err
+		}
+		for _, projectID := range projectIDs {
+			if err := recordChangeInTransaction(tx, ResourceKindProjectUser, MembershipResourceID(projectID, oldUserID), ChangeTypeDeleted); err != nil {
+				return err
+			}
+			if err := recordChangeInTransaction(tx, ResourceKindProjectUser, MembershipResourceID(projectID, newUserID), ChangeTypeCreated); err != nil {
+				return err
+			}
+		}
+
+		var keyIDs []string
+		if err := tx.Model(&APIKey{}).Where("user_id = ?", oldUserID).Pluck("api_key_id", &keyIDs).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&APIKey{}).Where("user_id = ?", oldUserID).Update("user_id", newUserID).Error; err != nil {
+			return err
+		}
+		for _, id := range keyIDs {
+			if err := recordChangeInTransaction(tx, ResourceKindAPIKey, id, ChangeTypeUpdated); err != nil {
+				return err
+			}
+		}
+
+		var saIDs []string
+		if err := tx.Model(&ServiceAccount{}).Where("user_id = ?", oldUserID).Pluck("service_account_id", &saIDs).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&ServiceAccount{}).Where("user_id = ?", oldUserID).Update("user_id", newUserID).Error; err != nil {
+			return err
+		}
+		for _, id := range saIDs {
+			if err := recordChangeInTransaction(tx, ResourceKindServiceAccount, id, ChangeTypeUpdated); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
 )
 
 func TestFindOrCreateUserInTransaction(t *testing.T) {
@@ -55,3 +56,58 @@ func TestDeleteUserInTransaction(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotEqual(t, u1.ID, u1Again.ID)
 }
+
+func TestRenameUser(t *testing.T) {
+	st, tearDown := NewTest(t)
+	defer tearDown()
+
+	const (
+		oldID = "system:serviceaccount:ci"
+		newID = "system:serviceaccount:t0:p0:ci"
+	)
+	_, err := FindOrCreateUserInTransaction(st.db, oldID, "iuser1")
+	assert.NoError(t, err)
+	_, err = FindOrCreateUserInTransaction(st.db, "user2", "iuser2")
+	assert.NoError(t, err)
+	_, err = st.CreateOrganizationUser("o0", oldID, "owner")
+	assert.NoError(t, err)
+	_, err = st.CreateProjectUser(CreateProjectUserParams{ProjectID: "p0", OrganizationID: "o0", UserID: oldID})
+	assert.NoError(t, err)
+	_, err = st.CreateAPIKey(APIKeySpec{APIKeyID: "k0", ProjectID: "p0", UserID: oldID, Name: "ci"})
+	assert.NoError(t, err)
+	_, err = CreateServiceAccountInTransaction(st.db, CreateServiceAccountParams{ServiceAccountID: "sa0", ProjectID: "p0", Name: "ci", UserID: oldID})
+	assert.NoError(t, err)
+
+	users, err := st.ListUsersByUserIDPrefix("system:serviceaccount:")
+	assert.NoError(t, err)
+	assert.Len(t, users, 1)
+
+	rev, err := st.GetLatestRevision()
+	assert.NoError(t, err)
+
+	err = st.RenameUser(oldID, newID)
+	assert.NoError(t, err)
+
+	u, err := st.GetUserByUserID(newID)
+	assert.NoError(t, err)
+	assert.Equal(t, "iuser1", u.InternalUserID)
+	_, err = st.GetUserByUserID(oldID)
+	assert.Error(t, err)
+	_, err = st.GetOrganizationUser("o0", newID)
+	assert.NoError(t, err)
+	_, err = st.GetProjectUser("p0", newID)
+	assert.NoError(t, err)
+	k, err := st.GetAPIKeyByID("k0")
+	assert.NoError(t, err)
+	assert.Equal(t, newID, k.UserID)
+	sa, err := GetServiceAccountByUserIDInTransaction(st.db, newID)
+	assert.NoError(t, err)
+	assert.Equal(t, "sa0", sa.ServiceAccountID)
+
+	ids, err := st.ListChangedResourceIDs(ResourceKindProjectUser, rev)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{MembershipResourceID("p0", oldID), MembershipResourceID("p0", newID)}, ids)
+
+	err = st.RenameUser(oldID, newID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
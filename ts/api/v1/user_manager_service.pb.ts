@@ -21,6 +21,28 @@ export enum ProjectRole {
   PROJECT_ROLE_MEMBER = "PROJECT_ROLE_MEMBER",
 }
 
+export enum InvitationState {
+  INVITATION_STATE_UNSPECIFIED = "INVITATION_STATE_UNSPECIFIED",
+  INVITATION_STATE_PENDING = "INVITATION_STATE_PENDING",
+  INVITATION_STATE_ACCEPTED = "INVITATION_STATE_ACCEPTED",
+  INVITATION_STATE_DECLINED = "INVITATION_STATE_DECLINED",
+  INVITATION_STATE_REVOKED = "INVITATION_STATE_REVOKED",
+  INVITATION_STATE_EXPIRED = "INVITATION_STATE_EXPIRED",
+}
+
+export enum ListAPIKeysRequestServiceAccountFilter {
+  SERVICE_ACCOUNT_FILTER_UNSPECIFIED = "SERVICE_ACCOUNT_FILTER_UNSPECIFIED",
+  SERVICE_ACCOUNT_FILTER_ONLY = "SERVICE_ACCOUNT_FILTER_ONLY",
+  SERVICE_ACCOUNT_FILTER_EXCLUDE = "SERVICE_ACCOUNT_FILTER_EXCLUDE",
+}
+
+export enum APIKeyEventType {
+  TYPE_UNSPECIFIED = "TYPE_UNSPECIFIED",
+  TYPE_CREATED = "TYPE_CREATED",
+  TYPE_UPDATED = "TYPE_UPDATED",
+  TYPE_DELETED = "TYPE_DELETED",
+}
+
 export type APIKey = {
   id?: string
   object?: string
@@ -33,6 +55,25 @@ export type APIKey = {
   organization_role?: OrganizationRole
   project_role?: ProjectRole
   excluded_from_rate_limiting?: boolean
+  expires_at?: string
+  previous_secret_expires_at?: string
+  last_used_at?: string
+  last_used_source_ip?: string
+  scopes?: string[]
+  allowed_models?: string[]
+  allowed_cidrs?: string[]
+  limits?: APIKeyLimits
+  enabled?: boolean
+  labels?: {[key: string]: string}
+  description?: string
+  imported_from?: string
+  imported_by?: string
+}
+
+export type APIKeyLimits = {
+  requests_per_minute?: string
+  tokens_per_minute?: string
+  monthly_token_budget?: string
 }
 
 export type UserOrganizationRoleBinding = {
@@ -123,6 +164,21 @@ export type CreateAPIKeyRequest = {
   is_service_account?: boolean
   role?: OrganizationRole
   excluded_from_rate_limiting?: boolean
+  expires_at?: string
+  ttl_seconds?: string
+  scopes?: string[]
+  allowed_models?: string[]
+  allowed_cidrs?: string[]
+  limits?: APIKeyLimits
+  labels?: {[key: string]: string}
+  description?: string
+  service_account_id?: string
+}
+
+export type ImportAPIKeyRequest = {
+  api_key?: CreateAPIKeyRequest
+  secret?: string
+  source?: string
 }
 
 export type ListProjectAPIKeysRequest = {
@@ -131,11 +187,22 @@ export type ListProjectAPIKeysRequest = {
 }
 
 export type ListAPIKeysRequest = {
+  after?: string
+  limit?: number
+  organization_id?: string
+  project_id?: string
+  user_id?: string
+  service_account_filter?: ListAPIKeysRequestServiceAccountFilter
+  name_prefix?: string
+  order_by?: string
+  order?: string
+  label_selector?: string
 }
 
 export type ListAPIKeysResponse = {
   object?: string
   data?: APIKey[]
+  has_more?: boolean
 }
 
 export type DeleteAPIKeyRequest = {
@@ -159,6 +226,21 @@ export type UpdateAPIKeyRequest = {
   update_mask?: GoogleProtobufField_mask.FieldMask
 }
 
+export type RotateAPIKeyRequest = {
+  id?: string
+  grace_period_seconds?: string
+}
+
+export type TransferAPIKeyRequest = {
+  id?: string
+  user_id?: string
+}
+
+export type TransferAPIKeyResponse = {
+  api_key?: APIKey
+  previous_user_id?: string
+}
+
 export type CreateOrganizationRequest = {
   title?: string
 }
@@ -171,6 +253,11 @@ export type ListOrganizationsResponse = {
   organizations?: Organization[]
 }
 
+export type GetOrganizationRequest = {
+  id?: string
+  include_summary?: boolean
+}
+
 export type DeleteOrganizationRequest = {
   id?: string
 }
@@ -181,6 +268,11 @@ export type DeleteOrganizationResponse = {
   deleted?: boolean
 }
 
+export type UpdateOrganizationRequest = {
+  organization?: Organization
+  update_mask?: GoogleProtobufField_mask.FieldMask
+}
+
 export type CreateOrganizationUserRequest = {
   organization_id?: string
   user_id?: string
@@ -198,12 +290,21 @@ export type ListOrganizationUsersResponse = {
 export type DeleteOrganizationUserRequest = {
   organization_id?: string
   user_id?: string
+  disable_api_keys?: boolean
 }
 
 export type DeleteOrganizationUserResponse = {
   id?: string
   object?: string
   deleted?: boolean
+  revoked_api_key_ids?: string[]
+  disabled_api_key_ids?: string[]
+}
+
+export type UpdateOrganizationUserRequest = {
+  organization_id?: string
+  user_id?: string
+  role?: OrganizationRole
 }
 
 export type CreateProjectRequest = {
@@ -216,10 +317,19 @@ export type CreateProjectRequest = {
 export type ListProjectsRequest = {
   organization_id?: string
   include_summary?: boolean
+  since_revision?: string
+}
+
+export type GetProjectRequest = {
+  organization_id?: string
+  id?: string
+  include_summary?: boolean
 }
 
 export type ListProjectsResponse = {
   projects?: Project[]
+  revision?: string
+  deleted_project_ids?: string[]
 }
 
 export type DeleteProjectRequest = {
@@ -248,44 +358,201 @@ export type CreateProjectUserRequest = {
 export type ListProjectUsersRequest = {
   organization_id?: string
   project_id?: string
+  since_revision?: string
 }
 
 export type ListProjectUsersResponse = {
   users?: ProjectUser[]
+  revision?: string
+  deleted_users?: ProjectUser[]
 }
 
 export type DeleteProjectUserRequest = {
   organization_id?: string
   project_id?: string
   user_id?: string
+  disable_api_keys?: boolean
 }
 
 export type DeleteProjectUserResponse = {
   id?: string
   object?: string
   deleted?: boolean
+  revoked_api_key_ids?: string[]
+  disabled_api_key_ids?: string[]
+}
+
+export type UpdateProjectUserRequest = {
+  organization_id?: string
+  project_id?: string
+  user_id?: string
+  role?: ProjectRole
+}
+
+export type ServiceAccount = {
+  id?: string
+  name?: string
+  description?: string
+  organization_id?: string
+  project_id?: string
+  user_id?: string
+  organization_role?: OrganizationRole
+  project_role?: ProjectRole
+  created_at?: string
+  api_key_count?: number
+}
+
+export type CreateServiceAccountRequest = {
+  organization_id?: string
+  project_id?: string
+  name?: string
+  description?: string
+  organization_role?: OrganizationRole
+  project_role?: ProjectRole
+}
+
+export type ListServiceAccountsRequest = {
+  organization_id?: string
+  project_id?: string
+}
+
+export type ListServiceAccountsResponse = {
+  object?: string
+  data?: ServiceAccount[]
+}
+
+export type GetServiceAccountRequest = {
+  organization_id?: string
+  project_id?: string
+  id?: string
+}
+
+export type UpdateServiceAccountRequest = {
+  service_account?: ServiceAccount
+  update_mask?: GoogleProtobufField_mask.FieldMask
+}
+
+export type DeleteServiceAccountRequest = {
+  organization_id?: string
+  project_id?: string
+  id?: string
+}
+
+export type DeleteServiceAccountResponse = {
+  id?: string
+  object?: string
+  deleted?: boolean
+  deleted_api_key_ids?: string[]
+}
+
+export type InvitationProjectBinding = {
+  project_id?: string
+  role?: ProjectRole
+}
+
+export type Invitation = {
+  id?: string
+  organization_id?: string
+  user_id?: string
+  role?: OrganizationRole
+  project_bindings?: InvitationProjectBinding[]
+  inviter_user_id?: string
+  state?: InvitationState
+  created_at?: string
+  expires_at?: string
+}
+
+export type InvitationProjectBindings = {
+  project_bindings?: InvitationProjectBinding[]
+}
+
+export type CreateInvitationRequest = {
+  organization_id?: string
+  user_id?: string
+  role?: OrganizationRole
+  project_bindings?: InvitationProjectBinding[]
+  ttl_seconds?: string
+}
+
+export type ListInvitationsRequest = {
+}
+
+export type ListInvitationsResponse = {
+  invitations?: Invitation[]
+}
+
+export type ListOrganizationInvitationsRequest = {
+  organization_id?: string
+}
+
+export type AcceptInvitationRequest = {
+  id?: string
+}
+
+export type DeclineInvitationRequest = {
+  id?: string
+}
+
+export type RevokeInvitationRequest = {
+  organization_id?: string
+  id?: string
 }
 
 export type GetUserSelfRequest = {
 }
 
 export type ListUsersRequest = {
+  since_revision?: string
 }
 
 export type ListUsersResponse = {
   users?: User[]
+  revision?: string
+  deleted_user_ids?: string[]
 }
 
 export type InternalAPIKey = {
   api_key?: APIKey
   tenant_id?: string
+  secret_hashed?: boolean
+  secret_prefix?: string
+  secret_salt?: Uint8Array
+  secret_hash?: Uint8Array
 }
 
 export type ListInternalAPIKeysRequest = {
+  since_revision?: string
 }
 
 export type ListInternalAPIKeysResponse = {
   api_keys?: InternalAPIKey[]
+  revision?: string
+  deleted_api_key_ids?: string[]
+}
+
+export type AuthenticateAPIKeyRequest = {
+  secret?: string
+}
+
+export type WatchAPIKeysRequest = {
+  since_revision?: string
+}
+
+export type APIKeyEvent = {
+  type?: APIKeyEventType
+  revision?: string
+  api_key_id?: string
+  api_keys?: InternalAPIKey[]
+}
+
+export type APIKeyUsage = {
+  api_key_id?: string
+  used_at?: string
+  source_ip?: string
+}
+
+export type ReportAPIKeyUsagesRequest = {
+  usages?: APIKeyUsage[]
 }
 
 export type InternalOrganization = {
@@ -294,10 +561,13 @@ export type InternalOrganization = {
 }
 
 export type ListInternalOrganizationsRequest = {
+  since_revision?: string
 }
 
 export type ListInternalOrganizationsResponse = {
   organizations?: InternalOrganization[]
+  revision?: string
+  deleted_organization_ids?: string[]
 }
 
 export type CreateUserInternalRequest = {
@@ -320,6 +590,15 @@ export class UsersService {
   static UpdateAPIKey(req: UpdateAPIKeyRequest, initReq?: fm.InitReq): Promise<APIKey> {
     return fm.fetchReq<UpdateAPIKeyRequest, APIKey>(`/v1/api_keys/${req["api_key.id"]}`, {...initReq, method: "PATCH", body: JSON.stringify(req)})
   }
+  static RotateAPIKey(req: RotateAPIKeyRequest, initReq?: fm.InitReq): Promise<APIKey> {
+    return fm.fetchReq<RotateAPIKeyRequest, APIKey>(`/v1/api_keys/${req["id"]}/rotate`, {...initReq, method: "POST", body: JSON.stringify(req)})
+  }
+  static TransferAPIKey(req: TransferAPIKeyRequest, initReq?: fm.InitReq): Promise<TransferAPIKeyResponse> {
+    return fm.fetchReq<TransferAPIKeyRequest, TransferAPIKeyResponse>(`/v1/api_keys/${req["id"]}/transfer`, {...initReq, method: "POST", body: JSON.stringify(req)})
+  }
+  static ImportAPIKey(req: ImportAPIKeyRequest, initReq?: fm.InitReq): Promise<APIKey> {
+    return fm.fetchReq<ImportAPIKeyRequest, APIKey>(`/v1/api_keys/import`, {...initReq, method: "POST", body: JSON.stringify(req)})
+  }
   static CreateProjectAPIKey(req: CreateAPIKeyRequest, initReq?: fm.InitReq): Promise<APIKey> {
     return fm.fetchReq<CreateAPIKeyRequest, APIKey>(`/v1/organizations/${req["organization_id"]}/projects/${req["project_id"]}/api_keys`, {...initReq, method: "POST", body: JSON.stringify(req)})
   }
@@ -335,17 +614,26 @@ export class UsersService {
   static ListOrganizations(req: ListOrganizationsRequest, initReq?: fm.InitReq): Promise<ListOrganizationsResponse> {
     return fm.fetchReq<ListOrganizationsRequest, ListOrganizationsResponse>(`/v1/organizations?${fm.renderURLSearchParams(req, [])}`, {...initReq, method: "GET"})
   }
+  static GetOrganization(req: GetOrganizationRequest, initReq?: fm.InitReq): Promise<Organization> {
+    return fm.fetchReq<GetOrganizationRequest, Organization>(`/v1/organizations/${req["id"]}?${fm.renderURLSearchParams(req, ["id"])}`, {...initReq, method: "GET"})
+  }
   static DeleteOrganization(req: DeleteOrganizationRequest, initReq?: fm.InitReq): Promise<DeleteOrganizationResponse> {
     return fm.fetchReq<DeleteOrganizationRequest, DeleteOrganizationResponse>(`/v1/organizations/${req["id"]}`, {...initReq, method: "DELETE"})
   }
+  static UpdateOrganization(req: UpdateOrganizationRequest, initReq?: fm.InitReq): Promise<Organization> {
+    return fm.fetchReq<UpdateOrganizationRequest, Organization>(`/v1/organizations/${req["organization.id"]}`, {...initReq, method: "PATCH", body: JSON.stringify(req)})
+  }
   static CreateOrganizationUser(req: CreateOrganizationUserRequest, initReq?: fm.InitReq): Promise<OrganizationUser> {
     return fm.fetchReq<CreateOrganizationUserRequest, OrganizationUser>(`/v1/organizations/${req["organization_id"]}/users`, {...initReq, method: "POST", body: JSON.stringify(req)})
   }
   static ListOrganizationUsers(req: ListOrganizationUsersRequest, initReq?: fm.InitReq): Promise<ListOrganizationUsersResponse> {
     return fm.fetchReq<ListOrganizationUsersRequest, ListOrganizationUsersResponse>(`/v1/organizations/${req["organization_id"]}/users?${fm.renderURLSearchParams(req, ["organization_id"])}`, {...initReq, method: "GET"})
   }
-  static DeleteOrganizationUser(req: DeleteOrganizationUserRequest, initReq?: fm.InitReq): Promise<GoogleProtobufEmpty.Empty> {
-    return fm.fetchReq<DeleteOrganizationUserRequest, GoogleProtobufEmpty.Empty>(`/v1/organizations/${req["organization_id"]}/users/${req["user_id"]}`, {...initReq, method: "DELETE"})
+  static DeleteOrganizationUser(req: DeleteOrganizationUserRequest, initReq?: fm.InitReq): Promise<DeleteOrganizationUserResponse> {
+    return fm.fetchReq<DeleteOrganizationUserRequest, DeleteOrganizationUserResponse>(`/v1/organizations/${req["organization_id"]}/users/${req["user_id"]}`, {...initReq, method: "DELETE"})
+  }
+  static UpdateOrganizationUser(req: UpdateOrganizationUserRequest, initReq?: fm.InitReq): Promise<OrganizationUser> {
+    return fm.fetchReq<UpdateOrganizationUserRequest, OrganizationUser>(`/v1/organizations/${req["organization_id"]}/users/${req["user_id"]}`, {...initReq, method: "PATCH", body: JSON.stringify(req)})
   }
   static CreateProject(req: CreateProjectRequest, initReq?: fm.InitReq): Promise<Project> {
     return fm.fetchReq<CreateProjectRequest, Project>(`/v1/organizations/${req["organization_id"]}/projects`, {...initReq, method: "POST", body: JSON.stringify(req)})
@@ -353,6 +641,9 @@ export class UsersService {
   static ListProjects(req: ListProjectsRequest, initReq?: fm.InitReq): Promise<ListProjectsResponse> {
     return fm.fetchReq<ListProjectsRequest, ListProjectsResponse>(`/v1/organizations/${req["organization_id"]}/projects?${fm.renderURLSearchParams(req, ["organization_id"])}`, {...initReq, method: "GET"})
   }
+  static GetProject(req: GetProjectRequest, initReq?: fm.InitReq): Promise<Project> {
+    return fm.fetchReq<GetProjectRequest, Project>(`/v1/organizations/${req["organization_id"]}/projects/${req["id"]}?${fm.renderURLSearchParams(req, ["organization_id", "id"])}`, {...initReq, method: "GET"})
+  }
   static DeleteProject(req: DeleteProjectRequest, initReq?: fm.InitReq): Promise<DeleteProjectResponse> {
     return fm.fetchReq<DeleteProjectRequest, DeleteProjectResponse>(`/v1/organizations/${req["organization_id"]}/projects/${req["id"]}`, {...initReq, method: "DELETE"})
   }
@@ -365,8 +656,44 @@ export class UsersService {
   static ListProjectUsers(req: ListProjectUsersRequest, initReq?: fm.InitReq): Promise<ListProjectUsersResponse> {
     return fm.fetchReq<ListProjectUsersRequest, ListProjectUsersResponse>(`/v1/organizations/${req["organization_id"]}/projects/${req["project_id"]}/users?${fm.renderURLSearchParams(req, ["organization_id", "project_id"])}`, {...initReq, method: "GET"})
   }
-  static DeleteProjectUser(req: DeleteProjectUserRequest, initReq?: fm.InitReq): Promise<GoogleProtobufEmpty.Empty> {
-    return fm.fetchReq<DeleteProjectUserRequest, GoogleProtobufEmpty.Empty>(`/v1/organizations/${req["organization_id"]}/projects/${req["project_id"]}/users/${req["user_id"]}`, {...initReq, method: "DELETE"})
+  static DeleteProjectUser(req: DeleteProjectUserRequest, initReq?: fm.InitReq): Promise<DeleteProjectUserResponse> {
+    return fm.fetchReq<DeleteProjectUserRequest, DeleteProjectUserResponse>(`/v1/organizations/${req["organization_id"]}/projects/${req["project_id"]}/users/${req["user_id"]}`, {...initReq, method: "DELETE"})
+  }
+  static UpdateProjectUser(req: UpdateProjectUserRequest, initReq?: fm.InitReq): Promise<ProjectUser> {
+    return fm.fetchReq<UpdateProjectUserRequest, ProjectUser>(`/v1/organizations/${req["organization_id"]}/projects/${req["project_id"]}/users/${req["user_id"]}`, {...initReq, method: "PATCH", body: JSON.stringify(req)})
+  }
+  static CreateServiceAccount(req: CreateServiceAccountRequest, initReq?: fm.InitReq): Promise<ServiceAccount> {
+    return fm.fetchReq<CreateServiceAccountRequest, ServiceAccount>(`/v1/organizations/${req["organization_id"]}/projects/${req["project_id"]}/service_accounts`, {...initReq, method: "POST", body: JSON.stringify(req)})
+  }
+  static ListServiceAccounts(req: ListServiceAccountsRequest, initReq?: fm.InitReq): Promise<ListServiceAccountsResponse> {
+    return fm.fetchReq<ListServiceAccountsRequest, ListServiceAccountsResponse>(`/v1/organizations/${req["organization_id"]}/projects/${req["project_id"]}/service_accounts?${fm.renderURLSearchParams(req, ["organization_id", "project_id"])}`, {...initReq, method: "GET"})
+  }
+  static GetServiceAccount(req: GetServiceAccountRequest, initReq?: fm.InitReq): Promise<ServiceAccount> {
+    return fm.fetchReq<GetServiceAccountRequest, ServiceAccount>(`/v1/organizations/${req["organization_id"]}/projects/${req["project_id"]}/service_accounts/${req["id"]}?${fm.renderURLSearchParams(req, ["organization_id", "project_id", "id"])}`, {...initReq, method: "GET"})
+  }
+  static UpdateServiceAccount(req: UpdateServiceAccountRequest, initReq?: fm.InitReq): Promise<ServiceAccount> {
+    return fm.fetchReq<UpdateServiceAccountRequest, ServiceAccount>(`/v1/organizations/${req["service_account.organization_id"]}/projects/${req["service_account.project_id"]}/service_accounts/${req["service_account.id"]}`, {...initReq, method: "PATCH", body: JSON.stringify(req)})
+  }
+  static DeleteServiceAccount(req: DeleteServiceAccountRequest, initReq?: fm.InitReq): Promise<DeleteServiceAccountResponse> {
+    return fm.fetchReq<DeleteServiceAccountRequest, DeleteServiceAccountResponse>(`/v1/organizations/${req["organization_id"]}/projects/${req["project_id"]}/service_accounts/${req["id"]}`, {...initReq, method: "DELETE"})
+  }
+  static CreateInvitation(req: CreateInvitationRequest, initReq?: fm.InitReq): Promise<Invitation> {
+    return fm.fetchReq<CreateInvitationRequest, Invitation>(`/v1/organizations/${req["organization_id"]}/invitations`, {...initReq, method: "POST", body: JSON.stringify(req)})
+  }
+  static ListInvitations(req: ListInvitationsRequest, initReq?: fm.InitReq): Promise<ListInvitationsResponse> {
+    return fm.fetchReq<ListInvitationsRequest, ListInvitationsResponse>(`/v1/invitations?${fm.renderURLSearchParams(req, [])}`, {...initReq, method: "GET"})
+  }
+  static ListOrganizationInvitations(req: ListOrganizationInvitationsRequest, initReq?: fm.InitReq): Promise<ListInvitationsResponse> {
+    return fm.fetchReq<ListOrganizationInvitationsRequest, ListInvitationsResponse>(`/v1/organizations/${req["organization_id"]}/invitations?${fm.renderURLSearchParams(req, ["organization_id"])}`, {...initReq, method: "GET"})
+  }
+  static AcceptInvitation(req: AcceptInvitationRequest, initReq?: fm.InitReq): Promise<Invitation> {
+    return fm.fetchReq<AcceptInvitationRequest, Invitation>(`/v1/invitations/${req["id"]}/accept`, {...initReq, method: "POST", body: JSON.stringify(req)})
+  }
+  static DeclineInvitation(req: DeclineInvitationRequest, initReq?: fm.InitReq): Promise<Invitation> {
+    return fm.fetchReq<DeclineInvitationRequest, Invitation>(`/v1/invitations/${req["id"]}/decline`, {...initReq, method: "POST", body: JSON.stringify(req)})
+  }
+  static RevokeInvitation(req: RevokeInvitationRequest, initReq?: fm.InitReq): Promise<Invitation> {
+    return fm.fetchReq<RevokeInvitationRequest, Invitation>(`/v1/organizations/${req["organization_id"]}/invitations/${req["id"]}/revoke`, {...initReq, method: "POST", body: JSON.stringify(req)})
   }
   static GetUserSelf(req: GetUserSelfRequest, initReq?: fm.InitReq): Promise<User> {
     return fm.fetchReq<GetUserSelfRequest, User>(`/v1/users:getSelf?${fm.renderURLSearchParams(req, [])}`, {...initReq, method: "GET"})
@@ -379,6 +706,15 @@ export class UsersInternalService {
   static ListInternalAPIKeys(req: ListInternalAPIKeysRequest, initReq?: fm.InitReq): Promise<ListInternalAPIKeysResponse> {
     return fm.fetchReq<ListInternalAPIKeysRequest, ListInternalAPIKeysResponse>(`/llmariner.users.server.v1.UsersInternalService/ListInternalAPIKeys`, {...initReq, method: "POST", body: JSON.stringify(req)})
   }
+  static AuthenticateAPIKey(req: AuthenticateAPIKeyRequest, initReq?: fm.InitReq): Promise<InternalAPIKey> {
+    return fm.fetchReq<AuthenticateAPIKeyRequest, InternalAPIKey>(`/llmariner.users.server.v1.UsersInternalService/AuthenticateAPIKey`, {...initReq, method: "POST", body: JSON.stringify(req)})
+  }
+  static WatchAPIKeys(req: WatchAPIKeysRequest, entityNotifier?: fm.NotifyStreamEntityArrival<APIKeyEvent>, initReq?: fm.InitReq): Promise<void> {
+    return fm.fetchStreamingRequest<WatchAPIKeysRequest, APIKeyEvent>(`/llmariner.users.server.v1.UsersInternalService/WatchAPIKeys`, entityNotifier, {...initReq, method: "POST", body: JSON.stringify(req)})
+  }
+  static ReportAPIKeyUsages(req: ReportAPIKeyUsagesRequest, initReq?: fm.InitReq): Promise<GoogleProtobufEmpty.Empty> {
+    return fm.fetchReq<ReportAPIKeyUsagesRequest, GoogleProtobufEmpty.Empty>(`/llmariner.users.server.v1.UsersInternalService/ReportAPIKeyUsages`, {...initReq, method: "POST", body: JSON.stringify(req)})
+  }
   static ListInternalOrganizations(req: ListInternalOrganizationsRequest, initReq?: fm.InitReq): Promise<ListInternalOrganizationsResponse> {
     return fm.fetchReq<ListInternalOrganizationsRequest, ListInternalOrganizationsResponse>(`/llmariner.users.server.v1.UsersInternalService/ListInternalOrganizations`, {...initReq, method: "POST", body: JSON.stringify(req)})
   }